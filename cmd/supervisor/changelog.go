@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/NERVEbing/supervisor/internal/adapter/presenter"
+	"github.com/NERVEbing/supervisor/internal/service"
+
+	"github.com/urfave/cli/v3"
+)
+
+func runChangelog(ctx context.Context, cmd *cli.Command) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	text := cfg.Changelog.Template
+	if file := cfg.Changelog.TemplateFile; file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read changelog template: %w", err)
+		}
+		text = string(data)
+	}
+
+	report, err := buildReport(ctx, cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := presenter.ToChangelog(service.BuildChangelog(report), text)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(rendered)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/NERVEbing/supervisor/internal/config"
+
+	"github.com/urfave/cli/v3"
+)
+
+// loadConfig reads the effective configuration for cmd: the --config file
+// (or the one discovered from the repository upward), the --profile within
+// it, the environment and, on top, the flags of cmd that override a config
+// entry.
+func loadConfig(cmd *cli.Command) (*config.Config, error) {
+	searchDir := cmd.String("repo")
+	if searchDir == "" {
+		searchDir = os.Getenv("SUPERVISOR_REPO_PATH")
+	}
+
+	cfg, err := config.Load(config.LoadOptions{
+		File:      cmd.String("config"),
+		SearchDir: searchDir,
+		Profile:   cmd.String("profile"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	applyFlags(cmd, cfg)
+	return cfg, nil
+}
+
+// applyFlags layers the flags given to cmd over cfg. A list flag replaces
+// the configured list rather than extending it; flags cmd does not define
+// read as unset.
+func applyFlags(cmd *cli.Command, cfg *config.Config) {
+	if repo := cmd.String("repo"); repo != "" {
+		cfg.RepoPath = repo
+	}
+
+	for flag, entry := range map[string]*[]string{
+		"exclude-suffix": &cfg.ExcludeSuffixes,
+		"exclude-path":   &cfg.ExcludePaths,
+		"include-glob":   &cfg.IncludeGlobs,
+		"exclude-glob":   &cfg.ExcludeGlobs,
+		"exclude-regex":  &cfg.ExcludeRegexes,
+		"ignore":         &cfg.IgnorePatterns,
+		"issue-project":  &cfg.IssueProjects,
+	} {
+		if values := cmd.StringSlice(flag); len(values) > 0 {
+			*entry = values
+		}
+	}
+
+	excludeClasses, onlyClasses := classFilters(cmd)
+	if len(excludeClasses) > 0 {
+		cfg.ExcludeClasses = excludeClasses
+	}
+	if len(onlyClasses) > 0 {
+		cfg.OnlyClasses = onlyClasses
+	}
+
+	if cmd.IsSet("template") {
+		cfg.Changelog.Template, cfg.Changelog.TemplateFile = "", cmd.String("template")
+	}
+	if space := cmd.String("space"); space != "" {
+		cfg.Confluence.Space = space
+	}
+	if parentID := cmd.String("parent-id"); parentID != "" {
+		cfg.Confluence.ParentID = parentID
+	}
+}
+
+func runConfigShow(ctx context.Context, cmd *cli.Command) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	data, err := config.Dump(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to render config: %w", err)
+	}
+	return writeOutput(data)
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 
+	"github.com/NERVEbing/supervisor/internal/adapter/forge"
 	"github.com/NERVEbing/supervisor/internal/adapter/git"
+	"github.com/NERVEbing/supervisor/internal/adapter/jira"
 	"github.com/NERVEbing/supervisor/internal/adapter/presenter"
 	"github.com/NERVEbing/supervisor/internal/config"
 	"github.com/NERVEbing/supervisor/internal/domain"
@@ -15,58 +20,230 @@ import (
 )
 
 func runDiff(ctx context.Context, cmd *cli.Command) error {
-	cfg := config.LoadFromEnv()
+	if cmd.String("manifest") != "" {
+		return runMultiDiff(ctx, cmd)
+	}
 
-	repoPath := cmd.String("repo")
-	if repoPath == "" {
-		repoPath = cfg.RepoPath
+	output, err := presenter.Get(cmd.String("format"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	report, err := buildReport(ctx, cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := output.Render(report)
+	if err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	return writeOutput(rendered)
+}
+
+func runMultiDiff(ctx context.Context, cmd *cli.Command) error {
+	if cmd.IsSet("repo") || cmd.IsSet("from") || cmd.IsSet("to") {
+		return fmt.Errorf("%w: --manifest cannot be combined with --repo, --from or --to", domain.ErrInvalidOption)
+	}
+
+	output, err := presenter.GetMulti(cmd.String("format"))
+	if err != nil {
+		return err
+	}
+
+	manifest, err := config.LoadManifest(cmd.String("manifest"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	multi := service.NewMultiDiffService(func(ctx context.Context, repo domain.ManifestRepo) (*domain.DiffReport, error) {
+		return generateReport(ctx, cmd, cfg, repo.Path, repo.From, repo.To)
+	}, cmd.Int("parallel"))
+
+	report := multi.GenerateReport(ctx, manifest)
+
+	rendered, err := output.RenderMulti(report)
+	if err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	if err := writeOutput(rendered); err != nil {
+		return err
 	}
+
+	if report.Summary.Failed > 0 {
+		return fmt.Errorf("%d of %d repositories failed", report.Summary.Failed, report.Summary.Repositories)
+	}
+	return nil
+}
+
+// buildReport runs the diff pipeline configured by diffFlags.
+func buildReport(ctx context.Context, cmd *cli.Command, cfg *config.Config) (*domain.DiffReport, error) {
+	repoPath := cfg.RepoPath
 	if repoPath == "" {
 		repoPath = "."
 	}
 
 	fromRef := cmd.String("from")
 	toRef := cmd.String("to")
-
-	excludeSuffixes := cmd.StringSlice("exclude-suffix")
-	if len(excludeSuffixes) == 0 {
-		excludeSuffixes = cfg.ExcludeSuffixes
+	if fromRef == "" || toRef == "" {
+		return nil, fmt.Errorf("%w: --from and --to are required", domain.ErrInvalidOption)
 	}
 
-	excludePaths := cmd.StringSlice("exclude-path")
-	if len(excludePaths) == 0 {
-		excludePaths = cfg.ExcludePaths
+	return generateReport(ctx, cmd, cfg, repoPath, fromRef, toRef)
+}
+
+// generateReport diffs one repository using the filters and issue options
+// of cfg and the remaining diffFlags (detection, history and patch
+// options), which apply to every repository in manifest mode.
+func generateReport(ctx context.Context, cmd *cli.Command, cfg *config.Config, repoPath, fromRef, toRef string) (*domain.DiffReport, error) {
+	classifier, err := service.NewClassifier(cfg.Classification)
+	if err != nil {
+		return nil, err
 	}
 
 	// Dependency Injection
-	repo, err := git.NewAdapter(repoPath)
+	repo, err := git.NewAdapter(repoPath,
+		git.WithClassifier(classifier),
+		git.WithURLTemplates(cfg.URLTemplates),
+		git.WithForgeHosts(cfg.ForgeHosts),
+		git.WithForgeURLTemplates(cfg.ForgeURLTemplates),
+	)
 	if err != nil {
-		return fmt.Errorf("failed to open repository: %w", err)
+		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
 
 	filterRule := domain.FilterRule{
-		ExcludeSuffixes: excludeSuffixes,
-		ExcludePaths:    excludePaths,
+		ExcludeSuffixes: cfg.ExcludeSuffixes,
+		ExcludePaths:    cfg.ExcludePaths,
+		IncludeGlobs:    cfg.IncludeGlobs,
+		ExcludeGlobs:    cfg.ExcludeGlobs,
+		ExcludeRegexes:  cfg.ExcludeRegexes,
+		IgnorePatterns:  cfg.IgnorePatterns,
+		ExcludeClasses:  cfg.ExcludeClasses,
+		OnlyClasses:     cfg.OnlyClasses,
+	}
+	filter, err := service.NewFilterService(filterRule)
+	if err != nil {
+		return nil, err
 	}
-	filter := service.NewFilterService(filterRule)
 
 	diffService := service.NewDiffService(repo, filter, filterRule)
 
+	threshold := cmd.Int("rename-threshold")
+	if threshold < 1 || threshold > 100 {
+		return nil, fmt.Errorf("%w: --rename-threshold must be between 1 and 100", domain.ErrInvalidOption)
+	}
+
 	opts := domain.RequestOptions{
-		IgnoreMergeCommits: true,
-		DetectRenames:      false,
+		IgnoreMergeCommits:  true,
+		FirstParent:         cmd.Bool("first-parent"),
+		HistoryOrder:        cmd.String("order"),
+		NestMerges:          cmd.Bool("nest-merges"),
+		DetectRenames:       cmd.Bool("detect-renames") || cmd.Bool("detect-copies"),
+		DetectCopies:        cmd.Bool("detect-copies"),
+		SimilarityThreshold: threshold,
+		BaselineStrategy:    cmd.String("baseline-strategy"),
+		IssueProjects:       cfg.IssueProjects,
+		SkipIgnoreFile:      cmd.Bool("no-ignore-file"),
+		ExplainFilters:      cmd.Bool("explain-filters"),
+		Patches: domain.PatchOptions{
+			Include:       cmd.Bool("include-patches"),
+			ContextLines:  cmd.Int("patch-context"),
+			MaxFileBytes:  cmd.Int("patch-file-bytes"),
+			MaxTotalBytes: cmd.Int("patch-total-bytes"),
+		},
 	}
 
 	report, err := diffService.GenerateReport(ctx, fromRef, toRef, opts)
 	if err != nil {
-		return fmt.Errorf("diff failed: %w", err)
+		return nil, fmt.Errorf("diff failed: %w", err)
 	}
 
-	jsonOutput, err := presenter.ToJSON(report)
-	if err != nil {
-		return fmt.Errorf("failed to serialize JSON: %w", err)
+	if cmd.Bool("forge-enrich") {
+		cacheDir := cfg.Forge.CacheDir
+		if cacheDir == "" {
+			if dir, err := os.UserCacheDir(); err == nil {
+				cacheDir = filepath.Join(dir, "supervisor", "forge")
+			}
+		}
+		if cmd.Bool("no-forge-cache") {
+			cacheDir = ""
+		}
+		provider, err := forge.NewAdapter(forge.Config{
+			Kind:       cfg.Forge.Type,
+			APIURL:     cfg.Forge.APIURL,
+			Repository: cfg.Forge.Repository,
+			RepoURL:    report.Repository.URL,
+			Hosts:      cfg.ForgeHosts,
+			Token:      cfg.Forge.Token,
+			CacheDir:   cacheDir,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure forge: %w", err)
+		}
+		if err := service.NewPullRequestEnricher(provider).Enrich(ctx, report); err != nil {
+			return nil, fmt.Errorf("pull request enrichment failed: %w", err)
+		}
 	}
 
-	_, err = fmt.Fprintln(os.Stdout, string(jsonOutput))
+	if cmd.Bool("jira-enrich") {
+		tracker, err := jira.NewAdapter(jira.Config{
+			BaseURL:    cfg.Jira.URL,
+			APIVersion: cfg.Jira.APIVersion,
+			AuthType:   cfg.Jira.AuthType,
+			User:       cfg.Jira.User,
+			Token:      cfg.Jira.Token,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure JIRA: %w", err)
+		}
+		if err := service.NewIssueEnricher(tracker).Enrich(ctx, report); err != nil {
+			return nil, fmt.Errorf("issue enrichment failed: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+func writeOutput(data []byte) error {
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	_, err := os.Stdout.Write(data)
 	return err
 }
+
+// classFilters collects the class names selected by the --exclude-<class>
+// and --only-* flags.
+func classFilters(cmd *cli.Command) (exclude, only []string) {
+	for flag, class := range map[string]string{
+		"exclude-generated":     domain.ClassGenerated,
+		"exclude-tests":         domain.ClassTest,
+		"exclude-config":        domain.ClassConfig,
+		"exclude-binary":        domain.ClassBinary,
+		"exclude-vendored":      domain.ClassVendored,
+		"exclude-documentation": domain.ClassDocumentation,
+	} {
+		if cmd.Bool(flag) {
+			exclude = append(exclude, class)
+		}
+	}
+	slices.Sort(exclude)
+
+	only = cmd.StringSlice("only-class")
+	if cmd.Bool("only-tests") && !slices.Contains(only, domain.ClassTest) {
+		only = append(only, domain.ClassTest)
+	}
+	return exclude, only
+}
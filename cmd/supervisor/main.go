@@ -4,6 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/adapter/presenter"
+	"github.com/NERVEbing/supervisor/internal/domain"
 
 	"github.com/urfave/cli/v3"
 )
@@ -20,37 +25,282 @@ func buildApp() *cli.Command {
 	return &cli.Command{
 		Name:  "supervisor",
 		Usage: "Multi-platform project tracking and reporting tool",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Config file, YAML or TOML (default: .supervisor.yaml, .supervisor.yml or .supervisor.toml in the repository or a parent directory)",
+			},
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Named profile from the config file to apply",
+			},
+		},
 		Commands: []*cli.Command{
 			{
 				Name:  "diff",
-				Usage: "Generate structured JSON diff between two git references",
-				Flags: []cli.Flag{
+				Usage: "Generate a structured diff report between two git references",
+				Flags: append(diffFlags(),
 					&cli.StringFlag{
-						Name:     "repo",
-						Usage:    "Path to local git repository",
-						Required: false,
+						Name:  "format",
+						Usage: "Output format: " + strings.Join(presenter.Formats(), ", "),
+						Value: "json",
 					},
 					&cli.StringFlag{
-						Name:     "from",
-						Usage:    "Starting git reference (tag/branch/commit)",
-						Required: true,
+						Name:  "manifest",
+						Usage: "YAML manifest listing repositories and refs to diff in one run (replaces --repo, --from and --to)",
+					},
+					&cli.IntFlag{
+						Name:  "parallel",
+						Usage: "Maximum number of repositories diffed concurrently with --manifest",
+						Value: 4,
+					},
+				),
+				Action: runDiff,
+			},
+			{
+				Name:   "changelog",
+				Usage:  "Generate a Keep a Changelog section from the commits between two git references",
+				Flags:  append(diffFlags(), changelogFlags()...),
+				Action: runChangelog,
+			},
+			{
+				Name:  "pack",
+				Usage: "Bundle a diff report with its patches into a token budget for LLM prompts",
+				Flags: append(diffFlags(),
+					&cli.IntFlag{
+						Name:  "max-tokens",
+						Usage: "Estimated token budget for the bundle",
+						Value: domain.DefaultPackMaxTokens,
 					},
 					&cli.StringFlag{
-						Name:     "to",
-						Usage:    "Target git reference (tag/branch/commit)",
-						Required: true,
+						Name:  "format",
+						Usage: "Output format: " + strings.Join(presenter.PackFormats(), ", "),
+						Value: "markdown",
 					},
-					&cli.StringSliceFlag{
-						Name:  "exclude-suffix",
-						Usage: "File suffixes to exclude (e.g., .png)",
+				),
+				Action: runPack,
+			},
+			{
+				Name:  "publish",
+				Usage: "Publish a diff report to an external documentation system",
+				Commands: []*cli.Command{
+					{
+						Name:  "confluence",
+						Usage: "Create or update a Confluence page with the rendered report",
+						Flags: append(slices.Concat(diffFlags(), confluenceFlags()),
+							&cli.StringFlag{
+								Name:  "page-id",
+								Usage: "Update this page instead of looking it up by space and title",
+							},
+							&cli.StringFlag{
+								Name:  "title",
+								Usage: "Page title (default: \"<repo> <from> → <to>\")",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "Print the storage-format body instead of publishing it",
+							},
+						),
+						Action: runPublishConfluence,
 					},
-					&cli.StringSliceFlag{
-						Name:  "exclude-path",
-						Usage: "Path prefixes to exclude (e.g., vendor/)",
+				},
+			},
+			{
+				Name:  "config",
+				Usage: "Inspect the configuration",
+				Commands: []*cli.Command{
+					{
+						Name:   "show",
+						Usage:  "Print the effective configuration: file, profile, environment and the given flags merged (secrets redacted)",
+						Flags:  slices.Concat(diffFlags(), changelogFlags(), confluenceFlags()),
+						Action: runConfigShow,
 					},
 				},
-				Action: runDiff,
 			},
+			{
+				Name:   "schema",
+				Usage:  "Print the JSON Schema (draft 2020-12) for report schema v" + domain.ReportSchemaVersion,
+				Action: runSchema,
+			},
+			{
+				Name:      "validate",
+				Usage:     "Validate a saved JSON report against schema v" + domain.ReportSchemaVersion + ", including reports from older releases",
+				ArgsUsage: "<file|->",
+				Action:    runValidate,
+			},
+		},
+	}
+}
+
+// changelogFlags override the changelog settings of the config file.
+func changelogFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "template",
+			Usage: "Go text/template file for the section (default: built-in Keep a Changelog layout)",
+		},
+	}
+}
+
+// confluenceFlags override where the config file places published pages.
+func confluenceFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "space",
+			Usage: "Confluence space key (env: SUPERVISOR_CONFLUENCE_SPACE)",
+		},
+		&cli.StringFlag{
+			Name:  "parent-id",
+			Usage: "ID of the parent page for new pages (env: SUPERVISOR_CONFLUENCE_PARENT)",
+		},
+	}
+}
+
+// diffFlags are shared by every command that generates a diff report.
+func diffFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "repo",
+			Usage:    "Path to local git repository",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "Starting git reference (tag/branch/commit)",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "Target git reference (tag/branch/commit)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude-suffix",
+			Usage: "File suffixes to exclude (e.g., .png)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude-path",
+			Usage: "Path prefixes to exclude (e.g., vendor/)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "include-glob",
+			Usage: "Only keep paths matching these doublestar globs (e.g., 'src/**')",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude-glob",
+			Usage: "Doublestar globs to exclude (e.g., '**/testdata/**', '*.generated.*')",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude-regex",
+			Usage: "Regular expressions matched against the path to exclude",
+		},
+		&cli.StringSliceFlag{
+			Name:  "ignore",
+			Usage: "gitignore-style patterns to exclude; '!pattern' re-includes",
+		},
+		&cli.BoolFlag{
+			Name:  "no-ignore-file",
+			Usage: "Do not read " + domain.IgnoreFileName + " from the target ref",
+		},
+		&cli.BoolFlag{
+			Name:  "exclude-generated",
+			Usage: "Exclude generated files",
+		},
+		&cli.BoolFlag{
+			Name:  "exclude-tests",
+			Usage: "Exclude test files",
+		},
+		&cli.BoolFlag{
+			Name:  "exclude-config",
+			Usage: "Exclude configuration files",
+		},
+		&cli.BoolFlag{
+			Name:  "exclude-binary",
+			Usage: "Exclude binary files",
+		},
+		&cli.BoolFlag{
+			Name:  "exclude-vendored",
+			Usage: "Exclude vendored files",
+		},
+		&cli.BoolFlag{
+			Name:  "exclude-documentation",
+			Usage: "Exclude documentation files",
+		},
+		&cli.StringSliceFlag{
+			Name:  "only-class",
+			Usage: "Only keep files of these classes (" + strings.Join(domain.Classes, ", ") + ")",
+		},
+		&cli.BoolFlag{
+			Name:  "only-tests",
+			Usage: "Only keep test files (same as --only-class test)",
+		},
+		&cli.BoolFlag{
+			Name:  "explain-filters",
+			Usage: "List every excluded file with the rule that matched it",
+		},
+		&cli.BoolFlag{
+			Name:  "include-patches",
+			Usage: "Embed unified-diff hunks for each file",
+		},
+		&cli.IntFlag{
+			Name:  "patch-context",
+			Usage: "Context lines around each hunk with --include-patches",
+			Value: domain.DefaultPatchContextLines,
+		},
+		&cli.IntFlag{
+			Name:  "patch-file-bytes",
+			Usage: "Truncate each file's patch beyond this many bytes (0 for no limit)",
+			Value: domain.DefaultPatchMaxFileBytes,
+		},
+		&cli.IntFlag{
+			Name:  "patch-total-bytes",
+			Usage: "Truncate patches once the report holds this many patch bytes (0 for no limit)",
+			Value: domain.DefaultPatchMaxTotalBytes,
+		},
+		&cli.BoolFlag{
+			Name:  "detect-renames",
+			Usage: "Pair deleted and added files with similar content as renames",
+		},
+		&cli.BoolFlag{
+			Name:  "detect-copies",
+			Usage: "Also detect added files copied from deleted or modified files (implies --detect-renames)",
+		},
+		&cli.IntFlag{
+			Name:  "rename-threshold",
+			Usage: "Minimum similarity percentage (1-100) for rename and copy detection",
+			Value: 50,
+		},
+		&cli.StringSliceFlag{
+			Name:  "issue-project",
+			Usage: "Issue project key (or key pattern) to extract from commits and branches (e.g., ABC)",
+		},
+		&cli.BoolFlag{
+			Name:  "jira-enrich",
+			Usage: "Fetch extracted issues from JIRA (requires SUPERVISOR_JIRA_URL)",
+		},
+		&cli.BoolFlag{
+			Name:  "forge-enrich",
+			Usage: "Fetch recognised pull requests from GitHub, GitLab or Gitea (token from SUPERVISOR_FORGE_TOKEN)",
+		},
+		&cli.BoolFlag{
+			Name:  "no-forge-cache",
+			Usage: "Fetch pull requests again instead of reusing the on-disk cache",
+		},
+		&cli.StringFlag{
+			Name:  "baseline-strategy",
+			Usage: "Baseline for the tree diff: merge-base, direct, or two-dot",
+			Value: "merge-base",
+		},
+		&cli.BoolFlag{
+			Name:  "first-parent",
+			Usage: "Only list the first-parent chain of --to in history (the mainline, merges included)",
+		},
+		&cli.StringFlag{
+			Name:  "order",
+			Usage: "History order, oldest first: topo, author-date, or committer-date",
+			Value: domain.HistoryOrderAuthorDate,
+		},
+		&cli.BoolFlag{
+			Name:  "nest-merges",
+			Usage: "List the mainline with each merge's commits nested under it (implies --first-parent)",
 		},
 	}
 }
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
+	"slices"
 	"testing"
 
+	"github.com/NERVEbing/supervisor/internal/config"
+	"github.com/NERVEbing/supervisor/internal/domain"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
 )
 
 func TestBuildApp_HasDiffCommand(t *testing.T) {
@@ -23,3 +30,106 @@ func TestBuildApp_HasDiffCommand(t *testing.T) {
 
 	assert.True(t, foundDiff, "should have 'diff' command")
 }
+
+func TestBuildApp_HasSchemaCommands(t *testing.T) {
+	cmd := buildApp()
+
+	names := map[string]bool{}
+	for _, subCmd := range cmd.Commands {
+		names[subCmd.Name] = true
+	}
+
+	assert.True(t, names["schema"], "should have 'schema' command")
+	assert.True(t, names["validate"], "should have 'validate' command")
+}
+
+func TestBuildApp_HasPublishConfluenceCommand(t *testing.T) {
+	cmd := buildApp()
+
+	var found bool
+	for _, subCmd := range cmd.Commands {
+		if subCmd.Name != "publish" {
+			continue
+		}
+		for _, target := range subCmd.Commands {
+			if target.Name == "confluence" {
+				found = true
+			}
+		}
+	}
+
+	assert.True(t, found, "should have 'publish confluence' command")
+}
+
+func TestBuildApp_HasConfigShowCommand(t *testing.T) {
+	cmd := buildApp()
+
+	var found bool
+	for _, subCmd := range cmd.Commands {
+		if subCmd.Name != "config" {
+			continue
+		}
+		for _, action := range subCmd.Commands {
+			if action.Name == "show" {
+				found = true
+			}
+		}
+	}
+
+	assert.True(t, found, "should have 'config show' command")
+}
+
+func TestBuildApp_HasPackCommand(t *testing.T) {
+	cmd := buildApp()
+
+	var found bool
+	for _, subCmd := range cmd.Commands {
+		if subCmd.Name == "pack" {
+			found = true
+		}
+	}
+
+	assert.True(t, found, "should have 'pack' command")
+}
+
+func TestBuildApp_HasChangelogCommand(t *testing.T) {
+	cmd := buildApp()
+
+	var found bool
+	for _, subCmd := range cmd.Commands {
+		if subCmd.Name == "changelog" {
+			found = true
+		}
+	}
+
+	assert.True(t, found, "should have 'changelog' command")
+}
+
+func TestApplyFlags(t *testing.T) {
+	cfg := &config.Config{
+		RepoPath:      "/srv/app",
+		ExcludePaths:  []string{"vendor/"},
+		IssueProjects: []string{"ABC"},
+		Changelog:     config.ChangelogConfig{Template: "inline"},
+		Confluence:    config.ConfluenceConfig{Space: "DOC", ParentID: "42"},
+	}
+	cmd := &cli.Command{
+		Name:  "show",
+		Flags: slices.Concat(diffFlags(), changelogFlags(), confluenceFlags()),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			applyFlags(cmd, cfg)
+			return nil
+		},
+	}
+
+	err := cmd.Run(context.Background(), []string{"show",
+		"--exclude-path", "third_party/", "--exclude-tests", "--template", "release.tmpl", "--space", "REL"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/srv/app", cfg.RepoPath)
+	assert.Equal(t, []string{"third_party/"}, cfg.ExcludePaths)
+	assert.Equal(t, []string{"ABC"}, cfg.IssueProjects)
+	assert.Equal(t, []string{domain.ClassTest}, cfg.ExcludeClasses)
+	assert.Equal(t, config.ChangelogConfig{TemplateFile: "release.tmpl"}, cfg.Changelog)
+	assert.Equal(t, config.ConfluenceConfig{Space: "REL", ParentID: "42"}, cfg.Confluence)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NERVEbing/supervisor/internal/adapter/presenter"
+	"github.com/NERVEbing/supervisor/internal/domain"
+	"github.com/NERVEbing/supervisor/internal/service"
+
+	"github.com/urfave/cli/v3"
+)
+
+func runPack(ctx context.Context, cmd *cli.Command) error {
+	// The pack is bounded by tokens, so patches are collected whole unless
+	// the byte budgets are asked for explicitly.
+	for name, value := range map[string]string{
+		"include-patches":   "true",
+		"patch-file-bytes":  "0",
+		"patch-total-bytes": "0",
+	} {
+		if !cmd.IsSet(name) {
+			if err := cmd.Set(name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	report, err := buildReport(ctx, cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	pack, err := service.PackReport(report, domain.PackOptions{MaxTokens: cmd.Int("max-tokens")})
+	if err != nil {
+		return err
+	}
+
+	rendered, err := presenter.RenderPack(cmd.String("format"), pack)
+	if err != nil {
+		return fmt.Errorf("failed to render pack: %w", err)
+	}
+
+	return writeOutput(rendered)
+}
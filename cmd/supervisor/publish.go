@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NERVEbing/supervisor/internal/adapter/confluence"
+	"github.com/NERVEbing/supervisor/internal/adapter/presenter"
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/urfave/cli/v3"
+)
+
+func runPublishConfluence(ctx context.Context, cmd *cli.Command) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	report, err := buildReport(ctx, cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	body, err := presenter.ToConfluenceStorage(report)
+	if err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	if cmd.Bool("dry-run") {
+		return writeOutput(body)
+	}
+
+	title := cmd.String("title")
+	if title == "" {
+		title = fmt.Sprintf("%s %s → %s", report.Repository.Name, report.Resolution.From.Ref, report.Resolution.To.Ref)
+	}
+
+	publisher, err := confluence.NewAdapter(confluence.Config{
+		BaseURL:  cfg.Confluence.URL,
+		AuthType: cfg.Confluence.AuthType,
+		User:     cfg.Confluence.User,
+		Token:    cfg.Confluence.Token,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to configure Confluence: %w", err)
+	}
+
+	page, err := publisher.PublishPage(ctx, domain.Page{
+		SpaceKey: cfg.Confluence.Space,
+		ParentID: cfg.Confluence.ParentID,
+		PageID:   cmd.String("page-id"),
+		Title:    title,
+		Body:     string(body),
+	})
+	if err != nil {
+		return fmt.Errorf("publish failed: %w", err)
+	}
+
+	return writeOutput(fmt.Appendf(nil, "%s page %s %q (version %d) %s", page.Action, page.ID, page.Title, page.Version, page.URL))
+}
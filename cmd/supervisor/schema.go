@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/NERVEbing/supervisor/internal/adapter/presenter"
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/urfave/cli/v3"
+)
+
+func runSchema(ctx context.Context, cmd *cli.Command) error {
+	schema, err := presenter.JSONSchema()
+	if err != nil {
+		return fmt.Errorf("failed to generate schema: %w", err)
+	}
+	return writeOutput(schema)
+}
+
+func runValidate(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.Args().First()
+	if path == "" {
+		return fmt.Errorf("%w: validate requires a report file (or - for stdin)", domain.ErrInvalidOption)
+	}
+
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read report: %w", err)
+	}
+
+	violations, err := presenter.ValidateJSON(data)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range violations {
+		fmt.Fprintln(os.Stderr, v.Error())
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%s does not conform to schema v%s (%d violations)", path, domain.ReportSchemaVersion, len(violations))
+	}
+
+	_, err = fmt.Fprintf(os.Stdout, "%s: valid\n", path)
+	return err
+}
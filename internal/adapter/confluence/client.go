@@ -0,0 +1,328 @@
+package confluence
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+)
+
+type Config struct {
+	BaseURL    string
+	AuthType   string
+	User       string
+	Token      string
+	HTTPClient *http.Client
+}
+
+type Adapter struct {
+	baseURL  *url.URL
+	authType string
+	user     string
+	token    string
+	client   *http.Client
+}
+
+func NewAdapter(cfg Config) (domain.PagePublisher, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("%w: Confluence base URL is required", domain.ErrInvalidOption)
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("%w: invalid Confluence base URL %q", domain.ErrInvalidOption, cfg.BaseURL)
+	}
+
+	authType := strings.ToLower(cfg.AuthType)
+	if authType == "" {
+		authType = AuthBearer
+		if cfg.User != "" {
+			authType = AuthBasic
+		}
+	}
+	if authType != AuthBasic && authType != AuthBearer {
+		return nil, fmt.Errorf("%w: unsupported Confluence auth type %q", domain.ErrInvalidOption, cfg.AuthType)
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Adapter{
+		baseURL:  baseURL,
+		authType: authType,
+		user:     cfg.User,
+		token:    cfg.Token,
+		client:   client,
+	}, nil
+}
+
+type content struct {
+	ID        string          `json:"id,omitempty"`
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Space     *contentSpace   `json:"space,omitempty"`
+	Ancestors []contentRef    `json:"ancestors,omitempty"`
+	Version   *contentVersion `json:"version,omitempty"`
+	Body      *contentBody    `json:"body,omitempty"`
+	Links     *contentLinks   `json:"_links,omitempty"`
+}
+
+type contentSpace struct {
+	Key string `json:"key"`
+}
+
+type contentRef struct {
+	ID string `json:"id"`
+}
+
+type contentVersion struct {
+	Number int `json:"number"`
+}
+
+type contentBody struct {
+	Storage contentStorage `json:"storage"`
+}
+
+type contentStorage struct {
+	Value          string `json:"value"`
+	Representation string `json:"representation"`
+}
+
+type contentLinks struct {
+	WebUI string `json:"webui"`
+}
+
+type contentList struct {
+	Results []content `json:"results"`
+}
+
+// PublishPage creates the page or, when it already exists, updates it with
+// the next version number. A page whose title and body already match is
+// left untouched so that repeated runs do not pile up versions; bodies are
+// compared after normaliseStorage, as Confluence reformats what it stores.
+func (a *Adapter) PublishPage(ctx context.Context, page domain.Page) (*domain.PublishedPage, error) {
+	if page.Title == "" {
+		return nil, fmt.Errorf("%w: Confluence page title is required", domain.ErrInvalidOption)
+	}
+
+	existing, err := a.findPage(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		if page.SpaceKey == "" {
+			return nil, fmt.Errorf("%w: Confluence space key is required to create a page", domain.ErrInvalidOption)
+		}
+		created := content{
+			Type:  "page",
+			Title: page.Title,
+			Space: &contentSpace{Key: page.SpaceKey},
+			Body:  storageBody(page.Body),
+		}
+		if page.ParentID != "" {
+			created.Ancestors = []contentRef{{ID: page.ParentID}}
+		}
+
+		var result content
+		if err := a.do(ctx, http.MethodPost, a.baseURL.JoinPath("rest", "api", "content"), created, &result); err != nil {
+			return nil, fmt.Errorf("failed to create page %q: %w", page.Title, err)
+		}
+		return a.published(result, domain.PageCreated), nil
+	}
+
+	if existing.Title == page.Title && existing.Body != nil && normaliseStorage(existing.Body.Storage.Value) == normaliseStorage(page.Body) {
+		return a.published(*existing, domain.PageUnchanged), nil
+	}
+
+	version := 1
+	if existing.Version != nil {
+		version = existing.Version.Number
+	}
+	updated := content{
+		ID:      existing.ID,
+		Type:    "page",
+		Title:   page.Title,
+		Version: &contentVersion{Number: version + 1},
+		Body:    storageBody(page.Body),
+	}
+	if existing.Space != nil {
+		updated.Space = existing.Space
+	}
+	if page.ParentID != "" {
+		updated.Ancestors = []contentRef{{ID: page.ParentID}}
+	}
+
+	var result content
+	if err := a.do(ctx, http.MethodPut, a.baseURL.JoinPath("rest", "api", "content", existing.ID), updated, &result); err != nil {
+		return nil, fmt.Errorf("failed to update page %s: %w", existing.ID, err)
+	}
+	return a.published(result, domain.PageUpdated), nil
+}
+
+// findPage returns the page addressed by ID, or by space and title, or nil
+// when no such page exists yet.
+func (a *Adapter) findPage(ctx context.Context, page domain.Page) (*content, error) {
+	expand := url.Values{"expand": {"version,body.storage,space"}}
+
+	if page.PageID != "" {
+		endpoint := a.baseURL.JoinPath("rest", "api", "content", page.PageID)
+		endpoint.RawQuery = expand.Encode()
+
+		var found content
+		if err := a.do(ctx, http.MethodGet, endpoint, nil, &found); err != nil {
+			return nil, fmt.Errorf("failed to fetch page %s: %w", page.PageID, err)
+		}
+		return &found, nil
+	}
+
+	if page.SpaceKey == "" {
+		return nil, fmt.Errorf("%w: Confluence space key or page ID is required", domain.ErrInvalidOption)
+	}
+
+	endpoint := a.baseURL.JoinPath("rest", "api", "content")
+	query := url.Values{
+		"type":     {"page"},
+		"spaceKey": {page.SpaceKey},
+		"title":    {page.Title},
+	}
+	for k, v := range expand {
+		query[k] = v
+	}
+	endpoint.RawQuery = query.Encode()
+
+	var list contentList
+	if err := a.do(ctx, http.MethodGet, endpoint, nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to look up page %q: %w", page.Title, err)
+	}
+	if len(list.Results) == 0 {
+		return nil, nil
+	}
+	return &list.Results[0], nil
+}
+
+func (a *Adapter) do(ctx context.Context, method string, endpoint *url.URL, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch a.authType {
+	case AuthBasic:
+		req.SetBasicAuth(a.user, a.token)
+	case AuthBearer:
+		if a.token != "" {
+			req.Header.Set("Authorization", "Bearer "+a.token)
+		}
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, endpoint.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (a *Adapter) published(c content, action string) *domain.PublishedPage {
+	page := &domain.PublishedPage{
+		ID:     c.ID,
+		Title:  c.Title,
+		Action: action,
+	}
+	if c.Version != nil {
+		page.Version = c.Version.Number
+	}
+	if c.Links != nil && c.Links.WebUI != "" {
+		page.URL = a.baseURL.String() + c.Links.WebUI
+	}
+	return page
+}
+
+func storageBody(value string) *contentBody {
+	return &contentBody{Storage: contentStorage{Value: value, Representation: "storage"}}
+}
+
+// normaliseStorage renders a storage-format body in a canonical form for
+// comparison: elements with their attributes sorted by name, self-closing
+// tags expanded and text trimmed, with runs of whitespace collapsed to one
+// space. Whitespace next to a tag is lost, which only hides edits that
+// change nothing else. Entities are decoded, so "&nbsp;" and "&#160;"
+// compare equal. A body that does not parse only has its whitespace
+// collapsed.
+func normaliseStorage(body string) string {
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var b strings.Builder
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return b.String()
+		}
+		if err != nil {
+			return strings.Join(strings.Fields(body), " ")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			attrs := slices.Clone(t.Attr)
+			slices.SortFunc(attrs, func(x, y xml.Attr) int {
+				return cmp.Or(cmp.Compare(x.Name.Space, y.Name.Space), cmp.Compare(x.Name.Local, y.Name.Local))
+			})
+			b.WriteString("<" + qualifiedName(t.Name))
+			for _, attr := range attrs {
+				fmt.Fprintf(&b, " %s=%q", qualifiedName(attr.Name), attr.Value)
+			}
+			b.WriteString(">")
+		case xml.EndElement:
+			b.WriteString("</" + qualifiedName(t.Name) + ">")
+		case xml.CharData:
+			b.WriteString(strings.Join(strings.Fields(string(t)), " "))
+		}
+	}
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
@@ -0,0 +1,217 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConfluence is an in-memory stand-in for the content REST API.
+type fakeConfluence struct {
+	*httptest.Server
+	mu       sync.Mutex
+	pages    map[string]*content
+	nextID   int
+	requests []string
+	lastAuth string
+}
+
+func newFakeConfluence(t *testing.T) *fakeConfluence {
+	f := &fakeConfluence{pages: map[string]*content{}, nextID: 100}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /wiki/rest/api/content", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "page", q.Get("type"))
+		assert.Contains(t, q.Get("expand"), "body.storage")
+
+		list := contentList{Results: []content{}}
+		for _, p := range f.pages {
+			if p.Space.Key == q.Get("spaceKey") && p.Title == q.Get("title") {
+				list.Results = append(list.Results, *p)
+			}
+		}
+		writeJSON(w, list)
+	})
+
+	mux.HandleFunc("GET /wiki/rest/api/content/{id}", func(w http.ResponseWriter, r *http.Request) {
+		p, ok := f.pages[r.PathValue("id")]
+		if !ok {
+			http.Error(w, `{"message":"No content found"}`, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, p)
+	})
+
+	mux.HandleFunc("POST /wiki/rest/api/content", func(w http.ResponseWriter, r *http.Request) {
+		var c content
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&c))
+		assert.Equal(t, "storage", c.Body.Storage.Representation)
+
+		c.ID = fmt.Sprint(f.nextID)
+		f.nextID++
+		c.Version = &contentVersion{Number: 1}
+		c.Links = &contentLinks{WebUI: "/spaces/" + c.Space.Key + "/pages/" + c.ID}
+		f.pages[c.ID] = &c
+		writeJSON(w, c)
+	})
+
+	mux.HandleFunc("PUT /wiki/rest/api/content/{id}", func(w http.ResponseWriter, r *http.Request) {
+		existing, ok := f.pages[r.PathValue("id")]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		var c content
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&c))
+		if c.Version.Number != existing.Version.Number+1 {
+			http.Error(w, `{"message":"Version must be incremented"}`, http.StatusConflict)
+			return
+		}
+		c.Space = existing.Space
+		c.Links = existing.Links
+		f.pages[c.ID] = &c
+		writeJSON(w, c)
+	})
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+		f.lastAuth = r.Header.Get("Authorization")
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestPublishPage_CreateUpdateUnchanged(t *testing.T) {
+	server := newFakeConfluence(t)
+
+	publisher, err := NewAdapter(Config{BaseURL: server.URL + "/wiki/", Token: "pat"})
+	require.NoError(t, err)
+
+	page := domain.Page{SpaceKey: "REL", ParentID: "42", Title: "widget v1.0.0 → v1.1.0", Body: "<p>first</p>"}
+
+	created, err := publisher.PublishPage(context.Background(), page)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.PublishedPage{
+		ID:      "100",
+		Title:   page.Title,
+		URL:     server.URL + "/wiki/spaces/REL/pages/100",
+		Version: 1,
+		Action:  domain.PageCreated,
+	}, created)
+	assert.Equal(t, []contentRef{{ID: "42"}}, server.pages["100"].Ancestors)
+	assert.Equal(t, "Bearer pat", server.lastAuth)
+
+	unchanged, err := publisher.PublishPage(context.Background(), page)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PageUnchanged, unchanged.Action)
+	assert.Equal(t, 1, unchanged.Version)
+
+	// Confluence reformats the body it stores; that is not a change.
+	server.pages["100"].Body.Storage.Value = "<p>\n  first\n</p>"
+	unchanged, err = publisher.PublishPage(context.Background(), page)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PageUnchanged, unchanged.Action)
+
+	page.Body = "<p>second</p>"
+	updated, err := publisher.PublishPage(context.Background(), page)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PageUpdated, updated.Action)
+	assert.Equal(t, "100", updated.ID)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, "<p>second</p>", server.pages["100"].Body.Storage.Value)
+	assert.Len(t, server.pages, 1)
+}
+
+func TestPublishPage_ByPageID(t *testing.T) {
+	server := newFakeConfluence(t)
+	server.pages["7"] = &content{
+		ID:      "7",
+		Type:    "page",
+		Title:   "Old title",
+		Space:   &contentSpace{Key: "REL"},
+		Version: &contentVersion{Number: 4},
+		Body:    storageBody("<p>old</p>"),
+	}
+
+	publisher, err := NewAdapter(Config{BaseURL: server.URL + "/wiki", User: "bot", Token: "secret"})
+	require.NoError(t, err)
+
+	published, err := publisher.PublishPage(context.Background(), domain.Page{PageID: "7", Title: "New title", Body: "<p>old</p>"})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.PageUpdated, published.Action)
+	assert.Equal(t, 5, published.Version)
+	assert.Equal(t, "New title", server.pages["7"].Title)
+	assert.Equal(t, []string{"GET /wiki/rest/api/content/7", "PUT /wiki/rest/api/content/7"}, server.requests)
+}
+
+func TestPublishPage_Errors(t *testing.T) {
+	server := newFakeConfluence(t)
+
+	publisher, err := NewAdapter(Config{BaseURL: server.URL + "/wiki"})
+	require.NoError(t, err)
+
+	_, err = publisher.PublishPage(context.Background(), domain.Page{PageID: "404", Title: "Missing"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+
+	_, err = publisher.PublishPage(context.Background(), domain.Page{Title: "No space"})
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+
+	_, err = publisher.PublishPage(context.Background(), domain.Page{SpaceKey: "REL"})
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+}
+
+func TestNewAdapter_ValidatesConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{},
+		{BaseURL: "not a url"},
+		{BaseURL: "https://wiki.example.com", AuthType: "oauth"},
+	} {
+		_, err := NewAdapter(cfg)
+		assert.ErrorIs(t, err, domain.ErrInvalidOption, "%+v", cfg)
+	}
+}
+
+func TestNormaliseStorage(t *testing.T) {
+	same := []struct{ stored, rendered string }{
+		{"<p>first</p>", "<p>first</p>"},
+		{"<p>first</p>\n<p>second</p>", "<p>first</p><p>second</p>"},
+		{"<p>a  long\n line</p>", "<p>a long line</p>"},
+		{`<table class="wrapped" data-layout="wide"><tbody></tbody></table>`, `<table data-layout="wide" class="wrapped"><tbody/></table>`},
+		{`<ac:structured-macro ac:name="code" ac:schema-version="1"/>`, `<ac:structured-macro ac:schema-version="1" ac:name="code"></ac:structured-macro>`},
+		{"<p>a<br /></p>", "<p>a<br></br></p>"},
+		{"<p>a&nbsp;&amp; b</p>", "<p>a&#160;&#38; b</p>"},
+	}
+	for _, tt := range same {
+		assert.Equal(t, normaliseStorage(tt.rendered), normaliseStorage(tt.stored), tt.stored)
+	}
+
+	different := []struct{ stored, rendered string }{
+		{"<p>first</p>", "<p>second</p>"},
+		{"<p>a b</p>", "<p>ab</p>"},
+		{`<a href="/x">x</a>`, `<a href="/y">x</a>`},
+		{"<p>first</p>", "<h1>first</h1>"},
+	}
+	for _, tt := range different {
+		assert.NotEqual(t, normaliseStorage(tt.rendered), normaliseStorage(tt.stored), tt.stored)
+	}
+}
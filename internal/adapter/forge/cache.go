@@ -0,0 +1,83 @@
+package forge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// diskCache keeps fetched pull requests as one JSON file each, named by a
+// hash of the forge, repository and number. Tokens are not part of the
+// key, so the cache is shared by whoever can read the directory.
+type diskCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	FetchedAt   time.Time                `json:"fetched_at"`
+	PullRequest *domain.ForgePullRequest `json:"pull_request"`
+}
+
+// get returns the cached request for key, or nil when there is none or it
+// is older than the TTL. An unreadable entry counts as missing.
+func (c *diskCache) get(key string) (*domain.ForgePullRequest, error) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read forge cache: %w", err)
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.PullRequest == nil {
+		return nil, nil
+	}
+	if c.now().Sub(entry.FetchedAt) > c.ttl {
+		return nil, nil
+	}
+	return entry.PullRequest, nil
+}
+
+func (c *diskCache) put(key string, pr *domain.ForgePullRequest) error {
+	data, err := json.Marshal(cacheEntry{FetchedAt: c.now().UTC(), PullRequest: pr})
+	if err != nil {
+		return fmt.Errorf("failed to encode forge cache entry: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create forge cache: %w", err)
+	}
+
+	// Write then rename, so a concurrent run never reads half an entry.
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return fmt.Errorf("failed to write forge cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write forge cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write forge cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("failed to write forge cache: %w", err)
+	}
+	return nil
+}
+
+func (c *diskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,340 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// Config selects a forge and the repository on it. Kind, APIURL and
+// Repository are derived from RepoURL, the repository's web URL, when they
+// are empty: the public forges and the self-hosted ones in Hosts are
+// recognised, and any other forge needs Kind. An empty CacheDir disables
+// the disk cache.
+type Config struct {
+	Kind       string
+	APIURL     string
+	Repository string
+	RepoURL    string
+	Hosts      map[string]string
+	Token      string
+	CacheDir   string
+	CacheTTL   time.Duration
+	HTTPClient *http.Client
+}
+
+// DefaultCacheTTL is how long a fetched pull request is reused. Merged
+// requests rarely change, but labels are sometimes added afterwards.
+const DefaultCacheTTL = 24 * time.Hour
+
+type Adapter struct {
+	kind       string
+	apiURL     *url.URL
+	repository string
+	token      string
+	cache      *diskCache
+	client     *http.Client
+}
+
+func NewAdapter(cfg Config) (domain.PullRequestProvider, error) {
+	// A remote that is not a web URL, such as a local path, derives nothing.
+	var web *url.URL
+	if u, err := url.Parse(cfg.RepoURL); err == nil && u.Host != "" {
+		web = u
+	}
+
+	kind := strings.ToLower(cfg.Kind)
+	if kind == "" && web != nil {
+		kind = domain.ForgeForHost(web.Hostname(), cfg.Hosts)
+	}
+	switch kind {
+	case domain.ForgeGitHub, domain.ForgeGitLab, domain.ForgeGitea:
+	case "":
+		return nil, fmt.Errorf("%w: forge type is required for this repository (github, gitlab or gitea)", domain.ErrInvalidOption)
+	default:
+		return nil, fmt.Errorf("%w: unsupported forge type %q", domain.ErrInvalidOption, kind)
+	}
+
+	repository := strings.Trim(cfg.Repository, "/")
+	if repository == "" && web != nil {
+		repository = strings.TrimSuffix(strings.Trim(web.Path, "/"), ".git")
+	}
+	if !strings.Contains(repository, "/") {
+		return nil, fmt.Errorf("%w: forge repository must be owner/name, got %q", domain.ErrInvalidOption, repository)
+	}
+
+	rawAPI := cfg.APIURL
+	if rawAPI == "" && web != nil {
+		rawAPI = defaultAPIURL(kind, web)
+	}
+	apiURL, err := url.Parse(strings.TrimSuffix(rawAPI, "/"))
+	if err != nil || apiURL.Scheme == "" || apiURL.Host == "" {
+		return nil, fmt.Errorf("%w: invalid forge API URL %q", domain.ErrInvalidOption, rawAPI)
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	a := &Adapter{
+		kind:       kind,
+		apiURL:     apiURL,
+		repository: repository,
+		token:      cfg.Token,
+		client:     client,
+	}
+	if cfg.CacheDir != "" {
+		ttl := cfg.CacheTTL
+		if ttl == 0 {
+			ttl = DefaultCacheTTL
+		}
+		a.cache = &diskCache{dir: cfg.CacheDir, ttl: ttl, now: time.Now}
+	}
+	return a, nil
+}
+
+// defaultAPIURL is the API root of a forge serving web, following each
+// forge's convention for self-hosted instances.
+func defaultAPIURL(kind string, web *url.URL) string {
+	root := web.Scheme + "://" + web.Host
+	switch kind {
+	case domain.ForgeGitHub:
+		if strings.EqualFold(web.Hostname(), "github.com") {
+			return "https://api.github.com"
+		}
+		return root + "/api/v3"
+	case domain.ForgeGitLab:
+		return root + "/api/v4"
+	default:
+		return root + "/api/v1"
+	}
+}
+
+// GetPullRequest fetches request number, from the disk cache when a fresh
+// copy is there. A cache that cannot be read or written only costs a
+// fetch.
+func (a *Adapter) GetPullRequest(ctx context.Context, number int) (*domain.ForgePullRequest, error) {
+	key := strings.Join([]string{a.kind, a.apiURL.String(), a.repository, strconv.Itoa(number)}, "\n")
+	if a.cache != nil {
+		if pr, err := a.cache.get(key); err == nil && pr != nil {
+			return pr, nil
+		}
+	}
+
+	var pr *domain.ForgePullRequest
+	var err error
+	switch a.kind {
+	case domain.ForgeGitLab:
+		pr, err = a.gitlabMergeRequest(ctx, number)
+	default:
+		pr, err = a.githubPullRequest(ctx, number)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pr.Number = number
+	if pr.Labels == nil {
+		pr.Labels = []string{}
+	}
+	slices.Sort(pr.Reviewers)
+	pr.Reviewers = slices.Compact(pr.Reviewers)
+	if pr.Reviewers == nil {
+		pr.Reviewers = []string{}
+	}
+
+	if a.cache != nil {
+		_ = a.cache.put(key, pr)
+	}
+	return pr, nil
+}
+
+type user struct {
+	Login string `json:"login"`
+}
+
+// githubPull is a pull request as GitHub and Gitea both serve it.
+type githubPull struct {
+	HTMLURL            string     `json:"html_url"`
+	Title              string     `json:"title"`
+	State              string     `json:"state"`
+	MergedAt           *time.Time `json:"merged_at"`
+	User               user       `json:"user"`
+	RequestedReviewers []user     `json:"requested_reviewers"`
+	Labels             []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+}
+
+type githubReview struct {
+	User user `json:"user"`
+}
+
+// githubPullRequest reads a pull request and its reviews from GitHub or
+// Gitea, whose APIs share this shape.
+func (a *Adapter) githubPullRequest(ctx context.Context, number int) (*domain.ForgePullRequest, error) {
+	endpoint := a.apiURL.JoinPath("repos", a.repository, "pulls", strconv.Itoa(number))
+
+	var pull githubPull
+	if _, err := a.getJSON(ctx, endpoint, number, &pull); err != nil {
+		return nil, err
+	}
+
+	// Reviews are paginated; follow the pages until none is left.
+	var reviews []githubReview
+	next := endpoint.JoinPath("reviews")
+	next.RawQuery = url.Values{"per_page": {"100"}, "limit": {"50"}}.Encode()
+	for next != nil {
+		var page []githubReview
+		var err error
+		if next, err = a.getJSON(ctx, next, number, &page); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, page...)
+	}
+
+	pr := &domain.ForgePullRequest{
+		URL:    pull.HTMLURL,
+		Title:  pull.Title,
+		State:  normaliseState(pull.State, pull.MergedAt),
+		Author: pull.User.Login,
+	}
+	for _, l := range pull.Labels {
+		pr.Labels = append(pr.Labels, l.Name)
+	}
+	for _, r := range pull.RequestedReviewers {
+		pr.Reviewers = append(pr.Reviewers, r.Login)
+	}
+	for _, r := range reviews {
+		if r.User.Login != "" && r.User.Login != pr.Author {
+			pr.Reviewers = append(pr.Reviewers, r.User.Login)
+		}
+	}
+	if pull.MergedAt != nil {
+		merged := pull.MergedAt.UTC()
+		pr.MergedAt = &merged
+	}
+	return pr, nil
+}
+
+type gitlabUser struct {
+	Username string `json:"username"`
+}
+
+type gitlabMergeRequest struct {
+	WebURL    string       `json:"web_url"`
+	Title     string       `json:"title"`
+	State     string       `json:"state"`
+	MergedAt  *time.Time   `json:"merged_at"`
+	Author    gitlabUser   `json:"author"`
+	Reviewers []gitlabUser `json:"reviewers"`
+	Labels    []string     `json:"labels"`
+}
+
+func (a *Adapter) gitlabMergeRequest(ctx context.Context, number int) (*domain.ForgePullRequest, error) {
+	// The project path is one URL-encoded segment.
+	endpoint, err := url.Parse(a.apiURL.String() + "/projects/" + url.PathEscape(a.repository) + "/merge_requests/" + strconv.Itoa(number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build GitLab request: %w", err)
+	}
+
+	var mr gitlabMergeRequest
+	if _, err := a.getJSON(ctx, endpoint, number, &mr); err != nil {
+		return nil, err
+	}
+
+	pr := &domain.ForgePullRequest{
+		URL:    mr.WebURL,
+		Title:  mr.Title,
+		State:  normaliseState(mr.State, mr.MergedAt),
+		Author: mr.Author.Username,
+		Labels: mr.Labels,
+	}
+	for _, r := range mr.Reviewers {
+		pr.Reviewers = append(pr.Reviewers, r.Username)
+	}
+	if mr.MergedAt != nil {
+		merged := mr.MergedAt.UTC()
+		pr.MergedAt = &merged
+	}
+	return pr, nil
+}
+
+// normaliseState maps each forge's states onto open, closed and merged.
+// GitHub and Gitea report merged requests as closed.
+func normaliseState(state string, mergedAt *time.Time) string {
+	switch {
+	case mergedAt != nil || state == "merged":
+		return domain.PullRequestMerged
+	case state == "open" || state == "opened":
+		return domain.PullRequestOpen
+	}
+	return domain.PullRequestClosed
+}
+
+// getJSON decodes the response to endpoint into dst and returns the next
+// page named by its Link header, or nil on the last page.
+func (a *Adapter) getJSON(ctx context.Context, endpoint *url.URL, number int, dst any) (*url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s request: %w", a.kind, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if a.token != "" {
+		switch a.kind {
+		case domain.ForgeGitHub:
+			req.Header.Set("Authorization", "Bearer "+a.token)
+		case domain.ForgeGitLab:
+			req.Header.Set("PRIVATE-TOKEN", a.token)
+		case domain.ForgeGitea:
+			req.Header.Set("Authorization", "token "+a.token)
+		}
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s pull request #%d: %w", a.kind, number, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: #%d", domain.ErrPullRequestNotFound, number)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("failed to fetch %s pull request #%d: %s: %s", a.kind, number, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return nil, fmt.Errorf("failed to decode %s pull request #%d: %w", a.kind, number, err)
+	}
+	return nextPage(resp), nil
+}
+
+// nextPage returns the rel="next" target of the response's Link header,
+// resolved against the request URL, or nil when there is none.
+func nextPage(resp *http.Response) *url.URL {
+	for _, link := range strings.Split(strings.Join(resp.Header.Values("Link"), ","), ",") {
+		target, params, _ := strings.Cut(link, ";")
+		for _, param := range strings.Split(params, ";") {
+			if strings.ReplaceAll(strings.TrimSpace(param), `"`, "") != "rel=next" {
+				continue
+			}
+			next, err := resp.Request.URL.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+			if err != nil {
+				return nil
+			}
+			return next
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package forge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const githubPullJSON = `{
+  "html_url": "https://forge.example/org/repo/pull/12",
+  "title": "feat: add pagination",
+  "state": "closed",
+  "merged_at": "2024-05-02T10:00:00+02:00",
+  "user": {"login": "ada"},
+  "requested_reviewers": [{"login": "linus"}],
+  "labels": [{"name": "api"}, {"name": "feature"}]
+}`
+
+const githubReviewsJSON = `[
+  {"user": {"login": "grace"}, "state": "APPROVED"},
+  {"user": {"login": "ada"}, "state": "COMMENTED"},
+  {"user": {"login": "grace"}, "state": "COMMENTED"}
+]`
+
+const githubReviewsPage2JSON = `[
+  {"user": {"login": "margaret"}, "state": "APPROVED"}
+]`
+
+const gitlabMergeRequestJSON = `{
+  "web_url": "https://forge.example/group/sub/repo/-/merge_requests/12",
+  "title": "feat: add pagination",
+  "state": "merged",
+  "merged_at": "2024-05-02T08:00:00Z",
+  "author": {"username": "ada"},
+  "reviewers": [{"username": "linus"}, {"username": "grace"}],
+  "labels": ["api", "feature"]
+}`
+
+// fakeForge serves pull request 12 in the shape of each forge, with its
+// reviews over two pages, counting requests and recording the last
+// authentication headers it saw.
+type fakeForge struct {
+	*httptest.Server
+	hits          int
+	lastAuth      string
+	lastGitLabKey string
+}
+
+func newFakeForge(t *testing.T) *fakeForge {
+	f := &fakeForge{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.hits++
+		f.lastAuth = r.Header.Get("Authorization")
+		f.lastGitLabKey = r.Header.Get("PRIVATE-TOKEN")
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.EscapedPath() {
+		case "/api/v3/repos/org/repo/pulls/12", "/api/v1/repos/org/repo/pulls/12":
+			_, _ = w.Write([]byte(githubPullJSON))
+		case "/api/v3/repos/org/repo/pulls/12/reviews", "/api/v1/repos/org/repo/pulls/12/reviews":
+			if r.URL.Query().Get("page") == "2" {
+				_, _ = w.Write([]byte(githubReviewsPage2JSON))
+				break
+			}
+			next := *r.URL
+			next.RawQuery = "page=2"
+			w.Header().Set("Link", `<`+next.String()+`>; rel="next", <`+next.String()+`>; rel="last"`)
+			_, _ = w.Write([]byte(githubReviewsJSON))
+		case "/api/v4/projects/group%2Fsub%2Frepo/merge_requests/12":
+			_, _ = w.Write([]byte(gitlabMergeRequestJSON))
+		case "/api/v3/repos/org/repo/pulls/500":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		default:
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestGetPullRequest(t *testing.T) {
+	server := newFakeForge(t)
+	merged := time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		cfg           Config
+		wantAuth      string
+		wantKey       string
+		wantURL       string
+		wantReviewers []string
+	}{
+		{
+			name:          "github",
+			cfg:           Config{Kind: domain.ForgeGitHub, APIURL: server.URL + "/api/v3", Repository: "org/repo", Token: "gh"},
+			wantAuth:      "Bearer gh",
+			wantURL:       "https://forge.example/org/repo/pull/12",
+			wantReviewers: []string{"grace", "linus", "margaret"},
+		},
+		{
+			name:          "gitea",
+			cfg:           Config{Kind: domain.ForgeGitea, RepoURL: server.URL + "/org/repo", Token: "tea"},
+			wantAuth:      "token tea",
+			wantURL:       "https://forge.example/org/repo/pull/12",
+			wantReviewers: []string{"grace", "linus", "margaret"},
+		},
+		{
+			name:          "gitlab subgroup",
+			cfg:           Config{Kind: domain.ForgeGitLab, RepoURL: server.URL + "/group/sub/repo.git", Token: "lab"},
+			wantKey:       "lab",
+			wantURL:       "https://forge.example/group/sub/repo/-/merge_requests/12",
+			wantReviewers: []string{"grace", "linus"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewAdapter(tt.cfg)
+			require.NoError(t, err)
+
+			pr, err := provider.GetPullRequest(context.Background(), 12)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantAuth, server.lastAuth)
+			assert.Equal(t, tt.wantKey, server.lastGitLabKey)
+			assert.Equal(t, &domain.ForgePullRequest{
+				Number:    12,
+				URL:       tt.wantURL,
+				Title:     "feat: add pagination",
+				State:     domain.PullRequestMerged,
+				Author:    "ada",
+				Labels:    []string{"api", "feature"},
+				Reviewers: tt.wantReviewers,
+				MergedAt:  &merged,
+			}, pr)
+		})
+	}
+}
+
+func TestGetPullRequest_Errors(t *testing.T) {
+	server := newFakeForge(t)
+	provider, err := NewAdapter(Config{Kind: domain.ForgeGitHub, APIURL: server.URL + "/api/v3", Repository: "org/repo"})
+	require.NoError(t, err)
+
+	_, err = provider.GetPullRequest(context.Background(), 404)
+	assert.ErrorIs(t, err, domain.ErrPullRequestNotFound)
+	assert.Empty(t, server.lastAuth, "no token, no header")
+
+	_, err = provider.GetPullRequest(context.Background(), 500)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, domain.ErrPullRequestNotFound)
+	assert.Contains(t, err.Error(), "500")
+}
+
+func TestGetPullRequest_DiskCache(t *testing.T) {
+	server := newFakeForge(t)
+	dir := t.TempDir()
+	cfg := Config{Kind: domain.ForgeGitHub, APIURL: server.URL + "/api/v3", Repository: "org/repo", CacheDir: dir, CacheTTL: time.Hour}
+
+	provider, err := NewAdapter(cfg)
+	require.NoError(t, err)
+	first, err := provider.GetPullRequest(context.Background(), 12)
+	require.NoError(t, err)
+	assert.Equal(t, 3, server.hits, "pull request and two pages of reviews")
+
+	// A second run reads the entry written by the first.
+	provider, err = NewAdapter(cfg)
+	require.NoError(t, err)
+	cached, err := provider.GetPullRequest(context.Background(), 12)
+	require.NoError(t, err)
+	assert.Equal(t, 3, server.hits)
+	assert.Equal(t, first, cached)
+
+	adapter := provider.(*Adapter)
+	adapter.cache.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = provider.GetPullRequest(context.Background(), 12)
+	require.NoError(t, err)
+	assert.Equal(t, 6, server.hits, "expired entries are fetched again")
+}
+
+func TestGetPullRequest_UnwritableCache(t *testing.T) {
+	server := newFakeForge(t)
+	blocked := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(blocked, nil, 0o600))
+
+	provider, err := NewAdapter(Config{Kind: domain.ForgeGitHub, APIURL: server.URL + "/api/v3", Repository: "org/repo", CacheDir: filepath.Join(blocked, "cache")})
+	require.NoError(t, err)
+
+	pr, err := provider.GetPullRequest(context.Background(), 12)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add pagination", pr.Title)
+}
+
+func TestNewAdapter_DerivesFromRepoURL(t *testing.T) {
+	tests := []struct {
+		repoURL    string
+		kind       string
+		apiURL     string
+		repository string
+	}{
+		{"https://github.com/org/repo", domain.ForgeGitHub, "https://api.github.com", "org/repo"},
+		{"https://gitlab.com/group/sub/repo", domain.ForgeGitLab, "https://gitlab.com/api/v4", "group/sub/repo"},
+		{"https://codeberg.org/org/repo", domain.ForgeGitea, "https://codeberg.org/api/v1", "org/repo"},
+		{"https://git.example.com/group/repo", domain.ForgeGitLab, "https://git.example.com/api/v4", "group/repo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repoURL, func(t *testing.T) {
+			provider, err := NewAdapter(Config{RepoURL: tt.repoURL, Hosts: map[string]string{"git.example.com": domain.ForgeGitLab}})
+			require.NoError(t, err)
+
+			a := provider.(*Adapter)
+			assert.Equal(t, tt.kind, a.kind)
+			assert.Equal(t, tt.apiURL, a.apiURL.String())
+			assert.Equal(t, tt.repository, a.repository)
+			assert.Nil(t, a.cache)
+		})
+	}
+
+	provider, err := NewAdapter(Config{Kind: "GitHub", RepoURL: "https://git.example.com/org/repo"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://git.example.com/api/v3", provider.(*Adapter).apiURL.String(), "GitHub Enterprise")
+}
+
+func TestNewAdapter_RejectsBadConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{RepoURL: "https://git.example.com/org/repo"},
+		{RepoURL: "https://bitbucket.org/org/repo"},
+		{Kind: domain.ForgeGitHub, APIURL: "https://api.github.com", Repository: "repo"},
+		{Kind: domain.ForgeGitHub, Repository: "org/repo"},
+	} {
+		_, err := NewAdapter(cfg)
+		assert.ErrorIs(t, err, domain.ErrInvalidOption, "%+v", cfg)
+	}
+}
@@ -0,0 +1,120 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+const attributesFileName = ".gitattributes"
+
+// snapshot is one side of a diff: its tree plus the .gitattributes rules
+// found in it, loaded on first use.
+type snapshot struct {
+	tree       *object.Tree
+	attributes []gitattributes.MatchAttribute
+	loaded     bool
+}
+
+func newSnapshot(tree *object.Tree) *snapshot {
+	return &snapshot{tree: tree}
+}
+
+// attributesFor resolves the git attributes of p. Set attributes map to
+// "true", unset ones ("-attr") to "false" and the rest to their value; the
+// "binary" macro expands to -diff -merge -text as in git. Lines are applied
+// root file first, so deeper files and later lines win.
+func (s *snapshot) attributesFor(p string) (map[string]string, error) {
+	if !s.loaded {
+		attributes, err := loadAttributes(s.tree)
+		if err != nil {
+			return nil, err
+		}
+		s.attributes, s.loaded = attributes, true
+	}
+
+	parts := strings.Split(p, "/")
+	result := map[string]string{}
+	for _, rule := range s.attributes {
+		if rule.Pattern == nil || !rule.Pattern.Match(parts) {
+			continue
+		}
+		for _, attr := range rule.Attributes {
+			switch {
+			case attr.IsSet():
+				result[attr.Name()] = "true"
+				if attr.Name() == "binary" {
+					result["diff"], result["merge"], result["text"] = "false", "false", "false"
+				}
+			case attr.IsUnset():
+				result[attr.Name()] = "false"
+			case attr.IsValueSet():
+				result[attr.Name()] = attr.Value()
+			default:
+				delete(result, attr.Name())
+			}
+		}
+	}
+	return result, nil
+}
+
+// loadAttributes reads every .gitattributes file in tree, shallowest first.
+// Lines git would reject are skipped rather than failing the diff.
+func loadAttributes(tree *object.Tree) ([]gitattributes.MatchAttribute, error) {
+	var names []string
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk tree: %w", err)
+		}
+		if entry.Mode.IsFile() && path.Base(name) == attributesFileName {
+			names = append(names, name)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return strings.Count(names[i], "/") < strings.Count(names[j], "/")
+	})
+
+	var attributes []gitattributes.MatchAttribute
+	for _, name := range names {
+		file, err := tree.File(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		reader, err := file.Reader()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		var domain []string
+		if dir := path.Dir(name); dir != "." {
+			domain = strings.Split(dir, "/")
+		}
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			attr, err := gitattributes.ParseAttributesLine(scanner.Text(), domain, name == attributesFileName)
+			if err != nil || attr.Name == "" {
+				continue
+			}
+			attributes = append(attributes, attr)
+		}
+		err = scanner.Err()
+		reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+	}
+	return attributes, nil
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/adapter/git/gittest"
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func changedPaths(files []domain.FileChange) map[string]string {
+	out := make(map[string]string, len(files))
+	for _, f := range files {
+		p := f.Path.After
+		if p == "" {
+			p = f.Path.Before
+		}
+		out[p] = f.ChangeType
+	}
+	return out
+}
+
+func commitMessages(commits []domain.Commit) []string {
+	out := make([]string, len(commits))
+	for i, c := range commits {
+		out[i] = c.Message
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestCalculateBaseline(t *testing.T) {
+	r := gittest.NewReadmeTopology(t)
+
+	tests := []struct {
+		name       string
+		from, to   plumbing.Hash
+		strategy   string
+		wantBase   plumbing.Hash
+		wantLinear bool
+		wantErr    error
+	}{
+		{"merge-base on branched history", r.G, r.E, domain.BaselineMergeBase, r.B, false, nil},
+		{"merge-base by default", r.G, r.E, "", r.B, false, nil},
+		{"merge-base on linear history", r.C, r.E, domain.BaselineMergeBase, r.C, true, nil},
+		{"two-dot", r.G, r.E, domain.BaselineTwoDot, r.G, false, nil},
+		{"direct on linear history", r.C, r.E, domain.BaselineDirect, r.C, true, nil},
+		{"direct on branched history", r.G, r.E, domain.BaselineDirect, plumbing.ZeroHash, false, domain.ErrNotAncestor},
+		{"unknown strategy", r.G, r.E, "octopus", plumbing.ZeroHash, false, domain.ErrInvalidOption},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, linear, err := newAdapter(r.Repo).CalculateBaseline(context.Background(), tt.from.String(), tt.to.String(), tt.strategy)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBase.String(), base)
+			assert.Equal(t, tt.wantLinear, linear)
+		})
+	}
+}
+
+func TestCalculateDiff_BranchedHistory(t *testing.T) {
+	r := gittest.NewReadmeTopology(t)
+	adapter := newAdapter(r.Repo)
+
+	files, _, err := adapter.CalculateDiff(context.Background(), r.B.String(), r.E.String(), domain.RequestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"feature.go":      "added",
+		"core.go":         "modified",
+		"docs/feature.md": "added",
+	}, changedPaths(files))
+
+	files, _, err = adapter.CalculateDiff(context.Background(), r.G.String(), r.E.String(), domain.RequestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"feature.go":      "added",
+		"core.go":         "modified",
+		"docs/feature.md": "added",
+		"hotfix.go":       "deleted",
+		"README.md":       "modified",
+	}, changedPaths(files))
+}
@@ -2,180 +2,89 @@ package git
 
 import (
 	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
 
+	"github.com/NERVEbing/supervisor/internal/domain"
+
 	"github.com/go-git/go-git/v6/plumbing/object"
 )
 
-func detectLanguage(path string) string {
-	ext := filepath.Ext(path)
-	if ext == "" {
-		return ""
-	}
-
-	languageMap := map[string]string{
-		".go":    "Go",
-		".py":    "Python",
-		".js":    "JavaScript",
-		".ts":    "TypeScript",
-		".jsx":   "JavaScript",
-		".tsx":   "TypeScript",
-		".java":  "Java",
-		".c":     "C",
-		".cpp":   "C++",
-		".cc":    "C++",
-		".h":     "C",
-		".hpp":   "C++",
-		".rs":    "Rust",
-		".rb":    "Ruby",
-		".php":   "PHP",
-		".swift": "Swift",
-		".kt":    "Kotlin",
-		".scala": "Scala",
-		".sh":    "Shell",
-		".bash":  "Shell",
-		".zsh":   "Shell",
-		".sql":   "SQL",
-		".md":    "Markdown",
-		".json":  "JSON",
-		".yaml":  "YAML",
-		".yml":   "YAML",
-		".xml":   "XML",
-		".html":  "HTML",
-		".css":   "CSS",
-		".scss":  "SCSS",
-		".sass":  "Sass",
-		".proto": "Protocol Buffers",
-	}
-
-	if lang, ok := languageMap[ext]; ok {
-		return lang
-	}
-	return ""
-}
+// headSize bounds how much of a file's content is handed to the classifier.
+const headSize = 8 << 10
 
-func isGeneratedFile(path string) bool {
-	patterns := []string{
-		".pb.go",
-		".pb.gw.go",
-		"_generated.go",
-		".gen.go",
-		"vendor/",
-		"node_modules/",
-		"dist/",
-		"build/",
-		".min.js",
-		".min.css",
+// classify runs the adapter's classifier over path, its git attributes
+// and, for text files, the start of its content.
+func (a *Adapter) classify(path string, file *object.File, attributes map[string]string, isBinary bool) (domain.FileClass, error) {
+	if a.classifier == nil {
+		return domain.FileClass{}, nil
 	}
 
-	for _, pattern := range patterns {
-		if strings.Contains(path, pattern) {
-			return true
+	input := domain.ClassifyInput{Path: path, Attributes: attributes}
+	if file != nil && !isBinary {
+		head, err := readHead(file)
+		if err != nil {
+			return domain.FileClass{}, err
 		}
+		input.Head = head
 	}
-
-	return false
+	return a.classifier.Classify(input), nil
 }
 
-func isTestFile(path string) bool {
-	patterns := []string{
-		"_test.go",
-		"_test.py",
-		".test.js",
-		".test.ts",
-		".spec.js",
-		".spec.ts",
-		"test/",
-		"tests/",
-		"__tests__/",
+func readHead(file *object.File) ([]byte, error) {
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("read file %s: %w", file.Name, err)
 	}
+	defer reader.Close()
 
-	for _, pattern := range patterns {
-		if strings.Contains(path, pattern) {
-			return true
-		}
+	head, err := io.ReadAll(io.LimitReader(reader, headSize))
+	if err != nil {
+		return nil, fmt.Errorf("read file %s: %w", file.Name, err)
 	}
-
-	return false
+	return head, nil
 }
 
-func isConfigFile(path string) bool {
-	configFiles := []string{
-		"Makefile",
-		"Dockerfile",
-		".gitignore",
-		".dockerignore",
-		"go.mod",
-		"go.sum",
-		"package.json",
-		"package-lock.json",
-		"yarn.lock",
-		"Cargo.toml",
-		"Cargo.lock",
-		"pom.xml",
-		"build.gradle",
-		"CMakeLists.txt",
-		".golangci.yml",
-		".golangci.yaml",
-	}
-
-	baseName := filepath.Base(path)
-	for _, cf := range configFiles {
-		if baseName == cf {
-			return true
-		}
+// changedFile returns the file on the target side of change, or on the
+// source side when it was deleted, together with that side.
+func changedFile(change *object.Change, from, to *snapshot) (*object.File, *snapshot, error) {
+	side, name := to, change.To.Name
+	if name == "" {
+		side, name = from, change.From.Name
 	}
 
-	if strings.HasPrefix(path, ".github/") ||
-		strings.HasPrefix(path, ".vscode/") ||
-		strings.HasPrefix(path, ".idea/") ||
-		strings.Contains(path, "/config/") {
-		return true
+	file, err := side.tree.File(name)
+	if err != nil {
+		return nil, side, fmt.Errorf("read file %s: %w", name, err)
 	}
+	return file, side, nil
+}
 
-	ext := filepath.Ext(path)
-	configExts := []string{".yaml", ".yml", ".toml", ".ini", ".conf", ".config"}
-	for _, ce := range configExts {
-		if ext == ce {
-			return true
-		}
+// isBinaryFile follows git: "-diff" (and the "binary" macro) forces binary,
+// "diff" or a diff driver forces text, and otherwise the extension and
+// content decide.
+func isBinaryFile(path string, file *object.File, attributes map[string]string) (bool, error) {
+	switch attributes["diff"] {
+	case "":
+	case "false":
+		return true, nil
+	default:
+		return false, nil
 	}
 
-	return false
-}
-
-func isBinaryFile(change *object.Change, fromTree, toTree *object.Tree) (bool, error) {
-	path := getChangePath(change)
 	if isBinaryExtension(path) {
 		return true, nil
 	}
-
-	if change.To.Name != "" {
-		file, err := toTree.File(change.To.Name)
-		if err != nil {
-			return false, fmt.Errorf("read file %s: %w", change.To.Name, err)
-		}
-		isBin, err := file.IsBinary()
-		if err != nil {
-			return false, fmt.Errorf("detect binary for %s: %w", change.To.Name, err)
-		}
-		return isBin, nil
+	if file == nil {
+		return false, nil
 	}
 
-	if change.From.Name != "" && change.To.Name == "" {
-		file, err := fromTree.File(change.From.Name)
-		if err != nil {
-			return false, fmt.Errorf("read file %s: %w", change.From.Name, err)
-		}
-		isBin, err := file.IsBinary()
-		if err != nil {
-			return false, fmt.Errorf("detect binary for %s: %w", change.From.Name, err)
-		}
-		return isBin, nil
+	isBin, err := file.IsBinary()
+	if err != nil {
+		return false, fmt.Errorf("detect binary for %s: %w", file.Name, err)
 	}
-
-	return false, nil
+	return isBin, nil
 }
 
 func isBinaryExtension(path string) bool {
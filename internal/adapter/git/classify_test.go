@@ -0,0 +1,173 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/adapter/git/gittest"
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingClassifier records what it is asked to classify and answers
+// with the class set for the path.
+type recordingClassifier struct {
+	inputs  map[string]domain.ClassifyInput
+	classes map[string]domain.FileClass
+}
+
+func newRecordingClassifier(classes map[string]domain.FileClass) *recordingClassifier {
+	return &recordingClassifier{inputs: map[string]domain.ClassifyInput{}, classes: classes}
+}
+
+func (c *recordingClassifier) Classify(file domain.ClassifyInput) domain.FileClass {
+	c.inputs[file.Path] = file
+	return c.classes[file.Path]
+}
+
+func TestCalculateDiff_Classifier(t *testing.T) {
+	r := gittest.New(t)
+
+	from := r.Commit("initial", map[string]string{"README.md": "hi\n", "old.go": "package old\n"})
+	to := r.Commit("add", map[string]string{
+		"README.md":         "hi\n",
+		"schema/api.gen.ts": "export {}\n",
+		"scripts/release":   "#!/usr/bin/env python3\nprint('hi')\n",
+		"logo.png":          "png",
+	}, from)
+
+	classifier := newRecordingClassifier(map[string]domain.FileClass{
+		"schema/api.gen.ts": {Language: "TypeScript", IsGenerated: true, GeneratedBy: domain.GeneratedByPath, Tags: []string{"api"}},
+		"scripts/release":   {Language: "Python", IsTest: true, IsConfig: true, IsVendored: true, IsDocumentation: true},
+	})
+	adapter := newAdapter(r)
+	WithClassifier(classifier)(adapter)
+
+	files, _, err := adapter.CalculateDiff(context.Background(), from.String(), to.String(), domain.RequestOptions{})
+	require.NoError(t, err)
+
+	gen := findChange(t, files, "schema/api.gen.ts")
+	assert.Equal(t, "TypeScript", gen.Language)
+	assert.Equal(t, domain.Classification{
+		IsNew:       true,
+		IsGenerated: true,
+		GeneratedBy: domain.GeneratedByPath,
+		Tags:        []string{"api"},
+	}, gen.Classification)
+
+	release := findChange(t, files, "scripts/release")
+	assert.Equal(t, "Python", release.Language)
+	assert.True(t, release.Classification.IsTest)
+	assert.True(t, release.Classification.IsConfig)
+	assert.True(t, release.Classification.IsVendored)
+	assert.True(t, release.Classification.IsDocumentation)
+
+	assert.Equal(t, "#!/usr/bin/env python3\nprint('hi')\n", string(classifier.inputs["scripts/release"].Head))
+	assert.Equal(t, "package old\n", string(classifier.inputs["old.go"].Head), "deletions are read at the source side")
+	assert.Empty(t, classifier.inputs["logo.png"].Head, "binary files carry no head")
+	assert.True(t, findChange(t, files, "logo.png").Classification.IsBinary)
+}
+
+func TestCalculateDiff_WithoutClassifier(t *testing.T) {
+	r := gittest.New(t)
+
+	from := r.Commit("initial", map[string]string{"README.md": "hi\n"})
+	to := r.Commit("add", map[string]string{"README.md": "hi\n", "main_test.go": "package main\n"}, from)
+
+	files, _, err := newAdapter(r).CalculateDiff(context.Background(), from.String(), to.String(), domain.RequestOptions{})
+	require.NoError(t, err)
+
+	f := findChange(t, files, "main_test.go")
+	assert.Empty(t, f.Language)
+	assert.False(t, f.Classification.IsTest)
+	assert.True(t, f.Classification.IsNew)
+}
+
+func TestCalculateDiff_GeneratedFromGitattributes(t *testing.T) {
+	r := gittest.New(t)
+
+	from := r.Commit("initial", map[string]string{
+		"README.md":      "hi\n",
+		"old/gen.txt":    "x\n",
+		".gitattributes": "old/** linguist-generated\n",
+	})
+	to := r.Commit("add", map[string]string{
+		"README.md":               "hi\n",
+		".gitattributes":          "*.snap linguist-generated\napi/** linguist-generated=true\n",
+		"api/.gitattributes":      "keep.go -linguist-generated\n",
+		"api/client.go":           "package api\n",
+		"api/keep.go":             "// Code generated by hand. DO NOT EDIT.\npackage api\n",
+		"ui/__snapshots__/a.snap": "exports[`a`] = 1;\n",
+		"mock/mock.go":            "// Code generated by mockgen. DO NOT EDIT.\npackage mock\n",
+	}, from)
+
+	classifier := newRecordingClassifier(nil)
+	adapter := newAdapter(r)
+	WithClassifier(classifier)(adapter)
+
+	_, _, err := adapter.CalculateDiff(context.Background(), from.String(), to.String(), domain.RequestOptions{})
+	require.NoError(t, err)
+
+	for path, want := range map[string]string{
+		"api/client.go":           "true",
+		"api/keep.go":             "false",
+		"ui/__snapshots__/a.snap": "true",
+		"mock/mock.go":            "",
+		"old/gen.txt":             "true",
+	} {
+		assert.Equal(t, want, classifier.inputs[path].Attributes["linguist-generated"], path)
+	}
+	assert.Contains(t, string(classifier.inputs["mock/mock.go"].Head), "DO NOT EDIT")
+}
+
+func TestCalculateDiff_HonoursGitattributes(t *testing.T) {
+	r := gittest.New(t)
+
+	from := r.Commit("initial", map[string]string{
+		"data/fixture.json": "{}\n",
+		"schema.sql":        "create table a ();\n",
+		"assets/logo.svg":   "<svg/>\n",
+	})
+	to := r.Commit("change", map[string]string{
+		".gitattributes": "*.json binary\n" +
+			"*.sql -diff\n" +
+			"*.svg diff\n" +
+			"third_party/** linguist-vendored\n" +
+			"docs/** linguist-documentation\n" +
+			"*.tmpl linguist-language=Go\n",
+		"data/fixture.json":        "{\"a\": 1}\n",
+		"schema.sql":               "create table a ();\ncreate table b ();\n",
+		"assets/logo.svg":          "<svg>\n</svg>\n",
+		"third_party/lib/a.go":     "package lib\n",
+		"docs/guide.md":            "# Guide\n",
+		"templates/page.tmpl":      "{{ .Title }}\n",
+		"templates/.gitattributes": "*.tmpl linguist-language=HTML\n",
+	}, from)
+
+	classifier := newRecordingClassifier(nil)
+	adapter := newAdapter(r)
+	WithClassifier(classifier)(adapter)
+
+	files, stats, err := adapter.CalculateDiff(context.Background(), from.String(), to.String(), domain.RequestOptions{})
+	require.NoError(t, err)
+
+	fixture := findChange(t, files, "data/fixture.json")
+	assert.True(t, fixture.Classification.IsBinary)
+	assert.Zero(t, fixture.Lines)
+
+	schema := findChange(t, files, "schema.sql")
+	assert.True(t, schema.Classification.IsBinary)
+	assert.Zero(t, schema.Lines)
+
+	logo := findChange(t, files, "assets/logo.svg")
+	assert.False(t, logo.Classification.IsBinary)
+	assert.Equal(t, domain.FileLineStats{Added: 2, Deleted: 1}, logo.Lines)
+
+	assert.Equal(t, 2, stats.BinaryFilesDetected)
+	assert.Empty(t, classifier.inputs["schema.sql"].Head)
+	assert.Equal(t, "true", classifier.inputs["third_party/lib/a.go"].Attributes["linguist-vendored"])
+	assert.Equal(t, "true", classifier.inputs["docs/guide.md"].Attributes["linguist-documentation"])
+	assert.Equal(t, "HTML", classifier.inputs["templates/page.tmpl"].Attributes["linguist-language"], "nearer files win")
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/go-git/go-git/v6/plumbing/object"
 )
 
-func (a *Adapter) CalculateDiff(ctx context.Context, fromHash, toHash string) ([]domain.FileChange, domain.DiffStats, error) {
+func (a *Adapter) CalculateDiff(ctx context.Context, fromHash, toHash string, opts domain.RequestOptions) ([]domain.FileChange, domain.DiffStats, error) {
 	from := plumbing.NewHash(fromHash)
 	to := plumbing.NewHash(toHash)
 
@@ -38,21 +38,26 @@ func (a *Adapter) CalculateDiff(ctx context.Context, fromHash, toHash string) ([
 		return nil, domain.DiffStats{}, fmt.Errorf("failed to get to tree: %w", err)
 	}
 
-	changes, err := fromTree.Diff(toTree)
+	changes, err := object.DiffTreeWithOptions(ctx, fromTree, toTree, nil)
 	if err != nil {
 		return nil, domain.DiffStats{}, fmt.Errorf("failed to calculate tree diff: %w", err)
 	}
 
-	return a.convertChanges(changes, fromTree, toTree)
+	detected, err := a.detectRenames(changes, opts)
+	if err != nil {
+		return nil, domain.DiffStats{}, fmt.Errorf("failed to detect renames: %w", err)
+	}
+
+	return a.convertChanges(detected, newSnapshot(fromTree), newSnapshot(toTree), opts.Patches)
 }
 
-func (a *Adapter) convertChanges(changes object.Changes, fromTree, toTree *object.Tree) ([]domain.FileChange, domain.DiffStats, error) {
+func (a *Adapter) convertChanges(changes []detectedChange, from, to *snapshot, patches domain.PatchOptions) ([]domain.FileChange, domain.DiffStats, error) {
 	var result []domain.FileChange
 	var stats domain.DiffStats
 
 	for _, change := range changes {
-		path := getChangePath(change)
-		fc, isBinary, err := a.convertSingleChange(change, fromTree, toTree)
+		path := getChangePath(change.Change)
+		fc, isBinary, err := a.convertSingleChange(change, from, to, patches)
 		if err != nil {
 			return nil, stats, fmt.Errorf("failed to convert change for %s: %w", path, err)
 		}
@@ -67,7 +72,9 @@ func (a *Adapter) convertChanges(changes object.Changes, fromTree, toTree *objec
 	return result, stats, nil
 }
 
-func (a *Adapter) convertSingleChange(change *object.Change, fromTree, toTree *object.Tree) (domain.FileChange, bool, error) {
+func (a *Adapter) convertSingleChange(detected detectedChange, from, to *snapshot, patches domain.PatchOptions) (domain.FileChange, bool, error) {
+	change := detected.Change
+
 	var changeType string
 	var pathBefore, pathAfter string
 
@@ -78,6 +85,10 @@ func (a *Adapter) convertSingleChange(change *object.Change, fromTree, toTree *o
 	case change.From.Name != "" && change.To.Name == "":
 		changeType = "deleted"
 		pathBefore = change.From.Name
+	case detected.copied:
+		changeType = "copied"
+		pathBefore = change.From.Name
+		pathAfter = change.To.Name
 	case change.From.Name != change.To.Name:
 		changeType = "renamed"
 		pathBefore = change.From.Name
@@ -88,30 +99,49 @@ func (a *Adapter) convertSingleChange(change *object.Change, fromTree, toTree *o
 		pathAfter = change.To.Name
 	}
 
-	isBinary, err := isBinaryFile(change, fromTree, toTree)
+	path := getChangePath(change)
+	file, side, err := changedFile(change, from, to)
+	if err != nil {
+		return domain.FileChange{}, false, err
+	}
+
+	attributes, err := side.attributesFor(path)
+	if err != nil {
+		return domain.FileChange{}, false, err
+	}
+
+	isBinary, err := isBinaryFile(path, file, attributes)
 	if err != nil {
 		return domain.FileChange{}, false, err
 	}
 
 	var lineStats domain.FileLineStats
+	var patch *domain.FilePatch
 	if !isBinary {
-		stats, err := a.calculateLineStats(change)
+		stats, p, err := a.calculateLineStats(change, patches)
 		if err != nil {
 			return domain.FileChange{}, isBinary, err
 		}
-		lineStats = stats
+		lineStats, patch = stats, p
 	}
 
-	path := getChangePath(change)
-	language := detectLanguage(path)
+	class, err := a.classify(path, file, attributes, isBinary)
+	if err != nil {
+		return domain.FileChange{}, isBinary, err
+	}
 
 	classification := domain.Classification{
-		IsNew:       changeType == "added",
-		IsRename:    changeType == "renamed",
-		IsBinary:    isBinary,
-		IsGenerated: isGeneratedFile(path),
-		IsTest:      isTestFile(path),
-		IsConfig:    isConfigFile(path),
+		IsNew:           changeType == "added",
+		IsRename:        changeType == "renamed",
+		IsCopy:          changeType == "copied",
+		IsBinary:        isBinary,
+		IsGenerated:     class.IsGenerated,
+		GeneratedBy:     class.GeneratedBy,
+		IsTest:          class.IsTest,
+		IsConfig:        class.IsConfig,
+		IsVendored:      class.IsVendored,
+		IsDocumentation: class.IsDocumentation,
+		Tags:            class.Tags,
 	}
 
 	return domain.FileChange{
@@ -120,19 +150,23 @@ func (a *Adapter) convertSingleChange(change *object.Change, fromTree, toTree *o
 			After:  pathAfter,
 		},
 		ChangeType:     changeType,
-		Language:       language,
+		Similarity:     detected.similarity,
+		Language:       class.Language,
 		Lines:          lineStats,
 		Classification: classification,
 		History: domain.FileHistory{
 			RelatedCommits: []string{},
 		},
+		Patch: patch,
 	}, isBinary, nil
 }
 
-func (a *Adapter) calculateLineStats(change *object.Change) (domain.FileLineStats, error) {
+// calculateLineStats counts added and deleted lines and, when patches are
+// requested, encodes the hunks as well.
+func (a *Adapter) calculateLineStats(change *object.Change, patches domain.PatchOptions) (domain.FileLineStats, *domain.FilePatch, error) {
 	patch, err := change.Patch()
 	if err != nil {
-		return domain.FileLineStats{}, err
+		return domain.FileLineStats{}, nil, err
 	}
 
 	stats := patch.Stats()
@@ -142,8 +176,17 @@ func (a *Adapter) calculateLineStats(change *object.Change) (domain.FileLineStat
 		deleted += fileStat.Deletion
 	}
 
-	return domain.FileLineStats{
+	lineStats := domain.FileLineStats{
 		Added:   added,
 		Deleted: deleted,
-	}, nil
+	}
+	if !patches.Include {
+		return lineStats, nil, nil
+	}
+
+	hunks, err := encodePatch(patch, patches.ContextLines)
+	if err != nil {
+		return lineStats, nil, fmt.Errorf("failed to encode patch: %w", err)
+	}
+	return lineStats, hunks, nil
 }
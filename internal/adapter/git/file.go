@@ -0,0 +1,39 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// ReadFile returns the contents of path in the tree of the given commit.
+func (a *Adapter) ReadFile(ctx context.Context, commitHash, path string) ([]byte, error) {
+	commit, err := a.repo.CommitObject(plumbing.NewHash(commitHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree: %w", err)
+	}
+
+	file, err := tree.File(path)
+	if errors.Is(err, object.ErrFileNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrFileNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return []byte(contents), nil
+}
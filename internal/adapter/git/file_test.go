@@ -0,0 +1,24 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/adapter/git/gittest"
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFile(t *testing.T) {
+	r := gittest.New(t)
+	c := r.Commit("initial", map[string]string{"docs/a.md": "hello\n"})
+
+	data, err := newAdapter(r).ReadFile(context.Background(), c.String(), "docs/a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(data))
+
+	_, err = newAdapter(r).ReadFile(context.Background(), c.String(), "missing.md")
+	assert.ErrorIs(t, err, domain.ErrFileNotFound)
+}
@@ -0,0 +1,189 @@
+// Package gittest builds git repositories for tests of the git adapter and
+// of the services running on top of it.
+package gittest
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/filemode"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/stretchr/testify/require"
+)
+
+// Repo builds commit graphs directly in the object store of a bare
+// repository so tests can describe arbitrary topologies (branches, merges,
+// criss-cross) without a worktree. Path is where the repository lives, for
+// code that opens it itself.
+type Repo struct {
+	Repository *git.Repository
+	Path       string
+
+	t     testing.TB
+	clock time.Time
+}
+
+// New creates an empty repository in a temporary directory.
+func New(t testing.TB) *Repo {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, true)
+	require.NoError(t, err)
+
+	return &Repo{
+		Repository: repo,
+		Path:       dir,
+		t:          t,
+		clock:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// Commit writes a commit whose tree contains exactly files (path -> content).
+// Each commit is made an hour after the previous one.
+func (r *Repo) Commit(msg string, files map[string]string, parents ...plumbing.Hash) plumbing.Hash {
+	r.t.Helper()
+	return r.Backdated(msg, files, 0, parents...)
+}
+
+// Backdated writes a commit authored by earlier than it is committed, as
+// a rebase or cherry-pick leaves it.
+func (r *Repo) Backdated(msg string, files map[string]string, by time.Duration, parents ...plumbing.Hash) plumbing.Hash {
+	r.t.Helper()
+
+	r.clock = r.clock.Add(time.Hour)
+	return r.write(msg, files, r.clock.Add(-by), r.clock, parents...)
+}
+
+// Skewed writes a commit whose author and committer clocks both ran by
+// behind, so it is dated before commits made earlier.
+func (r *Repo) Skewed(msg string, files map[string]string, by time.Duration, parents ...plumbing.Hash) plumbing.Hash {
+	r.t.Helper()
+
+	r.clock = r.clock.Add(time.Hour)
+	return r.write(msg, files, r.clock.Add(-by), r.clock.Add(-by), parents...)
+}
+
+func (r *Repo) write(msg string, files map[string]string, authored, committed time.Time, parents ...plumbing.Hash) plumbing.Hash {
+	r.t.Helper()
+
+	sig := object.Signature{Name: "Tester", Email: "tester@example.com", When: committed}
+	author := sig
+	author.When = authored
+
+	c := &object.Commit{
+		Author:       author,
+		Committer:    sig,
+		Message:      msg,
+		TreeHash:     r.writeTree("", files),
+		ParentHashes: parents,
+	}
+
+	obj := r.Repository.Storer.NewEncodedObject()
+	require.NoError(r.t, c.Encode(obj))
+	hash, err := r.Repository.Storer.SetEncodedObject(obj)
+	require.NoError(r.t, err)
+
+	return hash
+}
+
+func (r *Repo) writeTree(dir string, files map[string]string) plumbing.Hash {
+	r.t.Helper()
+
+	var entries []object.TreeEntry
+	subdirs := map[string]map[string]string{}
+
+	for p, content := range files {
+		if dir != "" && !strings.HasPrefix(p, dir+"/") {
+			continue
+		}
+		rel := strings.TrimPrefix(p, dir+"/")
+		if dir == "" {
+			rel = p
+		}
+
+		if name, rest, ok := strings.Cut(rel, "/"); ok {
+			if subdirs[name] == nil {
+				subdirs[name] = map[string]string{}
+			}
+			subdirs[name][path.Join(dir, name, rest)] = content
+			continue
+		}
+
+		entries = append(entries, object.TreeEntry{
+			Name: rel,
+			Mode: filemode.Regular,
+			Hash: r.writeBlob(content),
+		})
+	}
+
+	for name, sub := range subdirs {
+		entries = append(entries, object.TreeEntry{
+			Name: name,
+			Mode: filemode.Dir,
+			Hash: r.writeTree(path.Join(dir, name), sub),
+		})
+	}
+
+	sort.Sort(object.TreeEntrySorter(entries))
+
+	obj := r.Repository.Storer.NewEncodedObject()
+	require.NoError(r.t, (&object.Tree{Entries: entries}).Encode(obj))
+	hash, err := r.Repository.Storer.SetEncodedObject(obj)
+	require.NoError(r.t, err)
+
+	return hash
+}
+
+func (r *Repo) writeBlob(content string) plumbing.Hash {
+	r.t.Helper()
+
+	obj := r.Repository.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	w, err := obj.Writer()
+	require.NoError(r.t, err)
+	_, err = w.Write([]byte(content))
+	require.NoError(r.t, err)
+	require.NoError(r.t, w.Close())
+
+	hash, err := r.Repository.Storer.SetEncodedObject(obj)
+	require.NoError(r.t, err)
+
+	return hash
+}
+
+// Tag points a lightweight tag at hash.
+func (r *Repo) Tag(name string, hash plumbing.Hash) {
+	r.t.Helper()
+	ref := plumbing.NewHashReference(plumbing.NewTagReferenceName(name), hash)
+	require.NoError(r.t, r.Repository.Storer.SetReference(ref))
+}
+
+// Branch points a branch at hash.
+func (r *Repo) Branch(name string, hash plumbing.Hash) {
+	r.t.Helper()
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), hash)
+	require.NoError(r.t, r.Repository.Storer.SetReference(ref))
+}
+
+// With returns a copy of files with the given path/content pairs applied.
+// An empty content removes the path.
+func With(files map[string]string, kv ...string) map[string]string {
+	out := make(map[string]string, len(files)+len(kv)/2)
+	for k, v := range files {
+		out[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] == "" {
+			delete(out, kv[i])
+			continue
+		}
+		out[kv[i]] = kv[i+1]
+	}
+	return out
+}
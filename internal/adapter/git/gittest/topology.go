@@ -0,0 +1,67 @@
+package gittest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6/plumbing"
+)
+
+// ReadmeTopology is the branched history from the README:
+//
+//	      C---D---E  (feature, tag v1.1.0)
+//	     /
+//	A---B---F---G    (main, tag v1.0.0)
+type ReadmeTopology struct {
+	*Repo
+	A, B, C, D, E, F, G plumbing.Hash
+}
+
+func NewReadmeTopology(t testing.TB) *ReadmeTopology {
+	t.Helper()
+	r := &ReadmeTopology{Repo: New(t)}
+
+	base := map[string]string{"README.md": "hello\n"}
+	r.A = r.Commit("A: initial", base)
+
+	base = With(base, "core.go", "package core\n")
+	r.B = r.Commit("B: add core", base, r.A)
+
+	feature := With(base, "feature.go", "package core\n\nfunc Feature() {}\n")
+	r.C = r.Commit("C: add feature", feature, r.B)
+	feature = With(feature, "core.go", "package core\n\nconst Version = 2\n")
+	r.D = r.Commit("D: bump core", feature, r.C)
+	feature = With(feature, "docs/feature.md", "# Feature\n")
+	r.E = r.Commit("E: document feature", feature, r.D)
+
+	main := With(base, "hotfix.go", "package core\n\nfunc Hotfix() {}\n")
+	r.F = r.Commit("F: hotfix", main, r.B)
+	main = With(main, "README.md", "hello, world\n")
+	r.G = r.Commit("G: readme", main, r.F)
+
+	r.Tag("v1.0.0", r.G)
+	r.Tag("v1.1.0", r.E)
+	r.Branch("main", r.G)
+	r.Branch("feature", r.E)
+
+	return r
+}
+
+// NewOrderTopology builds a feature branch whose second commit was rebased,
+// so author dates, committer dates and topology each disagree:
+//
+//	A---F1---F2   (F2 authored before F1)
+//	 \         \
+//	  B---------M---N
+func NewOrderTopology(t testing.TB) (r *Repo, a, n plumbing.Hash) {
+	t.Helper()
+	r = New(t)
+
+	a = r.Commit("A", map[string]string{"a": "1"})
+	f1 := r.Commit("F1", map[string]string{"a": "1", "f": "1"}, a)
+	b := r.Commit("B", map[string]string{"a": "1", "b": "1"}, a)
+	f2 := r.Backdated("F2", map[string]string{"a": "1", "f": "2"}, 150*time.Minute, f1)
+	m := r.Commit("M", map[string]string{"a": "1", "b": "1", "f": "2"}, b, f2)
+	n = r.Commit("N", map[string]string{"a": "1", "b": "1", "f": "2", "n": "1"}, m)
+	return r, a, n
+}
@@ -1,18 +1,25 @@
 package git
 
 import (
+	"container/heap"
 	"context"
 	"fmt"
+	"slices"
 	"sort"
+	"time"
 
 	"github.com/NERVEbing/supervisor/internal/domain"
 
-	"github.com/go-git/go-git/v6"
 	"github.com/go-git/go-git/v6/plumbing"
 	"github.com/go-git/go-git/v6/plumbing/object"
 )
 
-func (a *Adapter) GetHistory(ctx context.Context, fromHash, toHash string, excludeMerges bool) ([]domain.Commit, error) {
+// GetHistory lists the commits reachable from toHash but not from
+// fromHash, like `git rev-list from..to`, oldest first in opts.Order. With
+// opts.FirstParent only the first-parent chain of toHash is listed; with
+// opts.NestedMerges merges are kept and each one carries the commits it
+// brought in.
+func (a *Adapter) GetHistory(ctx context.Context, fromHash, toHash string, opts domain.HistoryOptions) ([]domain.Commit, error) {
 	from := plumbing.NewHash(fromHash)
 	to := plumbing.NewHash(toHash)
 
@@ -20,43 +27,337 @@ func (a *Adapter) GetHistory(ctx context.Context, fromHash, toHash string, exclu
 		return []domain.Commit{}, nil
 	}
 
-	cIter, err := a.repo.Log(&git.LogOptions{
-		From:  to,
-		Order: git.LogOrderCommitterTime,
-	})
+	walked, err := a.walkRange(ctx, from, to)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get commit log: %w", err)
+		return nil, err
+	}
+	inRange := make(map[plumbing.Hash]*object.Commit, len(walked))
+	for _, c := range walked {
+		inRange[c.Hash] = c
 	}
 
+	selected := walked
+	if opts.FirstParent || opts.NestedMerges {
+		selected = firstParentChain(inRange, to)
+	}
+	excludeMerges := !opts.MergeCommitsIncluded && !opts.NestedMerges
+
 	var commits []domain.Commit
+	for _, c := range orderCommits(selected, opts.Order) {
+		if excludeMerges && c.NumParents() > 1 {
+			continue
+		}
 
-	err = cIter.ForEach(func(c *object.Commit) error {
-		if c.Hash == from {
-			return domain.ErrStopIteration
+		commit, err := a.toCommit(ctx, c)
+		if err != nil {
+			return nil, err
 		}
+		if opts.NestedMerges && c.NumParents() > 1 {
+			if commit.Merged, err = a.mergedCommits(ctx, c, inRange, opts.Order); err != nil {
+				return nil, err
+			}
+		}
+		commits = append(commits, commit)
+	}
 
-		if excludeMerges && len(c.ParentHashes) > 1 {
-			return nil
+	return commits, nil
+}
+
+func (a *Adapter) toCommit(ctx context.Context, c *object.Commit) (domain.Commit, error) {
+	files, err := a.commitFiles(ctx, c)
+	if err != nil {
+		return domain.Commit{}, fmt.Errorf("failed to list files of commit %s: %w", c.Hash, err)
+	}
+
+	parents := make([]string, len(c.ParentHashes))
+	for i, p := range c.ParentHashes {
+		parents[i] = p.String()
+	}
+
+	return domain.Commit{
+		Hash:        c.Hash.String(),
+		Author:      c.Author.Name,
+		Date:        c.Author.When.UTC(),
+		Message:     c.Message,
+		DiffURL:     a.buildCommitURL(c.Hash.String()),
+		CommittedAt: c.Committer.When.UTC(),
+		Parents:     parents,
+		Files:       files,
+	}, nil
+}
+
+// mergedCommits lists the commits of the range that merge brought in: those
+// reachable from its other parents but not from its first one.
+func (a *Adapter) mergedCommits(ctx context.Context, merge *object.Commit, inRange map[plumbing.Hash]*object.Commit, order string) ([]domain.Commit, error) {
+	walked, err := a.walkRange(ctx, merge.ParentHashes[0], merge.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	// The merge itself stays in for ordering, so that a topological walk
+	// starts from it and follows its parents in order.
+	var brought []*object.Commit
+	for _, c := range walked {
+		if _, ok := inRange[c.Hash]; ok {
+			brought = append(brought, c)
 		}
+	}
 
-		commit := domain.Commit{
-			Hash:    c.Hash.String(),
-			Author:  c.Author.Name,
-			Date:    c.Author.When.UTC(),
-			Message: c.Message,
-			DiffURL: a.buildCommitURL(c.Hash.String()),
+	commits := make([]domain.Commit, 0, len(brought)-1)
+	for _, c := range orderCommits(brought, order) {
+		if c.Hash == merge.Hash {
+			continue
+		}
+		commit, err := a.toCommit(ctx, c)
+		if err != nil {
+			return nil, err
 		}
 		commits = append(commits, commit)
-		return nil
+	}
+	return commits, nil
+}
+
+// firstParentChain follows first parents from to while they are in the
+// range.
+func firstParentChain(inRange map[plumbing.Hash]*object.Commit, to plumbing.Hash) []*object.Commit {
+	var chain []*object.Commit
+	for c, ok := inRange[to]; ok; {
+		chain = append(chain, c)
+		if c.NumParents() == 0 {
+			break
+		}
+		c, ok = inRange[c.ParentHashes[0]]
+	}
+	return chain
+}
+
+// orderCommits sorts commits oldest first. Date orders start from the
+// topological order so that commits with equal dates keep a stable,
+// parents-first order.
+func orderCommits(commits []*object.Commit, order string) []*object.Commit {
+	sorted := topoSort(commits)
+	switch order {
+	case domain.HistoryOrderTopo:
+	case domain.HistoryOrderCommitterDate:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Committer.When.Before(sorted[j].Committer.When)
+		})
+	default:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Author.When.Before(sorted[j].Author.When)
+		})
+	}
+	return sorted
+}
+
+// topoSort lists commits parents first. It walks depth first from the
+// newest commit, first parents before the others, so the commits of a
+// merged branch end up together right before their merge, as with
+// `git log --topo-order --reverse`.
+func topoSort(commits []*object.Commit) []*object.Commit {
+	set := make(map[plumbing.Hash]*object.Commit, len(commits))
+	for _, c := range commits {
+		set[c.Hash] = c
+	}
+	starts := slices.Clone(commits)
+	sort.Slice(starts, func(i, j int) bool {
+		wi, wj := starts[i].Committer.When, starts[j].Committer.When
+		if !wi.Equal(wj) {
+			return wi.After(wj)
+		}
+		return starts[i].Hash.String() < starts[j].Hash.String()
 	})
 
-	if err != nil && err != domain.ErrStopIteration {
+	type frame struct {
+		commit *object.Commit
+		next   int
+	}
+	sorted := make([]*object.Commit, 0, len(commits))
+	visited := make(map[plumbing.Hash]bool, len(commits))
+	for _, start := range starts {
+		if visited[start.Hash] {
+			continue
+		}
+		visited[start.Hash] = true
+		stack := []frame{{commit: start}}
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if top.next < len(top.commit.ParentHashes) {
+				p := top.commit.ParentHashes[top.next]
+				top.next++
+				if parent, ok := set[p]; ok && !visited[p] {
+					visited[p] = true
+					stack = append(stack, frame{commit: parent})
+				}
+				continue
+			}
+			sorted = append(sorted, top.commit)
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return sorted
+}
+
+// rangeSlop is how many commits walkRange keeps walking after it could
+// stop, as git does, in case a commit with a skewed clock still excludes
+// something.
+const rangeSlop = 5
+
+// rangeNode is a commit seen by walkRange. Excluded nodes are reachable
+// from the range's base.
+type rangeNode struct {
+	commit   *object.Commit
+	excluded bool
+	expanded bool
+}
+
+// rangeQueue is a max-heap of nodes by committer time, so children are
+// normally visited before their parents.
+type rangeQueue []*rangeNode
+
+func (q rangeQueue) Len() int { return len(q) }
+func (q rangeQueue) Less(i, j int) bool {
+	return q[i].commit.Committer.When.After(q[j].commit.Committer.When)
+}
+func (q rangeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *rangeQueue) Push(x any)   { *q = append(*q, x.(*rangeNode)) }
+func (q *rangeQueue) Pop() any {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+// walkRange returns the commits reachable from to and not from base. Both
+// sides are walked together, newest first, and an exclusion found late is
+// pushed down to the ancestors already visited. The walk stops once only
+// excluded commits are queued and none of them is newer than the oldest
+// included commit, plus rangeSlop more, so history below the base is not
+// traversed; like git it relies on committer dates being roughly monotonic
+// to stop that early.
+func (a *Adapter) walkRange(ctx context.Context, base, to plumbing.Hash) ([]*object.Commit, error) {
+	nodes := map[plumbing.Hash]*rangeNode{}
+	queue := &rangeQueue{}
+	// pending counts the queued nodes that are not excluded; every node is
+	// queued until it is expanded.
+	pending := 0
+
+	var markExcluded func(n *rangeNode)
+	markExcluded = func(n *rangeNode) {
+		if n.excluded {
+			return
+		}
+		n.excluded = true
+		if !n.expanded {
+			pending--
+			return
+		}
+		for _, p := range n.commit.ParentHashes {
+			if parent, ok := nodes[p]; ok {
+				markExcluded(parent)
+			}
+		}
+	}
+
+	visit := func(hash plumbing.Hash, excluded bool) error {
+		if n, ok := nodes[hash]; ok {
+			if excluded {
+				markExcluded(n)
+			}
+			return nil
+		}
+		c, err := a.repo.CommitObject(hash)
+		if err != nil {
+			return fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		n := &rangeNode{commit: c, excluded: excluded}
+		nodes[hash] = n
+		heap.Push(queue, n)
+		if !excluded {
+			pending++
+		}
+		return nil
+	}
+
+	if err := visit(to, false); err != nil {
 		return nil, err
 	}
+	if !base.IsZero() {
+		if err := visit(base, true); err != nil {
+			return nil, err
+		}
+	}
 
-	sort.Slice(commits, func(i, j int) bool {
-		return commits[i].Date.Before(commits[j].Date)
-	})
+	var oldest time.Time
+	slop := rangeSlop
+	for queue.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if pending == 0 && (oldest.IsZero() || (*queue)[0].commit.Committer.When.Before(oldest)) {
+			if slop == 0 {
+				break
+			}
+			slop--
+		} else {
+			slop = rangeSlop
+		}
+
+		n := heap.Pop(queue).(*rangeNode)
+		n.expanded = true
+		if !n.excluded {
+			pending--
+			if when := n.commit.Committer.When; oldest.IsZero() || when.Before(oldest) {
+				oldest = when
+			}
+		}
+		for _, p := range n.commit.ParentHashes {
+			if err := visit(p, n.excluded); err != nil {
+				return nil, err
+			}
+		}
+	}
 
+	var commits []*object.Commit
+	for _, n := range nodes {
+		if n.expanded && !n.excluded {
+			commits = append(commits, n.commit)
+		}
+	}
 	return commits, nil
 }
+
+// commitFiles lists the paths a commit changed relative to its first parent,
+// pairing renames so callers can follow a file across them.
+func (a *Adapter) commitFiles(ctx context.Context, c *object.Commit) ([]domain.FilePath, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	parentTree := &object.Tree{}
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		parentTree, err = parent.Tree()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]domain.FilePath, 0, len(changes))
+	for _, change := range changes {
+		files = append(files, domain.FilePath{
+			Before: change.From.Name,
+			After:  change.To.Name,
+		})
+	}
+	return files, nil
+}
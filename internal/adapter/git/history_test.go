@@ -0,0 +1,197 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/adapter/git/gittest"
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHistory_ExcludesAncestorsOfSiblingBase(t *testing.T) {
+	r := gittest.NewReadmeTopology(t)
+
+	commits, err := newAdapter(r.Repo).GetHistory(context.Background(), r.G.String(), r.E.String(), domain.HistoryOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"C: add feature", "D: bump core", "E: document feature"}, commitMessages(commits))
+}
+
+// TestGetHistory_CrissCross diffs two branches that merged each other, so
+// B and C are both merge bases:
+//
+//	A---B---M1---D   (to)
+//	 \   \ /
+//	  \   X
+//	   \ / \
+//	    C---M2---E   (from)
+func TestGetHistory_CrissCross(t *testing.T) {
+	r := gittest.New(t)
+
+	a := r.Commit("A", map[string]string{"a": "1"})
+	b := r.Commit("B", map[string]string{"a": "1", "b": "1"}, a)
+	c := r.Commit("C", map[string]string{"a": "1", "c": "1"}, a)
+	m1 := r.Commit("M1", map[string]string{"a": "1", "b": "1", "c": "1"}, b, c)
+	m2 := r.Commit("M2", map[string]string{"a": "1", "b": "1", "c": "1"}, c, b)
+	d := r.Commit("D", map[string]string{"a": "1", "b": "1", "c": "1", "d": "1"}, m1)
+	e := r.Commit("E", map[string]string{"a": "1", "b": "1", "c": "1", "e": "1"}, m2)
+
+	tests := []struct {
+		name          string
+		base          plumbing.Hash
+		excludeMerges bool
+		want          []string
+	}{
+		{"from the other tip", e, false, []string{"D", "M1"}},
+		{"from one merge base", b, false, []string{"C", "D", "M1"}},
+		{"from the other merge base", c, false, []string{"B", "D", "M1"}},
+		{"without merges", b, true, []string{"C", "D"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commits, err := newAdapter(r).GetHistory(context.Background(), tt.base.String(), d.String(), domain.HistoryOptions{MergeCommitsIncluded: !tt.excludeMerges})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, commitMessages(commits))
+		})
+	}
+}
+
+// TestGetHistory_MultiMerge merges two side branches that started before
+// the base, so their commits are older than it yet not its ancestors:
+//
+//	  S1-----------.
+//	 /              \
+//	A---S2---------. \
+//	 \              \ \
+//	  B (base)-------M---N (to)
+func TestGetHistory_MultiMerge(t *testing.T) {
+	r := gittest.New(t)
+
+	a := r.Commit("A", map[string]string{"a": "1"})
+	s1 := r.Commit("S1", map[string]string{"a": "1", "s1": "1"}, a)
+	s2 := r.Commit("S2", map[string]string{"a": "1", "s2": "1"}, a)
+	b := r.Commit("B", map[string]string{"a": "1", "b": "1"}, a)
+	m := r.Commit("M", map[string]string{"a": "1", "b": "1", "s1": "1", "s2": "1"}, b, s1, s2)
+	n := r.Commit("N", map[string]string{"a": "1", "b": "1", "s1": "1", "s2": "1", "n": "1"}, m)
+
+	commits, err := newAdapter(r).GetHistory(context.Background(), b.String(), n.String(), domain.HistoryOptions{MergeCommitsIncluded: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"M", "N", "S1", "S2"}, commitMessages(commits))
+
+	commits, err = newAdapter(r).GetHistory(context.Background(), s1.String(), n.String(), domain.HistoryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"B", "N", "S2"}, commitMessages(commits))
+}
+
+// TestGetHistory_SkewedBase diffs from a base committed on a machine whose
+// clock was behind, so it is dated before the commit it shares with to:
+//
+//	A---X---T     (to)
+//	     \
+//	      B       (base, clock 10h behind)
+func TestGetHistory_SkewedBase(t *testing.T) {
+	r := gittest.New(t)
+
+	a := r.Commit("A", map[string]string{"a": "1"})
+	x := r.Commit("X", map[string]string{"a": "1", "x": "1"}, a)
+	to := r.Commit("T", map[string]string{"a": "1", "x": "1", "t": "1"}, x)
+	b := r.Skewed("B", map[string]string{"a": "1", "x": "1", "b": "1"}, 10*time.Hour, x)
+
+	commits, err := newAdapter(r).GetHistory(context.Background(), b.String(), to.String(), domain.HistoryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"T"}, commitMessages(commits))
+}
+
+func TestGetHistory_SameCommit(t *testing.T) {
+	r := gittest.NewReadmeTopology(t)
+
+	commits, err := newAdapter(r.Repo).GetHistory(context.Background(), r.E.String(), r.E.String(), domain.HistoryOptions{MergeCommitsIncluded: true})
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+}
+
+// inOrder lists commit messages as returned, nesting merged commits as
+// "M[F1 F2]".
+func inOrder(commits []domain.Commit) []string {
+	out := make([]string, len(commits))
+	for i, c := range commits {
+		out[i] = c.Message
+		if len(c.Merged) > 0 {
+			out[i] += fmt.Sprint(inOrder(c.Merged))
+		}
+	}
+	return out
+}
+
+func TestGetHistory_Order(t *testing.T) {
+	r, a, n := gittest.NewOrderTopology(t)
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{domain.HistoryOrderTopo, []string{"B", "F1", "F2", "M", "N"}},
+		{domain.HistoryOrderCommitterDate, []string{"F1", "B", "F2", "M", "N"}},
+		{domain.HistoryOrderAuthorDate, []string{"F2", "F1", "B", "M", "N"}},
+		{"", []string{"F2", "F1", "B", "M", "N"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			commits, err := newAdapter(r).GetHistory(context.Background(), a.String(), n.String(), domain.HistoryOptions{
+				MergeCommitsIncluded: true,
+				Order:                tt.order,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, inOrder(commits))
+		})
+	}
+}
+
+func TestGetHistory_FirstParent(t *testing.T) {
+	r, a, n := gittest.NewOrderTopology(t)
+
+	tests := []struct {
+		name string
+		opts domain.HistoryOptions
+		want []string
+	}{
+		{"with merges", domain.HistoryOptions{FirstParent: true, MergeCommitsIncluded: true}, []string{"B", "M", "N"}},
+		{"without merges", domain.HistoryOptions{FirstParent: true}, []string{"B", "N"}},
+		{"nested topo", domain.HistoryOptions{NestedMerges: true, Order: domain.HistoryOrderTopo}, []string{"B", "M[F1 F2]", "N"}},
+		{"nested author-date", domain.HistoryOptions{NestedMerges: true}, []string{"B", "M[F2 F1]", "N"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commits, err := newAdapter(r).GetHistory(context.Background(), a.String(), n.String(), tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, inOrder(commits))
+		})
+	}
+}
+
+// TestGetHistory_NestedMergesStayInRange nests the MultiMerge topology: the
+// side branches are older than the base but still brought in by M, while A
+// is reachable from the base and is left out.
+func TestGetHistory_NestedMergesStayInRange(t *testing.T) {
+	r := gittest.New(t)
+
+	a := r.Commit("A", map[string]string{"a": "1"})
+	s1 := r.Commit("S1", map[string]string{"a": "1", "s1": "1"}, a)
+	s2 := r.Commit("S2", map[string]string{"a": "1", "s2": "1"}, a)
+	b := r.Commit("B", map[string]string{"a": "1", "b": "1"}, a)
+	m := r.Commit("M", map[string]string{"a": "1", "b": "1", "s1": "1", "s2": "1"}, b, s1, s2)
+	n := r.Commit("N", map[string]string{"a": "1", "b": "1", "s1": "1", "s2": "1", "n": "1"}, m)
+
+	commits, err := newAdapter(r).GetHistory(context.Background(), b.String(), n.String(), domain.HistoryOptions{
+		NestedMerges: true,
+		Order:        domain.HistoryOrderTopo,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"M[S1 S2]", "N"}, inOrder(commits))
+}
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
 )
 
 func (a *Adapter) GetRepoURL() string {
@@ -57,48 +60,105 @@ func normalizeGitURL(rawURL string) string {
 	return rawURL
 }
 
-func (a *Adapter) buildCommitURL(hash string) string {
-	baseURL := a.GetRepoURL()
-	if baseURL == "" {
-		return ""
-	}
-
-	u, err := url.Parse(baseURL)
-	if err != nil {
-		return ""
-	}
+// forgeURLTemplates are the built-in links of each forge. A remote on an
+// unknown host gets GitHub's, which most forges also accept.
+var forgeURLTemplates = map[string]domain.URLTemplates{
+	domain.ForgeGitHub: {
+		Commit:      "{repo}/commit/{hash}",
+		Compare:     "{repo}/compare/{base}...{target}",
+		Blob:        "{repo}/blob/{ref}/{path}#L{line}",
+		Tag:         "{repo}/releases/tag/{tag}",
+		PullRequest: "{repo}/pull/{number}",
+	},
+	domain.ForgeGitLab: {
+		Commit:      "{repo}/-/commit/{hash}",
+		Compare:     "{repo}/-/compare/{base}...{target}",
+		Blob:        "{repo}/-/blob/{ref}/{path}#L{line}",
+		Tag:         "{repo}/-/tags/{tag}",
+		PullRequest: "{repo}/-/merge_requests/{number}",
+	},
+	domain.ForgeGitea: {
+		Commit:      "{repo}/commit/{hash}",
+		Compare:     "{repo}/compare/{base}...{target}",
+		Blob:        "{repo}/src/commit/{ref}/{path}#L{line}",
+		Tag:         "{repo}/releases/tag/{tag}",
+		PullRequest: "{repo}/pulls/{number}",
+	},
+	domain.ForgeBitbucket: {
+		Commit:      "{repo}/commits/{hash}",
+		Compare:     "{repo}/branches/compare/{base}..{target}",
+		Blob:        "{repo}/src/{ref}/{path}#lines-{line}",
+		Tag:         "{repo}/src/{tag}",
+		PullRequest: "{repo}/pull-requests/{number}",
+	},
+}
 
-	// GitHub/GitLab style
-	if strings.Contains(u.Host, "github.com") || strings.Contains(u.Host, "gitlab.com") {
-		return fmt.Sprintf("%s/commit/%s", baseURL, hash)
+// links returns the origin remote's web URL and the templates that apply
+// to it: those configured for the remote's forge, then those configured
+// for every forge, then the forge's built-in ones.
+func (a *Adapter) links() (string, domain.URLTemplates) {
+	repoURL := a.GetRepoURL()
+	kind := ""
+	if u, err := url.Parse(repoURL); err == nil {
+		kind = domain.ForgeForHost(u.Hostname(), a.forgeHosts)
 	}
-
-	// Bitbucket style
-	if strings.Contains(u.Host, "bitbucket.org") {
-		return fmt.Sprintf("%s/commits/%s", baseURL, hash)
+	builtin, ok := forgeURLTemplates[kind]
+	if !ok {
+		builtin = forgeURLTemplates[domain.ForgeGitHub]
 	}
+	return repoURL, a.forgeTemplates[kind].Or(a.urlTemplates).Or(builtin)
+}
 
-	return fmt.Sprintf("%s/commit/%s", baseURL, hash)
+func (a *Adapter) buildCommitURL(hash string) string {
+	repoURL, t := a.links()
+	return expandURLTemplate(t.Commit, repoURL, "{hash}", hash)
 }
 
 func (a *Adapter) GetDiffURL(base, target string) string {
-	baseURL := a.GetRepoURL()
-	if baseURL == "" {
-		return ""
+	repoURL, t := a.links()
+	return expandURLTemplate(t.Compare, repoURL, "{base}", shortHash(base), "{target}", shortHash(target))
+}
+
+func (a *Adapter) GetBlobURL(ref, path string, line int) string {
+	repoURL, t := a.links()
+	template := t.Blob
+	if line <= 0 {
+		template, _, _ = strings.Cut(template, "#")
 	}
+	return expandURLTemplate(template, repoURL, "{ref}", ref, "{path}", escapePath(path), "{line}", strconv.Itoa(line))
+}
 
-	u, err := url.Parse(baseURL)
-	if err != nil {
+func (a *Adapter) GetTagURL(tag string) string {
+	repoURL, t := a.links()
+	return expandURLTemplate(t.Tag, repoURL, "{tag}", url.PathEscape(tag))
+}
+
+func (a *Adapter) GetPullRequestURL(number int) string {
+	repoURL, t := a.links()
+	return expandURLTemplate(t.PullRequest, repoURL, "{number}", strconv.Itoa(number))
+}
+
+// expandURLTemplate fills {repo} and the given placeholder/value pairs. A
+// template that needs {repo} yields no link when there is no origin remote.
+func expandURLTemplate(template, repoURL string, placeholders ...string) string {
+	if template == "" || repoURL == "" && strings.Contains(template, "{repo}") {
 		return ""
 	}
+	return strings.NewReplacer(append([]string{"{repo}", repoURL}, placeholders...)...).Replace(template)
+}
 
-	if strings.Contains(u.Host, "github.com") || strings.Contains(u.Host, "gitlab.com") {
-		return fmt.Sprintf("%s/compare/%s...%s", baseURL, base[:7], target[:7])
+// escapePath escapes each segment of a repository path for a URL.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
 	}
+	return strings.Join(segments, "/")
+}
 
-	if strings.Contains(u.Host, "bitbucket.org") {
-		return fmt.Sprintf("%s/branches/compare/%s..%s", baseURL, target[:7], base[:7])
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
 	}
-
-	return fmt.Sprintf("%s/compare/%s...%s", baseURL, base[:7], target[:7])
+	return hash
 }
@@ -0,0 +1,211 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	linkBase   = "1111111111111111111111111111111111111111"
+	linkTarget = "2222222222222222222222222222222222222222"
+)
+
+// forgeLinks are every link a forge builds for one repository.
+type forgeLinks struct {
+	commit, compare, blob, blobNoLine, tag, pullRequest string
+}
+
+// remoteAdapter is an adapter over an empty repository whose origin is
+// remote, or that has no origin when remote is empty.
+func remoteAdapter(t *testing.T, remote string, opts ...Option) *Adapter {
+	t.Helper()
+	repo, err := git.Init(memory.NewStorage())
+	require.NoError(t, err)
+	if remote != "" {
+		_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remote}})
+		require.NoError(t, err)
+	}
+	a := &Adapter{repo: repo}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+func links(a *Adapter) forgeLinks {
+	return forgeLinks{
+		commit:      a.buildCommitURL(linkTarget),
+		compare:     a.GetDiffURL(linkBase, linkTarget),
+		blob:        a.GetBlobURL(linkTarget, "cmd/my tool/main.go", 12),
+		blobNoLine:  a.GetBlobURL(linkTarget, "README.md", 0),
+		tag:         a.GetTagURL("v1.0.0"),
+		pullRequest: a.GetPullRequestURL(42),
+	}
+}
+
+func TestLinks(t *testing.T) {
+	selfHosted := WithForgeHosts(map[string]string{
+		"git.example.com":  domain.ForgeGitLab,
+		"code.example.com": domain.ForgeGitea,
+		"BB.example.com":   domain.ForgeBitbucket,
+	})
+
+	tests := []struct {
+		name   string
+		remote string
+		opts   []Option
+		want   forgeLinks
+	}{
+		{
+			name:   "github",
+			remote: "git@github.com:acme/widget.git",
+			want: forgeLinks{
+				commit:      "https://github.com/acme/widget/commit/" + linkTarget,
+				compare:     "https://github.com/acme/widget/compare/1111111...2222222",
+				blob:        "https://github.com/acme/widget/blob/" + linkTarget + "/cmd/my%20tool/main.go#L12",
+				blobNoLine:  "https://github.com/acme/widget/blob/" + linkTarget + "/README.md",
+				tag:         "https://github.com/acme/widget/releases/tag/v1.0.0",
+				pullRequest: "https://github.com/acme/widget/pull/42",
+			},
+		},
+		{
+			name:   "gitlab",
+			remote: "https://gitlab.com/group/sub/widget.git",
+			want: forgeLinks{
+				commit:      "https://gitlab.com/group/sub/widget/-/commit/" + linkTarget,
+				compare:     "https://gitlab.com/group/sub/widget/-/compare/1111111...2222222",
+				blob:        "https://gitlab.com/group/sub/widget/-/blob/" + linkTarget + "/cmd/my%20tool/main.go#L12",
+				blobNoLine:  "https://gitlab.com/group/sub/widget/-/blob/" + linkTarget + "/README.md",
+				tag:         "https://gitlab.com/group/sub/widget/-/tags/v1.0.0",
+				pullRequest: "https://gitlab.com/group/sub/widget/-/merge_requests/42",
+			},
+		},
+		{
+			name:   "self-hosted gitlab",
+			remote: "git@git.example.com:group/widget.git",
+			opts:   []Option{selfHosted},
+			want: forgeLinks{
+				commit:      "https://git.example.com/group/widget/-/commit/" + linkTarget,
+				compare:     "https://git.example.com/group/widget/-/compare/1111111...2222222",
+				blob:        "https://git.example.com/group/widget/-/blob/" + linkTarget + "/cmd/my%20tool/main.go#L12",
+				blobNoLine:  "https://git.example.com/group/widget/-/blob/" + linkTarget + "/README.md",
+				tag:         "https://git.example.com/group/widget/-/tags/v1.0.0",
+				pullRequest: "https://git.example.com/group/widget/-/merge_requests/42",
+			},
+		},
+		{
+			name:   "self-hosted gitea",
+			remote: "https://code.example.com/acme/widget",
+			opts:   []Option{selfHosted},
+			want: forgeLinks{
+				commit:      "https://code.example.com/acme/widget/commit/" + linkTarget,
+				compare:     "https://code.example.com/acme/widget/compare/1111111...2222222",
+				blob:        "https://code.example.com/acme/widget/src/commit/" + linkTarget + "/cmd/my%20tool/main.go#L12",
+				blobNoLine:  "https://code.example.com/acme/widget/src/commit/" + linkTarget + "/README.md",
+				tag:         "https://code.example.com/acme/widget/releases/tag/v1.0.0",
+				pullRequest: "https://code.example.com/acme/widget/pulls/42",
+			},
+		},
+		{
+			name:   "bitbucket",
+			remote: "git@bitbucket.org:acme/widget.git",
+			want: forgeLinks{
+				commit:      "https://bitbucket.org/acme/widget/commits/" + linkTarget,
+				compare:     "https://bitbucket.org/acme/widget/branches/compare/1111111..2222222",
+				blob:        "https://bitbucket.org/acme/widget/src/" + linkTarget + "/cmd/my%20tool/main.go#lines-12",
+				blobNoLine:  "https://bitbucket.org/acme/widget/src/" + linkTarget + "/README.md",
+				tag:         "https://bitbucket.org/acme/widget/src/v1.0.0",
+				pullRequest: "https://bitbucket.org/acme/widget/pull-requests/42",
+			},
+		},
+		{
+			name:   "self-hosted bitbucket, host case ignored",
+			remote: "https://bb.example.com/acme/widget",
+			opts:   []Option{selfHosted},
+			want: forgeLinks{
+				commit:      "https://bb.example.com/acme/widget/commits/" + linkTarget,
+				compare:     "https://bb.example.com/acme/widget/branches/compare/1111111..2222222",
+				blob:        "https://bb.example.com/acme/widget/src/" + linkTarget + "/cmd/my%20tool/main.go#lines-12",
+				blobNoLine:  "https://bb.example.com/acme/widget/src/" + linkTarget + "/README.md",
+				tag:         "https://bb.example.com/acme/widget/src/v1.0.0",
+				pullRequest: "https://bb.example.com/acme/widget/pull-requests/42",
+			},
+		},
+		{
+			name:   "unknown host uses github links",
+			remote: "https://git.internal/acme/widget",
+			want: forgeLinks{
+				commit:      "https://git.internal/acme/widget/commit/" + linkTarget,
+				compare:     "https://git.internal/acme/widget/compare/1111111...2222222",
+				blob:        "https://git.internal/acme/widget/blob/" + linkTarget + "/cmd/my%20tool/main.go#L12",
+				blobNoLine:  "https://git.internal/acme/widget/blob/" + linkTarget + "/README.md",
+				tag:         "https://git.internal/acme/widget/releases/tag/v1.0.0",
+				pullRequest: "https://git.internal/acme/widget/pull/42",
+			},
+		},
+		{
+			name:   "forge templates replace the built-in ones",
+			remote: "https://git.example.com/group/widget",
+			opts: []Option{selfHosted, WithForgeURLTemplates(map[string]domain.URLTemplates{
+				domain.ForgeGitLab: {Tag: "{repo}/-/releases/{tag}", Blob: "{repo}/-/blob/{ref}/{path}?plain=1#L{line}"},
+				domain.ForgeGitHub: {Commit: "unused"},
+			})},
+			want: forgeLinks{
+				commit:      "https://git.example.com/group/widget/-/commit/" + linkTarget,
+				compare:     "https://git.example.com/group/widget/-/compare/1111111...2222222",
+				blob:        "https://git.example.com/group/widget/-/blob/" + linkTarget + "/cmd/my%20tool/main.go?plain=1#L12",
+				blobNoLine:  "https://git.example.com/group/widget/-/blob/" + linkTarget + "/README.md?plain=1",
+				tag:         "https://git.example.com/group/widget/-/releases/v1.0.0",
+				pullRequest: "https://git.example.com/group/widget/-/merge_requests/42",
+			},
+		},
+		{
+			name:   "url templates replace every forge's, but not the remote forge's templates",
+			remote: "git@github.com:acme/widget.git",
+			opts: []Option{
+				WithForgeURLTemplates(map[string]domain.URLTemplates{domain.ForgeGitHub: {Commit: "{repo}/c/{hash}"}}),
+				WithURLTemplates(domain.URLTemplates{
+					Commit:      "https://cgit.example.com/widget/commit/?id={hash}",
+					PullRequest: "https://review.example.com/{number}",
+				}),
+			},
+			want: forgeLinks{
+				commit:      "https://github.com/acme/widget/c/" + linkTarget,
+				compare:     "https://github.com/acme/widget/compare/1111111...2222222",
+				blob:        "https://github.com/acme/widget/blob/" + linkTarget + "/cmd/my%20tool/main.go#L12",
+				blobNoLine:  "https://github.com/acme/widget/blob/" + linkTarget + "/README.md",
+				tag:         "https://github.com/acme/widget/releases/tag/v1.0.0",
+				pullRequest: "https://review.example.com/42",
+			},
+		},
+		{
+			name: "no origin",
+			opts: []Option{WithURLTemplates(domain.URLTemplates{Commit: "https://cgit.example.com/widget/commit/?id={hash}"})},
+			want: forgeLinks{commit: "https://cgit.example.com/widget/commit/?id=" + linkTarget},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, links(remoteAdapter(t, tt.remote, tt.opts...)))
+		})
+	}
+}
+
+func TestExpandURLTemplate(t *testing.T) {
+	assert.Equal(t,
+		"https://git.example.com/acme/widget/-/compare/abc...def",
+		expandURLTemplate("{repo}/-/compare/{base}...{target}", "https://git.example.com/acme/widget", "{base}", "abc", "{target}", "def"))
+
+	assert.Equal(t,
+		"https://cgit.example.com/widget/commit/?id=abc",
+		expandURLTemplate("https://cgit.example.com/widget/commit/?id={hash}", "", "{hash}", "abc"))
+
+	assert.Empty(t, expandURLTemplate("{repo}/commit/{hash}", "", "{hash}", "abc"))
+}
@@ -0,0 +1,45 @@
+package git
+
+import (
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/go-git/go-git/v6/plumbing/format/diff"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// encodePatch renders patch as unified-diff hunks with the given context,
+// dropping the per-file header lines.
+func encodePatch(patch *object.Patch, contextLines int) (*domain.FilePatch, error) {
+	var b strings.Builder
+	if err := diff.NewUnifiedEncoder(&b, contextLines).Encode(patch); err != nil {
+		return nil, err
+	}
+
+	result := &domain.FilePatch{Hunks: []domain.PatchHunk{}}
+	var lines strings.Builder
+	flush := func() {
+		if n := len(result.Hunks); n > 0 {
+			result.Hunks[n-1].Lines = lines.String()
+		}
+		lines.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(b.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "@@ ") {
+			flush()
+			result.Hunks = append(result.Hunks, domain.PatchHunk{Header: strings.TrimSuffix(line, "\n")})
+			continue
+		}
+		if len(result.Hunks) > 0 {
+			lines.WriteString(line)
+		}
+	}
+	flush()
+
+	return result, nil
+}
@@ -0,0 +1,40 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/adapter/git/gittest"
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateDiff_Patches(t *testing.T) {
+	r := gittest.New(t)
+
+	from := r.Commit("initial", map[string]string{
+		"main.go":  "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n",
+		"logo.png": "png",
+	})
+	to := r.Commit("change", map[string]string{
+		"main.go":  "package main\n\nfunc a() {}\n\nfunc b() { return }\n\nfunc c() {}\n",
+		"logo.png": "png2",
+	}, from)
+
+	opts := domain.RequestOptions{Patches: domain.PatchOptions{Include: true, ContextLines: 1}}
+	files, _, err := newAdapter(r).CalculateDiff(context.Background(), from.String(), to.String(), opts)
+	require.NoError(t, err)
+
+	main := findChange(t, files, "main.go")
+	require.NotNil(t, main.Patch)
+	assert.Equal(t, []domain.PatchHunk{
+		{Header: "@@ -4,3 +4,3 @@ func a() {}", Lines: " \n-func b() {}\n+func b() { return }\n \n"},
+	}, main.Patch.Hunks)
+	assert.Nil(t, findChange(t, files, "logo.png").Patch, "binary files carry no patch")
+
+	files, _, err = newAdapter(r).CalculateDiff(context.Background(), from.String(), to.String(), domain.RequestOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, findChange(t, files, "main.go").Patch, "patches are off by default")
+}
@@ -0,0 +1,263 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/utils/merkletrie"
+)
+
+// defaultSimilarityThreshold matches git's default for -M and -C.
+const defaultSimilarityThreshold = 50
+
+// maxRenameCandidates caps the number of content comparisons per diff.
+// Beyond it only exact (same blob) renames and copies are detected.
+const maxRenameCandidates = 250000
+
+// detectedChange is a tree change annotated with the outcome of rename and
+// copy detection.
+type detectedChange struct {
+	*object.Change
+	similarity int
+	copied     bool
+}
+
+type renameCandidate struct {
+	src, dst int
+	score    int
+	sameBase bool
+}
+
+type renameDetector struct {
+	a         *Adapter
+	threshold int
+	contents  map[plumbing.Hash][]byte
+}
+
+func (a *Adapter) detectRenames(changes object.Changes, opts domain.RequestOptions) ([]detectedChange, error) {
+	result := make([]detectedChange, 0, len(changes))
+	if !opts.DetectRenames && !opts.DetectCopies {
+		for _, c := range changes {
+			result = append(result, detectedChange{Change: c})
+		}
+		return result, nil
+	}
+
+	threshold := opts.SimilarityThreshold
+	if threshold <= 0 {
+		threshold = defaultSimilarityThreshold
+	}
+	d := &renameDetector{
+		a:         a,
+		threshold: threshold,
+		contents:  map[plumbing.Hash][]byte{},
+	}
+
+	var added, deleted, modified []*object.Change
+	for _, c := range changes {
+		action, err := c.Action()
+		if err != nil {
+			return nil, err
+		}
+		switch action {
+		case merkletrie.Insert:
+			added = append(added, c)
+		case merkletrie.Delete:
+			deleted = append(deleted, c)
+		default:
+			modified = append(modified, c)
+		}
+	}
+
+	addedUsed := make([]bool, len(added))
+	deletedUsed := make([]bool, len(deleted))
+
+	// Renames pair a deleted path with an added one; each side is used once.
+	renames, err := d.match(deleted, added, addedUsed, func(i int) bool { return deletedUsed[i] })
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range renames {
+		deletedUsed[m.src] = true
+		addedUsed[m.dst] = true
+		result = append(result, detectedChange{
+			Change:     &object.Change{From: deleted[m.src].From, To: added[m.dst].To},
+			similarity: m.score,
+		})
+	}
+
+	// Copies pair an added path with any deleted or modified pre-image;
+	// a source may be copied several times.
+	if opts.DetectCopies {
+		sources := make([]*object.Change, 0, len(deleted)+len(modified))
+		sources = append(sources, deleted...)
+		sources = append(sources, modified...)
+
+		copies, err := d.match(sources, added, addedUsed, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range copies {
+			addedUsed[m.dst] = true
+			result = append(result, detectedChange{
+				Change:     &object.Change{From: sources[m.src].From, To: added[m.dst].To},
+				similarity: m.score,
+				copied:     true,
+			})
+		}
+	}
+
+	for i, c := range deleted {
+		if !deletedUsed[i] {
+			result = append(result, detectedChange{Change: c})
+		}
+	}
+	for i, c := range added {
+		if !addedUsed[i] {
+			result = append(result, detectedChange{Change: c})
+		}
+	}
+	for _, c := range modified {
+		result = append(result, detectedChange{Change: c})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return getChangePath(result[i].Change) < getChangePath(result[j].Change)
+	})
+
+	return result, nil
+}
+
+// match greedily pairs sources with unused destinations, best score first.
+// When srcUsed is nil every source may be matched more than once.
+func (d *renameDetector) match(srcs, dsts []*object.Change, dstUsed []bool, srcUsed func(int) bool) ([]renameCandidate, error) {
+	exactOnly := len(srcs)*len(dsts) > maxRenameCandidates
+
+	var candidates []renameCandidate
+	for si, src := range srcs {
+		if !src.From.TreeEntry.Mode.IsFile() {
+			continue
+		}
+		for di, dst := range dsts {
+			if dstUsed[di] || !dst.To.TreeEntry.Mode.IsFile() {
+				continue
+			}
+
+			score := 0
+			switch {
+			case src.From.TreeEntry.Hash == dst.To.TreeEntry.Hash:
+				score = 100
+			case exactOnly:
+				continue
+			default:
+				s, err := d.score(src.From.TreeEntry.Hash, dst.To.TreeEntry.Hash)
+				if err != nil {
+					return nil, err
+				}
+				score = s
+			}
+
+			if score < d.threshold {
+				continue
+			}
+			candidates = append(candidates, renameCandidate{
+				src:      si,
+				dst:      di,
+				score:    score,
+				sameBase: path.Base(src.From.Name) == path.Base(dst.To.Name),
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].sameBase && !candidates[j].sameBase
+	})
+
+	srcTaken := map[int]bool{}
+	dstTaken := map[int]bool{}
+	var matches []renameCandidate
+	for _, c := range candidates {
+		if dstTaken[c.dst] {
+			continue
+		}
+		if srcUsed != nil && (srcTaken[c.src] || srcUsed(c.src)) {
+			continue
+		}
+		srcTaken[c.src] = true
+		dstTaken[c.dst] = true
+		matches = append(matches, c)
+	}
+
+	return matches, nil
+}
+
+// score returns the percentage of content shared by two blobs, comparing
+// them line by line and weighing each line by its size. Scores are capped
+// at 99 so that 100 always means identical content.
+func (d *renameDetector) score(from, to plumbing.Hash) (int, error) {
+	a, err := d.content(from)
+	if err != nil {
+		return 0, err
+	}
+	b, err := d.content(to)
+	if err != nil {
+		return 0, err
+	}
+
+	maxSize := max(len(a), len(b))
+	if maxSize == 0 {
+		return 100, nil
+	}
+	if min(len(a), len(b))*100/maxSize < d.threshold {
+		return 0, nil
+	}
+
+	lines := map[string]int{}
+	for _, line := range bytes.SplitAfter(a, []byte("\n")) {
+		lines[string(line)]++
+	}
+
+	common := 0
+	for _, line := range bytes.SplitAfter(b, []byte("\n")) {
+		if lines[string(line)] > 0 {
+			lines[string(line)]--
+			common += len(line)
+		}
+	}
+
+	return min(common*100/maxSize, 99), nil
+}
+
+func (d *renameDetector) content(hash plumbing.Hash) ([]byte, error) {
+	if data, ok := d.contents[hash]; ok {
+		return data, nil
+	}
+
+	blob, err := d.a.repo.BlobObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+
+	d.contents[hash] = data
+	return data, nil
+}
@@ -0,0 +1,142 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/adapter/git/gittest"
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func numberedLines(prefix string, n int) string {
+	var b strings.Builder
+	for i := range n {
+		b.WriteString(prefix)
+		b.WriteString(strings.Repeat("x", i%7))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func findChange(t *testing.T, files []domain.FileChange, path string) domain.FileChange {
+	t.Helper()
+	for _, f := range files {
+		if f.Path.After == path || (f.Path.After == "" && f.Path.Before == path) {
+			return f
+		}
+	}
+	require.Failf(t, "change not found", "no change for %s", path)
+	return domain.FileChange{}
+}
+
+func TestCalculateDiff_RenameDetection(t *testing.T) {
+	r := gittest.New(t)
+
+	body := numberedLines("line ", 20)
+	from := r.Commit("initial", map[string]string{
+		"old/exact.go":  "package exact\n",
+		"old/edited.go": body,
+		"old/gone.go":   numberedLines("gone ", 10),
+	})
+	to := r.Commit("move", map[string]string{
+		"new/exact.go":  "package exact\n",
+		"new/edited.go": body + "one more line\n",
+		"new/fresh.go":  numberedLines("fresh ", 10),
+	}, from)
+
+	tests := []struct {
+		name      string
+		opts      domain.RequestOptions
+		renamed   map[string]string
+		unchanged []string
+	}{
+		{
+			name:      "disabled",
+			opts:      domain.RequestOptions{},
+			unchanged: []string{"new/exact.go", "new/edited.go", "old/exact.go", "old/edited.go"},
+		},
+		{
+			name: "default threshold",
+			opts: domain.RequestOptions{DetectRenames: true},
+			renamed: map[string]string{
+				"new/exact.go":  "old/exact.go",
+				"new/edited.go": "old/edited.go",
+			},
+		},
+		{
+			name:    "strict threshold keeps only exact renames",
+			opts:    domain.RequestOptions{DetectRenames: true, SimilarityThreshold: 100},
+			renamed: map[string]string{"new/exact.go": "old/exact.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, _, err := newAdapter(r).CalculateDiff(context.Background(), from.String(), to.String(), tt.opts)
+			require.NoError(t, err)
+
+			for after, before := range tt.renamed {
+				fc := findChange(t, files, after)
+				assert.Equal(t, "renamed", fc.ChangeType)
+				assert.Equal(t, before, fc.Path.Before)
+				assert.True(t, fc.Classification.IsRename)
+				assert.GreaterOrEqual(t, fc.Similarity, 50)
+			}
+			for _, p := range tt.unchanged {
+				fc := findChange(t, files, p)
+				assert.Contains(t, []string{"added", "deleted"}, fc.ChangeType)
+				assert.Zero(t, fc.Similarity)
+			}
+
+			// Unrelated content never pairs up.
+			assert.Equal(t, "deleted", findChange(t, files, "old/gone.go").ChangeType)
+			assert.Equal(t, "added", findChange(t, files, "new/fresh.go").ChangeType)
+		})
+	}
+}
+
+func TestCalculateDiff_ExactRenameScoresFull(t *testing.T) {
+	r := gittest.New(t)
+
+	from := r.Commit("initial", map[string]string{"a.txt": "same\n"})
+	to := r.Commit("rename", map[string]string{"b.txt": "same\n"}, from)
+
+	files, _, err := newAdapter(r).CalculateDiff(context.Background(), from.String(), to.String(), domain.RequestOptions{DetectRenames: true})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	assert.Equal(t, domain.FilePath{Before: "a.txt", After: "b.txt"}, files[0].Path)
+	assert.Equal(t, 100, files[0].Similarity)
+	assert.Zero(t, files[0].Lines.Added)
+	assert.Zero(t, files[0].Lines.Deleted)
+}
+
+func TestCalculateDiff_CopyDetection(t *testing.T) {
+	r := gittest.New(t)
+
+	template := numberedLines("tmpl ", 30)
+	from := r.Commit("initial", map[string]string{"handler.go": template})
+	to := r.Commit("copy", map[string]string{
+		"handler.go":      template + "// tweak\n",
+		"handler_copy.go": template,
+	}, from)
+
+	files, _, err := newAdapter(r).CalculateDiff(context.Background(), from.String(), to.String(), domain.RequestOptions{DetectRenames: true})
+	require.NoError(t, err)
+	assert.Equal(t, "added", findChange(t, files, "handler_copy.go").ChangeType)
+
+	files, _, err = newAdapter(r).CalculateDiff(context.Background(), from.String(), to.String(), domain.RequestOptions{DetectRenames: true, DetectCopies: true})
+	require.NoError(t, err)
+
+	copied := findChange(t, files, "handler_copy.go")
+	assert.Equal(t, "copied", copied.ChangeType)
+	assert.Equal(t, "handler.go", copied.Path.Before)
+	assert.True(t, copied.Classification.IsCopy)
+	assert.Equal(t, 100, copied.Similarity)
+
+	assert.Equal(t, "modified", findChange(t, files, "handler.go").ChangeType)
+}
@@ -9,13 +9,58 @@ import (
 )
 
 type Adapter struct {
-	repo *git.Repository
+	repo           *git.Repository
+	classifier     domain.Classifier
+	urlTemplates   domain.URLTemplates
+	forgeHosts     map[string]string
+	forgeTemplates map[string]domain.URLTemplates
 }
 
-func NewAdapter(repoPath string) (domain.Repository, error) {
+// Option customises an Adapter.
+type Option func(*Adapter)
+
+// WithClassifier sets how changed files are classified; without one, files
+// carry no language, class or tags beyond their change type and binary flag.
+func WithClassifier(c domain.Classifier) Option {
+	return func(a *Adapter) {
+		a.classifier = c
+	}
+}
+
+// WithURLTemplates replaces the built-in links, whatever the forge, unless
+// WithForgeURLTemplates sets them for the remote's forge; empty templates
+// keep the forge's.
+func WithURLTemplates(templates domain.URLTemplates) Option {
+	return func(a *Adapter) {
+		a.urlTemplates = templates
+	}
+}
+
+// WithForgeHosts maps self-hosted hosts to the kind of forge they run, so
+// their links follow that forge's templates.
+func WithForgeHosts(hosts map[string]string) Option {
+	return func(a *Adapter) {
+		a.forgeHosts = hosts
+	}
+}
+
+// WithForgeURLTemplates replaces, per forge kind, the links of that forge;
+// they take precedence over WithURLTemplates, and empty templates fall back
+// to it.
+func WithForgeURLTemplates(templates map[string]domain.URLTemplates) Option {
+	return func(a *Adapter) {
+		a.forgeTemplates = templates
+	}
+}
+
+func NewAdapter(repoPath string, opts ...Option) (domain.Repository, error) {
 	repo, err := git.PlainOpen(repoPath)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", domain.ErrRepoNotFound, err)
 	}
-	return &Adapter{repo: repo}, nil
+	a := &Adapter{repo: repo}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a, nil
 }
@@ -64,25 +64,36 @@ func (a *Adapter) isBranch(ref string) bool {
 	return err == domain.ErrStopIteration
 }
 
-func (a *Adapter) CalculateBaseline(ctx context.Context, fromHash, toHash string) (string, string, bool, error) {
+func (a *Adapter) CalculateBaseline(ctx context.Context, fromHash, toHash, strategy string) (string, bool, error) {
 	from := plumbing.NewHash(fromHash)
 	to := plumbing.NewHash(toHash)
 
 	isAncestor, err := a.isAncestor(from, to)
 	if err != nil {
-		return "", "", false, fmt.Errorf("failed to check ancestry: %w", err)
+		return "", false, fmt.Errorf("failed to check ancestry: %w", err)
 	}
 
-	if isAncestor {
-		return fromHash, "direct", true, nil
-	}
+	switch strategy {
+	case domain.BaselineMergeBase, "":
+		if isAncestor {
+			return fromHash, true, nil
+		}
 
-	mergeBase, err := a.findMergeBase(from, to)
-	if err != nil {
-		return "", "", false, fmt.Errorf("failed to find merge-base: %w", err)
+		mergeBase, err := a.findMergeBase(from, to)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to find merge-base: %w", err)
+		}
+		return mergeBase.String(), false, nil
+	case domain.BaselineDirect:
+		if !isAncestor {
+			return "", false, fmt.Errorf("%w: %s is not an ancestor of %s", domain.ErrNotAncestor, fromHash, toHash)
+		}
+		return fromHash, true, nil
+	case domain.BaselineTwoDot:
+		return fromHash, isAncestor, nil
+	default:
+		return "", false, fmt.Errorf("%w: unknown baseline strategy %q", domain.ErrInvalidOption, strategy)
 	}
-
-	return mergeBase.String(), "merge-base", false, nil
 }
 
 func (a *Adapter) isAncestor(ancestor, descendant plumbing.Hash) (bool, error) {
@@ -96,7 +107,7 @@ func (a *Adapter) isAncestor(ancestor, descendant plumbing.Hash) (bool, error) {
 		return false, err
 	}
 
-	return descendantCommit.IsAncestor(ancestorCommit)
+	return ancestorCommit.IsAncestor(descendantCommit)
 }
 
 func (a *Adapter) findMergeBase(hash1, hash2 plumbing.Hash) (*plumbing.Hash, error) {
@@ -0,0 +1,10 @@
+package git
+
+import (
+	"github.com/NERVEbing/supervisor/internal/adapter/git/gittest"
+)
+
+// newAdapter reads r directly, without going through NewAdapter.
+func newAdapter(r *gittest.Repo) *Adapter {
+	return &Adapter{repo: r.Repository}
+}
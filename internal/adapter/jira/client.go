@@ -0,0 +1,177 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+)
+
+// jiraTimeLayout is the timestamp format JIRA uses for date-time fields.
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+type Config struct {
+	BaseURL    string
+	APIVersion string
+	AuthType   string
+	User       string
+	Token      string
+	HTTPClient *http.Client
+}
+
+type Adapter struct {
+	baseURL    *url.URL
+	apiVersion string
+	authType   string
+	user       string
+	token      string
+	client     *http.Client
+}
+
+func NewAdapter(cfg Config) (domain.IssueTracker, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("%w: JIRA base URL is required", domain.ErrInvalidOption)
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("%w: invalid JIRA base URL %q", domain.ErrInvalidOption, cfg.BaseURL)
+	}
+
+	apiVersion := cfg.APIVersion
+	if apiVersion == "" {
+		apiVersion = "2"
+	}
+	if apiVersion != "2" && apiVersion != "3" {
+		return nil, fmt.Errorf("%w: unsupported JIRA API version %q", domain.ErrInvalidOption, apiVersion)
+	}
+
+	authType := strings.ToLower(cfg.AuthType)
+	if authType == "" {
+		authType = AuthBearer
+		if cfg.User != "" {
+			authType = AuthBasic
+		}
+	}
+	if authType != AuthBasic && authType != AuthBearer {
+		return nil, fmt.Errorf("%w: unsupported JIRA auth type %q", domain.ErrInvalidOption, cfg.AuthType)
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Adapter{
+		baseURL:    baseURL,
+		apiVersion: apiVersion,
+		authType:   authType,
+		user:       cfg.User,
+		token:      cfg.Token,
+		client:     client,
+	}, nil
+}
+
+type issueResponse struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary string `json:"summary"`
+		Status  struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+		IssueType struct {
+			Name string `json:"name"`
+		} `json:"issuetype"`
+		Assignee *struct {
+			DisplayName string `json:"displayName"`
+		} `json:"assignee"`
+		FixVersions []struct {
+			Name string `json:"name"`
+		} `json:"fixVersions"`
+		Labels         []string `json:"labels"`
+		ResolutionDate string   `json:"resolutiondate"`
+	} `json:"fields"`
+}
+
+func (a *Adapter) GetIssue(ctx context.Context, key string) (*domain.TrackedIssue, error) {
+	endpoint := a.baseURL.JoinPath("rest", "api", a.apiVersion, "issue", key)
+	endpoint.RawQuery = url.Values{
+		"fields": {"summary,status,issuetype,assignee,fixVersions,labels,resolutiondate"},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build JIRA request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	switch a.authType {
+	case AuthBasic:
+		req.SetBasicAuth(a.user, a.token)
+	case AuthBearer:
+		if a.token != "" {
+			req.Header.Set("Authorization", "Bearer "+a.token)
+		}
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JIRA issue %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", domain.ErrIssueNotFound, key)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("failed to fetch JIRA issue %s: %s: %s", key, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var payload issueResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode JIRA issue %s: %w", key, err)
+	}
+
+	issue := &domain.TrackedIssue{
+		Key:         payload.Key,
+		URL:         a.baseURL.JoinPath("browse", payload.Key).String(),
+		Summary:     payload.Fields.Summary,
+		Status:      payload.Fields.Status.Name,
+		Closed:      payload.Fields.Status.StatusCategory.Key == "done",
+		Type:        payload.Fields.IssueType.Name,
+		FixVersions: []string{},
+		Labels:      payload.Fields.Labels,
+	}
+	if issue.Labels == nil {
+		issue.Labels = []string{}
+	}
+	if payload.Fields.Assignee != nil {
+		issue.Assignee = payload.Fields.Assignee.DisplayName
+	}
+	for _, v := range payload.Fields.FixVersions {
+		issue.FixVersions = append(issue.FixVersions, v.Name)
+	}
+	if payload.Fields.ResolutionDate != "" {
+		resolved, err := time.Parse(jiraTimeLayout, payload.Fields.ResolutionDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resolution date of %s: %w", key, err)
+		}
+		resolved = resolved.UTC()
+		issue.ResolvedAt = &resolved
+	}
+
+	return issue, nil
+}
@@ -0,0 +1,126 @@
+package jira
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const issueJSON = `{
+  "key": "ABC-7",
+  "fields": {
+    "summary": "Search results are stale",
+    "status": {"name": "Done", "statusCategory": {"key": "done"}},
+    "issuetype": {"name": "Bug"},
+    "assignee": {"displayName": "Grace Hopper"},
+    "fixVersions": [{"name": "1.1.0"}, {"name": "1.0.3"}],
+    "labels": ["search", "backend"],
+    "resolutiondate": "2024-03-05T14:30:00.000+0100"
+  }
+}`
+
+// fakeJira serves a single issue and records the last request it saw.
+type fakeJira struct {
+	*httptest.Server
+	lastPath string
+	lastAuth string
+	lastUser string
+	lastPass string
+}
+
+func newFakeJira(t *testing.T) *fakeJira {
+	f := &fakeJira{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.lastPath = r.URL.Path
+		f.lastAuth = r.Header.Get("Authorization")
+		f.lastUser, f.lastPass, _ = r.BasicAuth()
+
+		switch r.URL.Path {
+		case "/jira/rest/api/2/issue/ABC-7", "/jira/rest/api/3/issue/ABC-7":
+			assert.Contains(t, r.URL.Query().Get("fields"), "fixVersions")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(issueJSON))
+		case "/jira/rest/api/2/issue/ABC-500":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		default:
+			http.Error(w, `{"errorMessages":["Issue does not exist"]}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestGetIssue_BasicAuthV2(t *testing.T) {
+	server := newFakeJira(t)
+
+	tracker, err := NewAdapter(Config{BaseURL: server.URL + "/jira/", User: "bot", Token: "secret"})
+	require.NoError(t, err)
+
+	issue, err := tracker.GetIssue(context.Background(), "ABC-7")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/jira/rest/api/2/issue/ABC-7", server.lastPath)
+	assert.Equal(t, "bot", server.lastUser)
+	assert.Equal(t, "secret", server.lastPass)
+
+	resolved := time.Date(2024, 3, 5, 13, 30, 0, 0, time.UTC)
+	assert.Equal(t, &domain.TrackedIssue{
+		Key:         "ABC-7",
+		URL:         server.URL + "/jira/browse/ABC-7",
+		Summary:     "Search results are stale",
+		Status:      "Done",
+		Closed:      true,
+		Type:        "Bug",
+		Assignee:    "Grace Hopper",
+		FixVersions: []string{"1.1.0", "1.0.3"},
+		Labels:      []string{"search", "backend"},
+		ResolvedAt:  &resolved,
+	}, issue)
+}
+
+func TestGetIssue_BearerAuthV3(t *testing.T) {
+	server := newFakeJira(t)
+
+	tracker, err := NewAdapter(Config{BaseURL: server.URL + "/jira", APIVersion: "3", AuthType: "Bearer", Token: "pat"})
+	require.NoError(t, err)
+
+	_, err = tracker.GetIssue(context.Background(), "ABC-7")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/jira/rest/api/3/issue/ABC-7", server.lastPath)
+	assert.Equal(t, "Bearer pat", server.lastAuth)
+}
+
+func TestGetIssue_Errors(t *testing.T) {
+	server := newFakeJira(t)
+
+	tracker, err := NewAdapter(Config{BaseURL: server.URL + "/jira"})
+	require.NoError(t, err)
+
+	_, err = tracker.GetIssue(context.Background(), "ABC-404")
+	assert.ErrorIs(t, err, domain.ErrIssueNotFound)
+
+	_, err = tracker.GetIssue(context.Background(), "ABC-500")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, domain.ErrIssueNotFound)
+	assert.Contains(t, err.Error(), "500")
+}
+
+func TestNewAdapter_ValidatesConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{},
+		{BaseURL: "not a url"},
+		{BaseURL: "https://jira.example.com", APIVersion: "4"},
+		{BaseURL: "https://jira.example.com", AuthType: "oauth"},
+	} {
+		_, err := NewAdapter(cfg)
+		assert.ErrorIs(t, err, domain.ErrInvalidOption, "%+v", cfg)
+	}
+}
@@ -0,0 +1,60 @@
+package presenter
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// DefaultChangelogTemplate renders a Keep a Changelog release section: a
+// version heading, a breaking-changes list, one heading per section with
+// scoped entries in bold and pull request numbers, and a link reference to
+// the compare view.
+const DefaultChangelogTemplate = `{{define "entry" -}}
+{{if .Scope}}**{{.Scope}}:** {{end}}{{.Subject}} {{if .PullRequest}}({{if .PullRequestURL}}[#{{.PullRequest}}]({{.PullRequestURL}}){{else}}#{{.PullRequest}}{{end}}) {{end}}{{if .URL}}([{{short .Hash}}]({{.URL}})){{else}}({{short .Hash}}){{end}}
+{{- end -}}
+
+## [{{.Version}}]{{if not .Date.IsZero}} - {{.Date.Format "2006-01-02"}}{{end}}
+{{- if .Breaking}}
+
+### Breaking Changes
+{{range .Breaking}}
+- {{template "entry" .}}{{if .BreakingNote}}: {{.BreakingNote}}{{end}}
+{{- end}}
+{{- end}}
+{{- range .Sections}}
+
+### {{.Title}}
+{{range .Scopes}}{{range .Entries}}
+- {{template "entry" .}}
+{{- end}}{{end}}
+{{- end}}
+{{- if .CompareURL}}
+
+[{{.Version}}]: {{.CompareURL}}
+{{- end}}
+`
+
+// ToChangelog renders cl with a text/template; an empty text means
+// DefaultChangelogTemplate. Templates can call "short" to abbreviate a
+// commit hash.
+func ToChangelog(cl *domain.Changelog, text string) ([]byte, error) {
+	if text == "" {
+		text = DefaultChangelogTemplate
+	}
+
+	tmpl, err := template.New("changelog").Funcs(template.FuncMap{
+		"short": shortHash,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid changelog template: %v", domain.ErrInvalidOption, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, cl); err != nil {
+		return nil, fmt.Errorf("failed to render changelog: %w", err)
+	}
+	return buf.Bytes(), nil
+}
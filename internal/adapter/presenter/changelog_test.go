@@ -0,0 +1,73 @@
+package presenter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleChangelog() *domain.Changelog {
+	breaking := domain.ChangelogEntry{
+		Type: "feat", Scope: "api", Subject: "drop v1 routes", Breaking: true, BreakingNote: "use /v2",
+		Hash: "1111111111111111111111111111111111111111", URL: "https://example.com/c/1111111",
+		PullRequest: 7, PullRequestURL: "https://example.com/pull/7",
+	}
+	return &domain.Changelog{
+		Version:    "v1.1.0",
+		Date:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		CompareURL: "https://example.com/compare/v1.0.0...v1.1.0",
+		Breaking:   []domain.ChangelogEntry{breaking},
+		Sections: []domain.ChangelogSection{
+			{Title: "Added", Scopes: []domain.ChangelogScope{
+				{Name: "api", Entries: []domain.ChangelogEntry{breaking}},
+				{Entries: []domain.ChangelogEntry{{Type: "feat", Subject: "add export", Hash: "2222222222222222222222222222222222222222", PullRequest: 42}}},
+			}},
+			{Title: "Fixed", Scopes: []domain.ChangelogScope{
+				{Entries: []domain.ChangelogEntry{{Type: "fix", Subject: "handle empty input", Hash: "3333333333333333333333333333333333333333", URL: "https://example.com/c/3333333"}}},
+			}},
+		},
+	}
+}
+
+func TestToChangelog_DefaultTemplate(t *testing.T) {
+	out, err := ToChangelog(sampleChangelog(), "")
+	require.NoError(t, err)
+
+	assert.Equal(t, `## [v1.1.0] - 2024-05-01
+
+### Breaking Changes
+
+- **api:** drop v1 routes ([#7](https://example.com/pull/7)) ([1111111](https://example.com/c/1111111)): use /v2
+
+### Added
+
+- **api:** drop v1 routes ([#7](https://example.com/pull/7)) ([1111111](https://example.com/c/1111111))
+- add export (#42) (2222222)
+
+### Fixed
+
+- handle empty input ([3333333](https://example.com/c/3333333))
+
+[v1.1.0]: https://example.com/compare/v1.0.0...v1.1.0
+`, string(out))
+}
+
+func TestToChangelog_CustomTemplate(t *testing.T) {
+	tmpl := `{{.Version}}{{range .Sections}}|{{.Title}}{{range .Scopes}}{{range .Entries}}:{{short .Hash}}{{end}}{{end}}{{end}}`
+
+	out, err := ToChangelog(sampleChangelog(), tmpl)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0|Added:1111111:2222222|Fixed:3333333", string(out))
+}
+
+func TestToChangelog_InvalidTemplate(t *testing.T) {
+	_, err := ToChangelog(sampleChangelog(), "{{.Version")
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+
+	_, err = ToChangelog(sampleChangelog(), "{{.Nope}}")
+	assert.Error(t, err)
+}
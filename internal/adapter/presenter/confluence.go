@@ -0,0 +1,108 @@
+package presenter
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// confluenceTemplate renders Confluence storage format: well-formed XHTML
+// plus ac: macros, suitable for the body.storage field of the REST API.
+var confluenceTemplate = template.Must(template.New("confluence").Funcs(template.FuncMap{
+	"short":        shortHash,
+	"statusColour": statusColour,
+	"prReview":     prReview,
+	"prURL":        prURL,
+}).Parse(`{{define "commits" -}}
+<ul>
+{{- range .}}
+<li>{{if .URL}}<a href="{{.URL}}"><code>{{.ShortHash}}</code></a>{{else}}<code>{{.ShortHash}}</code>{{end}} {{.Subject}} — {{.Author}}, {{.Date}}
+{{- if .Merged}}{{template "commits" .Merged}}{{end}}</li>
+{{- end}}
+</ul>
+{{- end -}}
+<p><strong>Repository:</strong> {{if .Repository.URL}}<a href="{{.Repository.URL}}">{{.Repository.Name}}</a>{{else}}{{.Repository.Name}}{{end}}<br/>
+<strong>Range:</strong> <code>{{.From.Ref}}</code> ({{short .From.Commit}}) → <code>{{.To.Ref}}</code> ({{short .To.Commit}})<br/>
+<strong>Baseline:</strong> {{.Baseline.Strategy}} at <code>{{short .Baseline.BaseCommit}}</code> ({{.Baseline.Ancestry.Relationship}})
+{{- if .CompareURL}}<br/>
+<strong>Compare:</strong> <a href="{{.CompareURL}}">{{short .Baseline.BaseCommit}}...{{short .To.Commit}}</a>{{end}}</p>
+<h2>Summary</h2>
+<table><tbody>
+<tr><th>Files added</th><th>Files modified</th><th>Files deleted</th><th>Files renamed</th><th>Files copied</th><th>Lines added</th><th>Lines deleted</th><th>Net</th></tr>
+<tr><td>{{.Summary.Files.Added}}</td><td>{{.Summary.Files.Modified}}</td><td>{{.Summary.Files.Deleted}}</td><td>{{.Summary.Files.Renamed}}</td><td>{{.Summary.Files.Copied}}</td><td>+{{.Summary.Lines.Added}}</td><td>-{{.Summary.Lines.Deleted}}</td><td>{{.Summary.Lines.Net}}</td></tr>
+</tbody></table>
+<p>{{.Filters.BinaryFilesDetected}} binary files detected, {{.Filters.FilesFilteredOut}} files filtered out.</p>
+{{- if .Classes}}
+<h2>Classification</h2>
+<table><tbody>
+<tr><th>Class</th><th>Files</th><th>Added</th><th>Deleted</th><th>Churn</th></tr>
+{{- range .Classes}}
+<tr><td>{{.Name}}</td><td>{{.Files}}</td><td>+{{.Added}}</td><td>-{{.Deleted}}</td><td>{{printf "%.1f%%" .ChurnPercent}}</td></tr>
+{{- end}}
+</tbody></table>
+{{- end}}
+{{- if .Languages}}
+<h2>Languages</h2>
+<table><tbody>
+<tr><th>Language</th><th>Files</th><th>Added</th><th>Deleted</th></tr>
+{{- range .Languages}}
+<tr><td>{{.Name}}</td><td>{{.Files}}</td><td>+{{.Added}}</td><td>-{{.Deleted}}</td></tr>
+{{- end}}
+</tbody></table>
+{{- end}}
+{{- if .Issues}}
+<h2>Issues</h2>
+<ul>
+{{- range .Issues}}
+<li>{{if .Details}}<a href="{{.Details.URL}}">{{.Key}}</a> {{.Details.Summary}} ({{.Details.Type}}, {{.Details.Status}}){{else}}{{.Key}}{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+<h2>Commits</h2>
+{{- if .Commits}}
+{{template "commits" .Commits}}
+{{- else}}
+<p>{{.Note}}</p>
+{{- end}}
+{{- if .PRs}}
+<h2>Pull Requests</h2>
+<ul>
+{{- range .PRs}}
+<li>{{if prURL .}}<a href="{{prURL .}}"><strong>#{{.Number}}</strong></a>{{else}}<strong>#{{.Number}}</strong>{{end}} {{.Title}} — {{.Author}}, {{if eq .Style "squash"}}squashed{{else}}{{len .Commits}} commits{{if .SourceBranch}} from <code>{{.SourceBranch}}</code>{{end}}, merged{{end}} in {{if .CommitURL}}<a href="{{.CommitURL}}"><code>{{short .MergeCommit}}</code></a>{{else}}<code>{{short .MergeCommit}}</code>{{end}}{{with prReview .}}; {{.}}{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Files}}
+<h2>Files</h2>
+<ac:structured-macro ac:name="expand"><ac:parameter ac:name="title">{{len .Files}} changed files</ac:parameter><ac:rich-text-body>
+<table><tbody>
+<tr><th>Change</th><th>Path</th><th>Language</th><th>Added</th><th>Deleted</th></tr>
+{{- range .Files}}
+<tr><td><ac:structured-macro ac:name="status"><ac:parameter ac:name="colour">{{statusColour .ChangeType}}</ac:parameter><ac:parameter ac:name="title">{{.ChangeType}}</ac:parameter></ac:structured-macro></td><td>{{if .URL}}<a href="{{.URL}}"><code>{{.Path}}</code></a>{{else}}<code>{{.Path}}</code>{{end}}</td><td>{{.Language}}</td>{{if .Binary}}<td colspan="2">binary</td>{{else}}<td>+{{.Added}}</td><td>-{{.Deleted}}</td>{{end}}</tr>
+{{- end}}
+</tbody></table>
+</ac:rich-text-body></ac:structured-macro>
+{{- end}}
+`))
+
+func ToConfluenceStorage(r *domain.DiffReport) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := confluenceTemplate.Execute(&buf, newReportView(r)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func statusColour(changeType string) string {
+	switch changeType {
+	case "added", "copied":
+		return "Green"
+	case "deleted":
+		return "Red"
+	case "renamed":
+		return "Blue"
+	default:
+		return "Yellow"
+	}
+}
@@ -0,0 +1,150 @@
+package presenter
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"short":    shortHash,
+	"prReview": prReview,
+	"prURL":    prURL,
+}).Parse(`{{define "commits" -}}
+<ul>
+{{- range .}}
+<li>{{if .URL}}<a href="{{.URL}}"><code>{{.ShortHash}}</code></a>{{else}}<code>{{.ShortHash}}</code>{{end}} {{.Subject}} <span class="muted">— {{.Author}}, {{.Date}}</span>
+{{- if .Merged}}{{template "commits" .Merged}}{{end}}</li>
+{{- end}}
+</ul>
+{{- end -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}: {{.From.Ref}} → {{.To.Ref}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 64rem; color: #1f2328; padding: 0 1rem; }
+h1 { font-size: 1.6rem; border-bottom: 1px solid #d0d7de; padding-bottom: .3rem; }
+h2 { font-size: 1.2rem; margin-top: 2rem; }
+table { border-collapse: collapse; width: 100%; margin: .5rem 0; }
+th, td { border: 1px solid #d0d7de; padding: .3rem .6rem; text-align: left; }
+th { background: #f6f8fa; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.add { color: #1a7f37; } .del { color: #cf222e; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: .9em; }
+dl { display: grid; grid-template-columns: max-content auto; gap: .2rem 1rem; }
+dt { font-weight: 600; } dd { margin: 0; }
+.muted { color: #656d76; }
+pre { background: #f6f8fa; padding: .6rem; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}: {{.From.Ref}} → {{.To.Ref}}</h1>
+<dl>
+<dt>Repository</dt><dd>{{if .Repository.URL}}<a href="{{.Repository.URL}}">{{.Repository.Name}}</a>{{else}}{{.Repository.Name}}{{end}}</dd>
+<dt>From</dt><dd>{{if .From.URL}}<a href="{{.From.URL}}"><code>{{.From.Ref}}</code></a>{{else}}<code>{{.From.Ref}}</code>{{end}} ({{.From.Type}}, <code>{{short .From.Commit}}</code>)</dd>
+<dt>To</dt><dd>{{if .To.URL}}<a href="{{.To.URL}}"><code>{{.To.Ref}}</code></a>{{else}}<code>{{.To.Ref}}</code>{{end}} ({{.To.Type}}, <code>{{short .To.Commit}}</code>)</dd>
+<dt>Baseline</dt><dd>{{.Baseline.Strategy}} at <code>{{short .Baseline.BaseCommit}}</code> ({{.Baseline.Ancestry.Relationship}})</dd>
+{{- if .CompareURL}}
+<dt>Compare</dt><dd><a href="{{.CompareURL}}">{{short .Baseline.BaseCommit}}...{{short .To.Commit}}</a></dd>
+{{- end}}
+</dl>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Files added</th><th>Files modified</th><th>Files deleted</th><th>Files renamed</th><th>Files copied</th><th>Lines added</th><th>Lines deleted</th><th>Net</th></tr>
+<tr>
+<td class="num">{{.Summary.Files.Added}}</td><td class="num">{{.Summary.Files.Modified}}</td><td class="num">{{.Summary.Files.Deleted}}</td><td class="num">{{.Summary.Files.Renamed}}</td><td class="num">{{.Summary.Files.Copied}}</td>
+<td class="num add">+{{.Summary.Lines.Added}}</td><td class="num del">-{{.Summary.Lines.Deleted}}</td><td class="num">{{.Summary.Lines.Net}}</td>
+</tr>
+</table>
+<p class="muted">{{.Filters.BinaryFilesDetected}} binary files detected, {{.Filters.FilesFilteredOut}} files filtered out.</p>
+{{- if .Classes}}
+
+<h2>Classification</h2>
+<table>
+<tr><th>Class</th><th>Files</th><th>Added</th><th>Deleted</th><th>Churn</th></tr>
+{{- range .Classes}}
+<tr><td>{{.Name}}</td><td class="num">{{.Files}}</td><td class="num add">+{{.Added}}</td><td class="num del">-{{.Deleted}}</td><td class="num">{{printf "%.1f%%" .ChurnPercent}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Languages}}
+
+<h2>Languages</h2>
+<table>
+<tr><th>Language</th><th>Files</th><th>Added</th><th>Deleted</th></tr>
+{{- range .Languages}}
+<tr><td>{{.Name}}</td><td class="num">{{.Files}}</td><td class="num add">+{{.Added}}</td><td class="num del">-{{.Deleted}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Files}}
+
+<h2>Files</h2>
+<table>
+<tr><th>Change</th><th>Path</th><th>Language</th><th>Added</th><th>Deleted</th></tr>
+{{- range .Files}}
+<tr><td>{{.ChangeType}}</td><td>{{if .URL}}<a href="{{.URL}}"><code>{{.Path}}</code></a>{{else}}<code>{{.Path}}</code>{{end}}</td><td>{{.Language}}</td>{{if .Binary}}<td class="num muted" colspan="2">binary</td>{{else}}<td class="num add">+{{.Added}}</td><td class="num del">-{{.Deleted}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Patches}}
+
+<h2>Patches</h2>
+{{- range .Patches}}
+<h3><code>{{.Path}}</code></h3>
+<pre><code>{{.Diff}}</code></pre>
+{{- end}}
+{{- end}}
+{{- if .Excluded}}
+
+<h2>Excluded by filters</h2>
+<table>
+<tr><th>Change</th><th>Path</th><th>Added</th><th>Deleted</th><th>Rule</th></tr>
+{{- range .Excluded}}
+<tr><td>{{.ChangeType}}</td><td><code>{{.Path}}</code></td><td class="num add">+{{.Added}}</td><td class="num del">-{{.Deleted}}</td><td>{{.Rule}} <code>{{.Pattern}}</code></td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+<h2>Commits</h2>
+{{- if .Commits}}
+{{template "commits" .Commits}}
+{{- else}}
+<p class="muted">{{.Note}}</p>
+{{- end}}
+{{- if .PRs}}
+
+<h2>Pull Requests</h2>
+<ul>
+{{- range .PRs}}
+<li>{{if prURL .}}<a href="{{prURL .}}"><strong>#{{.Number}}</strong></a>{{else}}<strong>#{{.Number}}</strong>{{end}} {{.Title}} <span class="muted">— {{.Author}}, {{if eq .Style "squash"}}squashed{{else}}{{len .Commits}} commits{{if .SourceBranch}} from <code>{{.SourceBranch}}</code>{{end}}, merged{{end}} in {{if .CommitURL}}<a href="{{.CommitURL}}"><code>{{short .MergeCommit}}</code></a>{{else}}<code>{{short .MergeCommit}}</code>{{end}}{{with prReview .}}; {{.}}{{end}}</span></li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Issues}}
+
+<h2>Issues</h2>
+<ul>
+{{- range .Issues}}
+<li>{{if .Details}}<a href="{{.Details.URL}}"><strong>{{.Key}}</strong></a> {{.Details.Summary}} ({{.Details.Type}}, {{.Details.Status}}){{else}}<strong>{{.Key}}</strong>{{end}} <span class="muted">— {{len .Commits}} commits, {{len .Files}} files</span>
+{{- range .Warnings}}<br><span class="del">⚠ <code>{{short .Commit}}</code> {{.Reason}}</span>{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+
+<p class="muted">Generated {{.Generated.Format "2006-01-02 15:04 MST"}}</p>
+</body>
+</html>
+`))
+
+func ToHTML(r *domain.DiffReport) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, newReportView(r)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -8,7 +8,7 @@ import (
 )
 
 type jsonDiffReport struct {
-	SchemaVersion string          `json:"schema_version"`
+	SchemaVersion string          `json:"schema_version" enum:"1.0"`
 	Repository    jsonRepository  `json:"repository"`
 	Request       jsonRequest     `json:"request"`
 	Resolution    jsonResolution  `json:"resolution"`
@@ -16,6 +16,7 @@ type jsonDiffReport struct {
 	Filters       jsonFilters     `json:"filters"`
 	TreeDiff      jsonTreeDiff    `json:"tree_diff"`
 	HistoryView   jsonHistoryView `json:"history_view"`
+	Issues        []jsonIssue     `json:"issues,omitempty"`
 	DiffLinks     jsonDiffLinks   `json:"diff_links"`
 	Integrity     jsonIntegrity   `json:"integrity"`
 	Metadata      jsonMetadata    `json:"metadata"`
@@ -24,7 +25,7 @@ type jsonDiffReport struct {
 type jsonRepository struct {
 	Name string `json:"name"`
 	URL  string `json:"url"`
-	VCS  string `json:"vcs"`
+	VCS  string `json:"vcs" enum:"git"`
 }
 
 type jsonRequest struct {
@@ -35,13 +36,30 @@ type jsonRequest struct {
 }
 
 type jsonRequestOptions struct {
-	IgnoreMergeCommits bool `json:"ignore_merge_commits"`
-	DetectRenames      bool `json:"detect_renames"`
+	IgnoreMergeCommits  bool     `json:"ignore_merge_commits"`
+	FirstParent         bool     `json:"first_parent,omitempty"`
+	HistoryOrder        string   `json:"history_order,omitempty" enum:"topo,author-date,committer-date"`
+	NestMerges          bool     `json:"nest_merges,omitempty"`
+	DetectRenames       bool     `json:"detect_renames"`
+	DetectCopies        bool     `json:"detect_copies,omitempty"`
+	SimilarityThreshold int      `json:"similarity_threshold,omitempty"`
+	BaselineStrategy    string   `json:"baseline_strategy,omitempty" enum:"merge-base,direct,two-dot"`
+	IssueProjects       []string `json:"issue_projects,omitempty"`
+	SkipIgnoreFile      bool     `json:"skip_ignore_file,omitempty"`
+	ExplainFilters      bool     `json:"explain_filters,omitempty"`
+	IncludePatches      bool     `json:"include_patches,omitempty"`
+	PatchContextLines   int      `json:"patch_context_lines,omitempty"`
+	PatchMaxFileBytes   int      `json:"patch_max_file_bytes,omitempty"`
+	PatchMaxTotalBytes  int      `json:"patch_max_total_bytes,omitempty"`
 }
 
 type jsonRequestFilters struct {
 	ExcludeSuffixes []string `json:"exclude_suffixes"`
 	ExcludePaths    []string `json:"exclude_paths"`
+	IncludeGlobs    []string `json:"include_globs,omitempty"`
+	ExcludeGlobs    []string `json:"exclude_globs,omitempty"`
+	ExcludeRegexes  []string `json:"exclude_regexes,omitempty"`
+	IgnorePatterns  []string `json:"ignore_patterns,omitempty"`
 }
 
 type jsonResolution struct {
@@ -53,24 +71,38 @@ type jsonResolutionRef struct {
 	Ref    string `json:"ref"`
 	Type   string `json:"type"`
 	Commit string `json:"commit"`
+	// URL links a tag's page on the forge.
+	URL string `json:"url,omitempty"`
 }
 
 type jsonBaseline struct {
-	Strategy   string       `json:"strategy"`
+	Strategy   string       `json:"strategy" enum:"merge-base,direct,two-dot"`
 	BaseCommit string       `json:"base_commit"`
 	Ancestry   jsonAncestry `json:"ancestry"`
 }
 
 type jsonAncestry struct {
 	IsLinear     bool   `json:"is_linear"`
-	Relationship string `json:"relationship"`
+	Relationship string `json:"relationship" enum:"linear,branched"`
 }
 
 type jsonFilters struct {
 	SuffixExcluded      []string `json:"suffix_excluded"`
 	PathExcluded        []string `json:"path_excluded"`
+	IgnoreFilePatterns  []string `json:"ignore_file_patterns,omitempty"`
 	BinaryFilesDetected int      `json:"binary_files_detected"`
 	FilesFilteredOut    int      `json:"files_filtered_out"`
+	// Excluded is present only when the report was generated with
+	// --explain-filters.
+	Excluded []jsonExcludedFile `json:"excluded,omitempty"`
+}
+
+type jsonExcludedFile struct {
+	Path       jsonFilePath      `json:"path"`
+	ChangeType string            `json:"change_type" enum:"added,modified,deleted,renamed,copied"`
+	Lines      jsonFileLineStats `json:"lines"`
+	Rule       string            `json:"rule" enum:"include_glob,suffix,path,glob,regex,ignore,ignore_file,classification,only_classification"`
+	Pattern    string            `json:"pattern"`
 }
 
 type jsonTreeDiff struct {
@@ -79,8 +111,26 @@ type jsonTreeDiff struct {
 }
 
 type jsonDiffSummary struct {
-	Files jsonFileStats        `json:"files"`
-	Lines jsonSummaryLineStats `json:"lines"`
+	Files            jsonFileStats        `json:"files"`
+	Lines            jsonSummaryLineStats `json:"lines"`
+	ByClassification []jsonBreakdown      `json:"by_classification,omitempty"`
+	ByLanguage       []jsonBreakdown      `json:"by_language,omitempty"`
+	// Patches is present only when patch hunks were requested.
+	Patches *jsonPatchSummary `json:"patches,omitempty"`
+}
+
+type jsonPatchSummary struct {
+	Bytes          int `json:"bytes"`
+	TruncatedFiles int `json:"truncated_files"`
+}
+
+type jsonBreakdown struct {
+	Name         string  `json:"name"`
+	Files        int     `json:"files"`
+	LinesAdded   int     `json:"lines_added"`
+	LinesDeleted int     `json:"lines_deleted"`
+	FilesPercent float64 `json:"files_percent"`
+	ChurnPercent float64 `json:"churn_percent"`
 }
 
 type jsonFileStats struct {
@@ -88,6 +138,7 @@ type jsonFileStats struct {
 	Modified int `json:"modified"`
 	Deleted  int `json:"deleted"`
 	Renamed  int `json:"renamed"`
+	Copied   int `json:"copied,omitempty"`
 }
 
 type jsonSummaryLineStats struct {
@@ -98,11 +149,26 @@ type jsonSummaryLineStats struct {
 
 type jsonFileChange struct {
 	Path           jsonFilePath       `json:"path"`
-	ChangeType     string             `json:"change_type"`
+	ChangeType     string             `json:"change_type" enum:"added,modified,deleted,renamed,copied"`
+	Similarity     int                `json:"similarity,omitempty"`
 	Language       string             `json:"language"`
 	Lines          jsonFileLineStats  `json:"lines"`
 	Classification jsonClassification `json:"classification"`
 	History        jsonFileHistory    `json:"history"`
+	// Patch holds the unified-diff hunks when patches were requested.
+	Patch *jsonFilePatch `json:"patch,omitempty"`
+	URL   string         `json:"url,omitempty"`
+}
+
+type jsonFilePatch struct {
+	Hunks        []jsonPatchHunk `json:"hunks"`
+	Truncated    bool            `json:"truncated"`
+	OmittedBytes int             `json:"omitted_bytes"`
+}
+
+type jsonPatchHunk struct {
+	Header string `json:"header"`
+	Lines  string `json:"lines"`
 }
 
 type jsonFilePath struct {
@@ -118,10 +184,17 @@ type jsonFileLineStats struct {
 type jsonClassification struct {
 	IsNew       bool `json:"is_new"`
 	IsRename    bool `json:"is_rename"`
+	IsCopy      bool `json:"is_copy,omitempty"`
 	IsBinary    bool `json:"is_binary"`
 	IsGenerated bool `json:"is_generated"`
-	IsTest      bool `json:"is_test"`
-	IsConfig    bool `json:"is_config"`
+	// GeneratedBy names the signal behind is_generated.
+	GeneratedBy     string `json:"generated_by,omitempty" enum:"gitattributes,path,generated_header,generated_marker,minified"`
+	IsTest          bool   `json:"is_test"`
+	IsConfig        bool   `json:"is_config"`
+	IsVendored      bool   `json:"is_vendored,omitempty"`
+	IsDocumentation bool   `json:"is_documentation,omitempty"`
+	// Tags lists the custom labels of matching classification rules.
+	Tags []string `json:"tags,omitempty"`
 }
 
 type jsonFileHistory struct {
@@ -129,13 +202,17 @@ type jsonFileHistory struct {
 }
 
 type jsonHistoryView struct {
-	Options     jsonHistoryOptions `json:"options"`
-	CommitRange jsonCommitRange    `json:"commit_range"`
-	Commits     []jsonCommit       `json:"commits"`
+	Options      jsonHistoryOptions `json:"options"`
+	CommitRange  jsonCommitRange    `json:"commit_range"`
+	Commits      []jsonCommit       `json:"commits"`
+	PullRequests []jsonPullRequest  `json:"pull_requests,omitempty"`
 }
 
 type jsonHistoryOptions struct {
-	MergeCommitsIncluded bool `json:"merge_commits_included"`
+	MergeCommitsIncluded bool   `json:"merge_commits_included"`
+	FirstParent          bool   `json:"first_parent,omitempty"`
+	Order                string `json:"order,omitempty" enum:"topo,author-date,committer-date"`
+	NestedMerges         bool   `json:"nested_merges,omitempty"`
 }
 
 type jsonCommitRange struct {
@@ -149,6 +226,74 @@ type jsonCommit struct {
 	Date    time.Time `json:"date"`
 	Message string    `json:"message"`
 	DiffURL string    `json:"diff_url"`
+	// Type and Scope are empty unless the subject follows Conventional Commits.
+	Type     string        `json:"type,omitempty"`
+	Scope    string        `json:"scope,omitempty"`
+	Subject  string        `json:"subject,omitempty"`
+	Body     string        `json:"body,omitempty"`
+	Breaking bool          `json:"breaking,omitempty"`
+	Trailers []jsonTrailer `json:"trailers,omitempty"`
+	// Merged is only set on merges when history_view.options.nested_merges
+	// is on: the commits the merge brought in.
+	Merged []jsonCommit `json:"merged,omitempty"`
+}
+
+type jsonPullRequest struct {
+	Number       int       `json:"number"`
+	Title        string    `json:"title"`
+	SourceBranch string    `json:"source_branch"`
+	Style        string    `json:"style" enum:"merge,squash"`
+	MergeCommit  string    `json:"merge_commit"`
+	CommitURL    string    `json:"commit_url"`
+	URL          string    `json:"url,omitempty"`
+	Author       string    `json:"author"`
+	Date         time.Time `json:"date"`
+	Commits      []string  `json:"commits"`
+	// Details is omitted unless forge enrichment is on and the forge knows
+	// the request.
+	Details *jsonForgePullRequest `json:"details,omitempty"`
+}
+
+type jsonForgePullRequest struct {
+	URL       string     `json:"url"`
+	Title     string     `json:"title"`
+	State     string     `json:"state" enum:"open,closed,merged"`
+	Author    string     `json:"author"`
+	Labels    []string   `json:"labels"`
+	Reviewers []string   `json:"reviewers"`
+	MergedAt  *time.Time `json:"merged_at"`
+}
+
+type jsonTrailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type jsonIssue struct {
+	Key      string             `json:"key"`
+	Commits  []string           `json:"commits"`
+	Branches []string           `json:"branches"`
+	Files    []string           `json:"files"`
+	Details  *jsonTrackedIssue  `json:"details"`
+	Warnings []jsonIssueWarning `json:"warnings"`
+}
+
+type jsonTrackedIssue struct {
+	Key         string     `json:"key"`
+	URL         string     `json:"url"`
+	Summary     string     `json:"summary"`
+	Status      string     `json:"status"`
+	Closed      bool       `json:"closed"`
+	Type        string     `json:"type"`
+	Assignee    string     `json:"assignee"`
+	FixVersions []string   `json:"fix_versions"`
+	Labels      []string   `json:"labels"`
+	ResolvedAt  *time.Time `json:"resolved_at"`
+}
+
+type jsonIssueWarning struct {
+	Commit string `json:"commit"`
+	Reason string `json:"reason" enum:"not_found,closed_before_merge"`
 }
 
 type jsonDiffLinks struct {
@@ -192,36 +337,106 @@ func mapToDTO(r *domain.DiffReport) jsonDiffReport {
 				After:  f.Path.After,
 			},
 			ChangeType: f.ChangeType,
+			Similarity: f.Similarity,
 			Language:   f.Language,
 			Lines: jsonFileLineStats{
 				Added:   f.Lines.Added,
 				Deleted: f.Lines.Deleted,
 			},
 			Classification: jsonClassification{
-				IsNew:       f.Classification.IsNew,
-				IsRename:    f.Classification.IsRename,
-				IsBinary:    f.Classification.IsBinary,
-				IsGenerated: f.Classification.IsGenerated,
-				IsTest:      f.Classification.IsTest,
-				IsConfig:    f.Classification.IsConfig,
+				IsNew:           f.Classification.IsNew,
+				IsRename:        f.Classification.IsRename,
+				IsCopy:          f.Classification.IsCopy,
+				IsBinary:        f.Classification.IsBinary,
+				IsGenerated:     f.Classification.IsGenerated,
+				GeneratedBy:     f.Classification.GeneratedBy,
+				IsTest:          f.Classification.IsTest,
+				IsConfig:        f.Classification.IsConfig,
+				IsVendored:      f.Classification.IsVendored,
+				IsDocumentation: f.Classification.IsDocumentation,
+				Tags:            f.Classification.Tags,
 			},
 			History: jsonFileHistory{
 				RelatedCommits: f.History.RelatedCommits,
 			},
+			Patch: toJSONPatch(f.Patch),
+			URL:   f.URL,
 		}
 	}
 
-	commits := make([]jsonCommit, len(r.HistoryView.Commits))
-	for i, c := range r.HistoryView.Commits {
-		commits[i] = jsonCommit{
-			Hash:    c.Hash,
-			Author:  c.Author,
-			Date:    c.Date,
-			Message: c.Message,
-			DiffURL: c.DiffURL,
+	commits := toJSONCommits(r.HistoryView.Commits)
+
+	pullRequests := make([]jsonPullRequest, len(r.HistoryView.PullRequests))
+	for i, pr := range r.HistoryView.PullRequests {
+		pullRequests[i] = jsonPullRequest{
+			Number:       pr.Number,
+			Title:        pr.Title,
+			SourceBranch: pr.SourceBranch,
+			Style:        pr.Style,
+			MergeCommit:  pr.MergeCommit,
+			CommitURL:    pr.CommitURL,
+			URL:          pr.URL,
+			Author:       pr.Author,
+			Date:         pr.Date,
+			Commits:      pr.Commits,
+		}
+		if d := pr.Details; d != nil {
+			pullRequests[i].Details = &jsonForgePullRequest{
+				URL:       d.URL,
+				Title:     d.Title,
+				State:     d.State,
+				Author:    d.Author,
+				Labels:    d.Labels,
+				Reviewers: d.Reviewers,
+				MergedAt:  d.MergedAt,
+			}
 		}
 	}
 
+	issues := make([]jsonIssue, len(r.Issues))
+	for i, issue := range r.Issues {
+		warnings := make([]jsonIssueWarning, len(issue.Warnings))
+		for j, w := range issue.Warnings {
+			warnings[j] = jsonIssueWarning{Commit: w.Commit, Reason: w.Reason}
+		}
+
+		var details *jsonTrackedIssue
+		if d := issue.Details; d != nil {
+			details = &jsonTrackedIssue{
+				Key:         d.Key,
+				URL:         d.URL,
+				Summary:     d.Summary,
+				Status:      d.Status,
+				Closed:      d.Closed,
+				Type:        d.Type,
+				Assignee:    d.Assignee,
+				FixVersions: d.FixVersions,
+				Labels:      d.Labels,
+				ResolvedAt:  d.ResolvedAt,
+			}
+		}
+
+		issues[i] = jsonIssue{
+			Key:      issue.Key,
+			Commits:  issue.Commits,
+			Branches: issue.Branches,
+			Files:    issue.Files,
+			Details:  details,
+			Warnings: warnings,
+		}
+	}
+
+	var excluded []jsonExcludedFile
+	for _, e := range r.Filters.Excluded {
+		excluded = append(excluded, jsonExcludedFile{
+			Path:       jsonFilePath{Before: e.Path.Before, After: e.Path.After},
+			ChangeType: e.ChangeType,
+			Lines:      jsonFileLineStats{Added: e.Lines.Added, Deleted: e.Lines.Deleted},
+			Rule:       e.Match.Rule,
+			Pattern:    e.Match.Pattern,
+		})
+	}
+
 	return jsonDiffReport{
 		SchemaVersion: r.SchemaVersion,
 		Repository: jsonRepository{
@@ -233,12 +448,29 @@ func mapToDTO(r *domain.DiffReport) jsonDiffReport {
 			FromRef: r.Request.FromRef,
 			ToRef:   r.Request.ToRef,
 			Options: jsonRequestOptions{
-				IgnoreMergeCommits: r.Request.Options.IgnoreMergeCommits,
-				DetectRenames:      r.Request.Options.DetectRenames,
+				IgnoreMergeCommits:  r.Request.Options.IgnoreMergeCommits,
+				FirstParent:         r.Request.Options.FirstParent,
+				HistoryOrder:        r.Request.Options.HistoryOrder,
+				NestMerges:          r.Request.Options.NestMerges,
+				DetectRenames:       r.Request.Options.DetectRenames,
+				DetectCopies:        r.Request.Options.DetectCopies,
+				SimilarityThreshold: r.Request.Options.SimilarityThreshold,
+				BaselineStrategy:    r.Request.Options.BaselineStrategy,
+				IssueProjects:       r.Request.Options.IssueProjects,
+				SkipIgnoreFile:      r.Request.Options.SkipIgnoreFile,
+				ExplainFilters:      r.Request.Options.ExplainFilters,
+				IncludePatches:      r.Request.Options.Patches.Include,
+				PatchContextLines:   r.Request.Options.Patches.ContextLines,
+				PatchMaxFileBytes:   r.Request.Options.Patches.MaxFileBytes,
+				PatchMaxTotalBytes:  r.Request.Options.Patches.MaxTotalBytes,
 			},
 			Filters: jsonRequestFilters{
 				ExcludeSuffixes: r.Request.Filters.ExcludeSuffixes,
 				ExcludePaths:    r.Request.Filters.ExcludePaths,
+				IncludeGlobs:    r.Request.Filters.IncludeGlobs,
+				ExcludeGlobs:    r.Request.Filters.ExcludeGlobs,
+				ExcludeRegexes:  r.Request.Filters.ExcludeRegexes,
+				IgnorePatterns:  r.Request.Filters.IgnorePatterns,
 			},
 		},
 		Resolution: jsonResolution{
@@ -246,11 +478,13 @@ func mapToDTO(r *domain.DiffReport) jsonDiffReport {
 				Ref:    r.Resolution.From.Ref,
 				Type:   r.Resolution.From.Type,
 				Commit: r.Resolution.From.Commit,
+				URL:    r.Resolution.From.URL,
 			},
 			To: jsonResolutionRef{
 				Ref:    r.Resolution.To.Ref,
 				Type:   r.Resolution.To.Type,
 				Commit: r.Resolution.To.Commit,
+				URL:    r.Resolution.To.URL,
 			},
 		},
 		Baseline: jsonBaseline{
@@ -264,8 +498,10 @@ func mapToDTO(r *domain.DiffReport) jsonDiffReport {
 		Filters: jsonFilters{
 			SuffixExcluded:      r.Filters.SuffixExcluded,
 			PathExcluded:        r.Filters.PathExcluded,
+			IgnoreFilePatterns:  r.Filters.IgnoreFilePatterns,
 			BinaryFilesDetected: r.Filters.BinaryFilesDetected,
 			FilesFilteredOut:    r.Filters.FilesFilteredOut,
+			Excluded:            excluded,
 		},
 		TreeDiff: jsonTreeDiff{
 			Summary: jsonDiffSummary{
@@ -274,25 +510,34 @@ func mapToDTO(r *domain.DiffReport) jsonDiffReport {
 					Modified: r.TreeDiff.Summary.Files.Modified,
 					Deleted:  r.TreeDiff.Summary.Files.Deleted,
 					Renamed:  r.TreeDiff.Summary.Files.Renamed,
+					Copied:   r.TreeDiff.Summary.Files.Copied,
 				},
 				Lines: jsonSummaryLineStats{
 					Added:   r.TreeDiff.Summary.Lines.Added,
 					Deleted: r.TreeDiff.Summary.Lines.Deleted,
 					Net:     r.TreeDiff.Summary.Lines.Net,
 				},
+				ByClassification: toJSONBreakdown(r.TreeDiff.Summary.ByClassification),
+				ByLanguage:       toJSONBreakdown(r.TreeDiff.Summary.ByLanguage),
+				Patches:          toJSONPatchSummary(r.TreeDiff.Summary.Patches),
 			},
 			Files: files,
 		},
 		HistoryView: jsonHistoryView{
 			Options: jsonHistoryOptions{
 				MergeCommitsIncluded: r.HistoryView.Options.MergeCommitsIncluded,
+				FirstParent:          r.HistoryView.Options.FirstParent,
+				Order:                r.HistoryView.Options.Order,
+				NestedMerges:         r.HistoryView.Options.NestedMerges,
 			},
 			CommitRange: jsonCommitRange{
 				From: r.HistoryView.CommitRange.From,
 				To:   r.HistoryView.CommitRange.To,
 			},
-			Commits: commits,
+			Commits:      commits,
+			PullRequests: pullRequests,
 		},
+		Issues: issues,
 		DiffLinks: jsonDiffLinks{
 			VersionDiff: jsonVersionDiffLink{
 				Base:   r.DiffLinks.VersionDiff.Base,
@@ -315,3 +560,62 @@ func mapToDTO(r *domain.DiffReport) jsonDiffReport {
 		},
 	}
 }
+
+func toJSONBreakdown(stats []domain.BreakdownStats) []jsonBreakdown {
+	out := make([]jsonBreakdown, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, jsonBreakdown{
+			Name:         s.Name,
+			Files:        s.Files,
+			LinesAdded:   s.Added,
+			LinesDeleted: s.Deleted,
+			FilesPercent: s.FilesPercent,
+			ChurnPercent: s.ChurnPercent,
+		})
+	}
+	return out
+}
+
+func toJSONPatch(p *domain.FilePatch) *jsonFilePatch {
+	if p == nil {
+		return nil
+	}
+	hunks := make([]jsonPatchHunk, len(p.Hunks))
+	for i, h := range p.Hunks {
+		hunks[i] = jsonPatchHunk{Header: h.Header, Lines: h.Lines}
+	}
+	return &jsonFilePatch{Hunks: hunks, Truncated: p.Truncated, OmittedBytes: p.OmittedBytes}
+}
+
+func toJSONPatchSummary(s *domain.PatchSummary) *jsonPatchSummary {
+	if s == nil {
+		return nil
+	}
+	return &jsonPatchSummary{Bytes: s.Bytes, TruncatedFiles: s.TruncatedFiles}
+}
+
+func toJSONCommits(commits []domain.Commit) []jsonCommit {
+	out := make([]jsonCommit, len(commits))
+	for i, c := range commits {
+		out[i] = jsonCommit{
+			Hash:     c.Hash,
+			Author:   c.Author,
+			Date:     c.Date,
+			Message:  c.Message,
+			DiffURL:  c.DiffURL,
+			Type:     c.Parsed.Type,
+			Scope:    c.Parsed.Scope,
+			Subject:  c.Parsed.Subject,
+			Body:     c.Parsed.Body,
+			Breaking: c.Parsed.Breaking,
+			Trailers: make([]jsonTrailer, len(c.Parsed.Trailers)),
+		}
+		for j, t := range c.Parsed.Trailers {
+			out[i].Trailers[j] = jsonTrailer{Key: t.Key, Value: t.Value}
+		}
+		if len(c.Merged) > 0 {
+			out[i].Merged = toJSONCommits(c.Merged)
+		}
+	}
+	return out
+}
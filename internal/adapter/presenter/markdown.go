@@ -0,0 +1,184 @@
+package presenter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+func ToMarkdown(r *domain.DiffReport) ([]byte, error) {
+	v := newReportView(r)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s: %s → %s\n\n", mdEscape(v.Title), mdEscape(v.From.Ref), mdEscape(v.To.Ref))
+
+	if v.Repository.URL != "" {
+		fmt.Fprintf(&b, "- **Repository:** [%s](%s)\n", mdEscape(v.Repository.Name), v.Repository.URL)
+	} else {
+		fmt.Fprintf(&b, "- **Repository:** %s\n", mdEscape(v.Repository.Name))
+	}
+	fmt.Fprintf(&b, "- **From:** %s (%s, `%s`)\n", mdRef(v.From), v.From.Type, shortHash(v.From.Commit))
+	fmt.Fprintf(&b, "- **To:** %s (%s, `%s`)\n", mdRef(v.To), v.To.Type, shortHash(v.To.Commit))
+	fmt.Fprintf(&b, "- **Baseline:** %s at `%s` (%s)\n", v.Baseline.Strategy, shortHash(v.Baseline.BaseCommit), v.Baseline.Ancestry.Relationship)
+	if v.CompareURL != "" {
+		fmt.Fprintf(&b, "- **Compare:** [%s...%s](%s)\n", shortHash(v.Baseline.BaseCommit), shortHash(v.To.Commit), v.CompareURL)
+	}
+
+	b.WriteString("\n## Summary\n\n")
+	b.WriteString("| Files added | Files modified | Files deleted | Files renamed | Files copied | Lines added | Lines deleted | Net |\n")
+	b.WriteString("|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d | +%d | -%d | %+d |\n\n",
+		v.Summary.Files.Added, v.Summary.Files.Modified, v.Summary.Files.Deleted, v.Summary.Files.Renamed, v.Summary.Files.Copied,
+		v.Summary.Lines.Added, v.Summary.Lines.Deleted, v.Summary.Lines.Net)
+	fmt.Fprintf(&b, "%d binary files detected, %d files filtered out.\n", v.Filters.BinaryFilesDetected, v.Filters.FilesFilteredOut)
+
+	if len(v.Classes) > 0 {
+		b.WriteString("\n## Classification\n\n")
+		b.WriteString("| Class | Files | Added | Deleted | Churn |\n")
+		b.WriteString("|---|---:|---:|---:|---:|\n")
+		for _, c := range v.Classes {
+			fmt.Fprintf(&b, "| %s | %d | +%d | -%d | %.1f%% |\n", c.Name, c.Files, c.Added, c.Deleted, c.ChurnPercent)
+		}
+	}
+
+	if len(v.Languages) > 0 {
+		b.WriteString("\n## Languages\n\n")
+		b.WriteString("| Language | Files | Added | Deleted |\n")
+		b.WriteString("|---|---:|---:|---:|\n")
+		for _, l := range v.Languages {
+			fmt.Fprintf(&b, "| %s | %d | +%d | -%d |\n", mdEscape(l.Name), l.Files, l.Added, l.Deleted)
+		}
+	}
+
+	if len(v.Files) > 0 {
+		b.WriteString("\n## Files\n\n")
+		b.WriteString("| Change | Path | Language | Added | Deleted |\n")
+		b.WriteString("|---|---|---|---:|---:|\n")
+		for _, f := range v.Files {
+			added, deleted := fmt.Sprintf("+%d", f.Added), fmt.Sprintf("-%d", f.Deleted)
+			if f.Binary {
+				added, deleted = "binary", "binary"
+			}
+			path := mdCode(mdEscape(f.Path))
+			if f.URL != "" {
+				path = "[" + path + "](" + f.URL + ")"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", f.ChangeType, path, mdEscape(f.Language), added, deleted)
+		}
+	}
+
+	if len(v.Patches) > 0 {
+		b.WriteString("\n## Patches\n")
+		for _, p := range v.Patches {
+			fence := mdFence(p.Diff)
+			fmt.Fprintf(&b, "\n### %s\n\n%sdiff\n%s%s\n", mdCode(mdEscape(p.Path)), fence, p.Diff, fence)
+		}
+	}
+
+	if len(v.Excluded) > 0 {
+		b.WriteString("\n## Excluded by filters\n\n")
+		b.WriteString("| Change | Path | Added | Deleted | Rule |\n")
+		b.WriteString("|---|---|---:|---:|---|\n")
+		for _, e := range v.Excluded {
+			fmt.Fprintf(&b, "| %s | %s | +%d | -%d | %s %s |\n", e.ChangeType, mdCode(mdEscape(e.Path)), e.Added, e.Deleted, e.Rule, mdCode(mdEscape(e.Pattern)))
+		}
+	}
+
+	b.WriteString("\n## Commits\n\n")
+	if len(v.Commits) == 0 {
+		fmt.Fprintf(&b, "_%s_\n", mdEscape(v.Note))
+	}
+	mdCommits(&b, v.Commits, "")
+
+	if len(v.PRs) > 0 {
+		b.WriteString("\n## Pull Requests\n\n")
+		for _, pr := range v.PRs {
+			hash := "`" + shortHash(pr.MergeCommit) + "`"
+			if pr.CommitURL != "" {
+				hash = "[" + hash + "](" + pr.CommitURL + ")"
+			}
+			number := fmt.Sprintf("**#%d**", pr.Number)
+			if url := prURL(pr); url != "" {
+				number = "[" + number + "](" + url + ")"
+			}
+			fmt.Fprintf(&b, "- %s %s — %s, %s", number, mdEscape(pr.Title), mdEscape(pr.Author), prDetail(pr, hash))
+			if review := prReview(pr); review != "" {
+				b.WriteString("; " + mdEscape(review))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(v.Issues) > 0 {
+		b.WriteString("\n## Issues\n\n")
+		for _, issue := range v.Issues {
+			key := "**" + issue.Key + "**"
+			if d := issue.Details; d != nil {
+				key = fmt.Sprintf("[**%s**](%s) %s (%s, %s)", issue.Key, d.URL, mdEscape(d.Summary), d.Type, d.Status)
+			}
+			fmt.Fprintf(&b, "- %s — %d commits, %d files\n", key, len(issue.Commits), len(issue.Files))
+			for _, w := range issue.Warnings {
+				fmt.Fprintf(&b, "  - ⚠ `%s`: %s\n", shortHash(w.Commit), strings.ReplaceAll(w.Reason, "_", " "))
+			}
+		}
+	}
+
+	return []byte(b.String()), nil
+}
+
+func mdEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// mdFence returns a code fence longer than any backtick run in s, so a
+// patch touching Markdown cannot close its own block.
+func mdFence(s string) string {
+	return strings.Repeat("`", max(3, longestBacktickRun(s)+1))
+}
+
+// mdCode renders s as a code span. As CommonMark requires, it is delimited
+// by a backtick run longer than any in s, and padded with a space where s
+// would otherwise merge with the delimiters or lose a space of its own.
+func mdCode(s string) string {
+	delimiter := strings.Repeat("`", longestBacktickRun(s)+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") ||
+		strings.HasPrefix(s, " ") && strings.HasSuffix(s, " ") && strings.Trim(s, " ") != "" {
+		s = " " + s + " "
+	}
+	return delimiter + s + delimiter
+}
+
+func longestBacktickRun(s string) int {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// mdCommits lists commits, nesting the commits of each merge one level
+// deeper under it.
+func mdCommits(b *strings.Builder, commits []commitView, indent string) {
+	for _, c := range commits {
+		hash := "`" + c.ShortHash + "`"
+		if c.URL != "" {
+			hash = "[" + hash + "](" + c.URL + ")"
+		}
+		fmt.Fprintf(b, "%s- %s %s — %s, %s\n", indent, hash, mdEscape(c.Subject), mdEscape(c.Author), c.Date)
+		mdCommits(b, c.Merged, indent+"  ")
+	}
+}
+
+// mdRef is ref in code style, linked when the forge has a page for it.
+func mdRef(ref domain.ResolutionRef) string {
+	if ref.URL == "" {
+		return mdCode(mdEscape(ref.Ref))
+	}
+	return "[" + mdCode(mdEscape(ref.Ref)) + "](" + ref.URL + ")"
+}
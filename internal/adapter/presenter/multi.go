@@ -0,0 +1,184 @@
+package presenter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+type jsonMultiDiffReport struct {
+	SchemaVersion string               `json:"schema_version"`
+	Name          string               `json:"name"`
+	Summary       jsonCrossRepoSummary `json:"summary"`
+	Repositories  []jsonRepoSection    `json:"repositories"`
+	Metadata      jsonMetadata         `json:"metadata"`
+}
+
+type jsonCrossRepoSummary struct {
+	Repositories int                  `json:"repositories"`
+	Succeeded    int                  `json:"succeeded"`
+	Failed       int                  `json:"failed"`
+	Files        jsonFileStats        `json:"files"`
+	Lines        jsonSummaryLineStats `json:"lines"`
+	Commits      int                  `json:"commits"`
+	Issues       []string             `json:"issues"`
+}
+
+type jsonRepoSection struct {
+	Name   string          `json:"name"`
+	Path   string          `json:"path"`
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Report *jsonDiffReport `json:"report"`
+	Error  string          `json:"error,omitempty"`
+}
+
+func ToMultiJSON(r *domain.MultiDiffReport) ([]byte, error) {
+	sections := make([]jsonRepoSection, len(r.Repositories))
+	for i, s := range r.Repositories {
+		sections[i] = jsonRepoSection{
+			Name:  s.Name,
+			Path:  s.Path,
+			From:  s.From,
+			To:    s.To,
+			Error: s.Error,
+		}
+		if s.Report != nil {
+			dto := mapToDTO(s.Report)
+			sections[i].Report = &dto
+		}
+	}
+
+	dto := jsonMultiDiffReport{
+		SchemaVersion: r.SchemaVersion,
+		Name:          r.Name,
+		Summary: jsonCrossRepoSummary{
+			Repositories: r.Summary.Repositories,
+			Succeeded:    r.Summary.Succeeded,
+			Failed:       r.Summary.Failed,
+			Files: jsonFileStats{
+				Added:    r.Summary.Files.Added,
+				Modified: r.Summary.Files.Modified,
+				Deleted:  r.Summary.Files.Deleted,
+				Renamed:  r.Summary.Files.Renamed,
+				Copied:   r.Summary.Files.Copied,
+			},
+			Lines: jsonSummaryLineStats{
+				Added:   r.Summary.Lines.Added,
+				Deleted: r.Summary.Lines.Deleted,
+				Net:     r.Summary.Lines.Net,
+			},
+			Commits: r.Summary.Commits,
+			Issues:  r.Summary.Issues,
+		},
+		Repositories: sections,
+		Metadata: jsonMetadata{
+			GeneratedAt: r.Metadata.GeneratedAt,
+			Generator: jsonGeneratorInfo{
+				Name:    r.Metadata.Generator.Name,
+				Version: r.Metadata.Generator.Version,
+			},
+		},
+	}
+	return json.MarshalIndent(dto, "", "  ")
+}
+
+func ToMultiMarkdown(r *domain.MultiDiffReport) ([]byte, error) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s: %d repositories\n\n", mdEscape(multiTitle(r)), r.Summary.Repositories)
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString("| Files added | Files modified | Files deleted | Files renamed | Files copied | Lines added | Lines deleted | Net | Commits |\n")
+	b.WriteString("|---:|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d | +%d | -%d | %+d | %d |\n\n",
+		r.Summary.Files.Added, r.Summary.Files.Modified, r.Summary.Files.Deleted, r.Summary.Files.Renamed, r.Summary.Files.Copied,
+		r.Summary.Lines.Added, r.Summary.Lines.Deleted, r.Summary.Lines.Net, r.Summary.Commits)
+	if len(r.Summary.Issues) > 0 {
+		fmt.Fprintf(&b, "Issues: %s\n\n", strings.Join(r.Summary.Issues, ", "))
+	}
+
+	b.WriteString("| Repository | Range | Files | Lines | Commits | Status |\n")
+	b.WriteString("|---|---|---:|---:|---:|---|\n")
+	for _, s := range r.Repositories {
+		if s.Report == nil {
+			fmt.Fprintf(&b, "| %s | %s → %s | | | | failed: %s |\n", mdEscape(s.Name), mdCode(mdEscape(s.From)), mdCode(mdEscape(s.To)), mdEscape(s.Error))
+			continue
+		}
+		sum := s.Report.TreeDiff.Summary
+		fmt.Fprintf(&b, "| %s | %s → %s | %d | +%d -%d | %d | ok |\n", mdEscape(s.Name), mdCode(mdEscape(s.From)), mdCode(mdEscape(s.To)),
+			len(s.Report.TreeDiff.Files), sum.Lines.Added, sum.Lines.Deleted, len(s.Report.HistoryView.Commits))
+	}
+
+	for _, s := range r.Repositories {
+		if s.Report == nil {
+			continue
+		}
+		section, err := ToMarkdown(s.Report)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString("\n")
+		// Demote every heading so each repository nests under the release.
+		for line := range strings.Lines(string(section)) {
+			if strings.HasPrefix(line, "#") {
+				b.WriteString("#")
+			}
+			b.WriteString(line)
+		}
+	}
+
+	return []byte(b.String()), nil
+}
+
+func ToMultiText(r *domain.MultiDiffReport) ([]byte, error) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: %d repositories (%d ok, %d failed)\n", multiTitle(r), r.Summary.Repositories, r.Summary.Succeeded, r.Summary.Failed)
+	fmt.Fprintf(&b, "  files    %d added, %d modified, %d deleted, %d renamed, %d copied\n",
+		r.Summary.Files.Added, r.Summary.Files.Modified, r.Summary.Files.Deleted, r.Summary.Files.Renamed, r.Summary.Files.Copied)
+	fmt.Fprintf(&b, "  lines    +%d -%d (net %+d)\n", r.Summary.Lines.Added, r.Summary.Lines.Deleted, r.Summary.Lines.Net)
+	fmt.Fprintf(&b, "  commits  %d\n", r.Summary.Commits)
+	if len(r.Summary.Issues) > 0 {
+		fmt.Fprintf(&b, "  issues   %s\n", strings.Join(r.Summary.Issues, ", "))
+	}
+
+	b.WriteString("\nRepositories\n")
+	tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	for _, s := range r.Repositories {
+		if s.Report == nil {
+			fmt.Fprintf(tw, "  %s\t%s -> %s\tfailed: %s\n", s.Name, s.From, s.To, s.Error)
+			continue
+		}
+		sum := s.Report.TreeDiff.Summary
+		fmt.Fprintf(tw, "  %s\t%s -> %s\t%d files\t+%d -%d\t%d commits\n", s.Name, s.From, s.To,
+			len(s.Report.TreeDiff.Files), sum.Lines.Added, sum.Lines.Deleted, len(s.Report.HistoryView.Commits))
+	}
+	if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+
+	for _, s := range r.Repositories {
+		if s.Report == nil {
+			continue
+		}
+		section, err := ToText(s.Report)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "\n%s\n", strings.Repeat("=", 72))
+		b.Write(section)
+	}
+
+	return []byte(b.String()), nil
+}
+
+func multiTitle(r *domain.MultiDiffReport) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return "Release"
+}
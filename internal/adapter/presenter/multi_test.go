@@ -0,0 +1,100 @@
+package presenter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleMultiReport() *domain.MultiDiffReport {
+	return &domain.MultiDiffReport{
+		SchemaVersion: "1.0",
+		Name:          "Release 24.05",
+		Summary: domain.CrossRepoSummary{
+			Repositories: 2,
+			Succeeded:    1,
+			Failed:       1,
+			Files:        domain.FileStats{Added: 1, Modified: 1, Renamed: 1},
+			Lines:        domain.SummaryLineStats{Added: 12, Deleted: 2, Net: 10},
+			Commits:      1,
+			Issues:       []string{"ABC-7"},
+		},
+		Repositories: []domain.RepoSection{
+			{Name: "supervisor", Path: "/src/supervisor", From: "v1.0.0", To: "v1.1.0", Report: sampleReport()},
+			{Name: "web", Path: "/src/web", From: "v2.0.0", To: "v2.1.0", Error: "reference not found"},
+		},
+	}
+}
+
+func TestMultiRegistry_BuiltinFormats(t *testing.T) {
+	assert.Equal(t, []string{"json", "markdown", "text"}, MultiFormats())
+
+	for _, format := range MultiFormats() {
+		p, err := GetMulti(format)
+		require.NoError(t, err)
+
+		out, err := p.RenderMulti(sampleMultiReport())
+		require.NoError(t, err, format)
+		assert.NotEmpty(t, out, format)
+	}
+
+	_, err := GetMulti("html")
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+}
+
+func TestToMultiJSON_EmbedsPerRepoReports(t *testing.T) {
+	out, err := ToMultiJSON(sampleMultiReport())
+	require.NoError(t, err)
+
+	var decoded struct {
+		Summary struct {
+			Failed int      `json:"failed"`
+			Issues []string `json:"issues"`
+		} `json:"summary"`
+		Repositories []struct {
+			Name   string          `json:"name"`
+			Report json.RawMessage `json:"report"`
+			Error  string          `json:"error"`
+		} `json:"repositories"`
+	}
+	require.NoError(t, json.Unmarshal(out, &decoded))
+
+	assert.Equal(t, 1, decoded.Summary.Failed)
+	assert.Equal(t, []string{"ABC-7"}, decoded.Summary.Issues)
+	require.Len(t, decoded.Repositories, 2)
+
+	violations, err := ValidateJSON(decoded.Repositories[0].Report)
+	require.NoError(t, err)
+	assert.Empty(t, violations, "embedded reports should satisfy schema v1.0")
+
+	assert.Equal(t, "null", string(decoded.Repositories[1].Report))
+	assert.Equal(t, "reference not found", decoded.Repositories[1].Error)
+}
+
+func TestToMultiMarkdown_NestsRepoSections(t *testing.T) {
+	out, err := ToMultiMarkdown(sampleMultiReport())
+	require.NoError(t, err)
+	md := string(out)
+
+	assert.Contains(t, md, "# Release 24.05: 2 repositories\n")
+	assert.Contains(t, md, "| 1 | 1 | 0 | 1 | 0 | +12 | -2 | +10 | 1 |")
+	assert.Contains(t, md, "| supervisor | `v1.0.0` → `v1.1.0` | 3 | +12 -2 | 1 | ok |")
+	assert.Contains(t, md, "| web | `v2.0.0` → `v2.1.0` | | | | failed: reference not found |")
+	assert.Contains(t, md, "\n## supervisor: v1.0.0 → v1.1.0\n")
+	assert.Contains(t, md, "\n### Summary\n")
+}
+
+func TestToMultiText(t *testing.T) {
+	out, err := ToMultiText(sampleMultiReport())
+	require.NoError(t, err)
+	text := string(out)
+
+	assert.Contains(t, text, "Release 24.05: 2 repositories (1 ok, 1 failed)")
+	assert.Contains(t, text, "issues   ABC-7")
+	assert.Contains(t, text, "failed: reference not found")
+	assert.Contains(t, text, "supervisor: v1.0.0 -> v1.1.0")
+}
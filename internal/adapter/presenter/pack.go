@@ -0,0 +1,174 @@
+package presenter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+var packRegistry = map[string]func(p *domain.Pack) ([]byte, error){
+	"json":     ToPackJSON,
+	"markdown": ToPackMarkdown,
+}
+
+// RenderPack renders p in the given format.
+func RenderPack(format string, p *domain.Pack) ([]byte, error) {
+	render, ok := packRegistry[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("%w: format %q does not support packs (available: %s)", domain.ErrInvalidOption, format, strings.Join(PackFormats(), ", "))
+	}
+	return render(p)
+}
+
+// PackFormats lists the formats a pack can be rendered in, sorted.
+func PackFormats() []string {
+	formats := make([]string, 0, len(packRegistry))
+	for name := range packRegistry {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+type jsonPack struct {
+	Repository     string             `json:"repository"`
+	From           string             `json:"from"`
+	To             string             `json:"to"`
+	MaxTokens      int                `json:"max_tokens"`
+	Tokens         int                `json:"estimated_tokens"`
+	Summary        jsonDiffSummary    `json:"summary"`
+	Commits        []jsonPackCommit   `json:"commits"`
+	OmittedCommits int                `json:"omitted_commits"`
+	Files          []jsonPackedFile   `json:"files"`
+	Manifest       []jsonManifestFile `json:"manifest"`
+}
+
+type jsonPackCommit struct {
+	Hash    string `json:"hash"`
+	Author  string `json:"author"`
+	Subject string `json:"subject"`
+}
+
+type jsonPackedFile struct {
+	Path       jsonFilePath      `json:"path"`
+	ChangeType string            `json:"change_type"`
+	Lines      jsonFileLineStats `json:"lines"`
+	Patch      *jsonFilePatch    `json:"patch"`
+}
+
+type jsonManifestFile struct {
+	Path        jsonFilePath `json:"path"`
+	Disposition string       `json:"disposition"`
+	Reason      string       `json:"reason,omitempty"`
+	Tokens      int          `json:"tokens"`
+}
+
+// ToPackJSON renders the bundle with a manifest entry for every file of
+// the report, in priority order.
+func ToPackJSON(p *domain.Pack) ([]byte, error) {
+	summary := mapToDTO(p.Report).TreeDiff.Summary
+	summary.Patches = nil
+
+	dto := jsonPack{
+		Repository:     p.Report.Repository.Name,
+		From:           p.Report.Resolution.From.Ref,
+		To:             p.Report.Resolution.To.Ref,
+		MaxTokens:      p.MaxTokens,
+		Tokens:         p.Tokens,
+		Summary:        summary,
+		Commits:        make([]jsonPackCommit, len(p.Commits)),
+		OmittedCommits: p.OmittedCommits,
+		Files:          []jsonPackedFile{},
+		Manifest:       make([]jsonManifestFile, len(p.Files)),
+	}
+	for i, c := range p.Commits {
+		dto.Commits[i] = jsonPackCommit{Hash: c.Hash, Author: c.Author, Subject: commitSubject(c.Message)}
+	}
+	for i, f := range p.Files {
+		path := jsonFilePath{Before: f.File.Path.Before, After: f.File.Path.After}
+		dto.Manifest[i] = jsonManifestFile{Path: path, Disposition: f.Disposition, Reason: f.Reason, Tokens: f.Tokens}
+		if f.Disposition == domain.PackDropped {
+			continue
+		}
+		dto.Files = append(dto.Files, jsonPackedFile{
+			Path:       path,
+			ChangeType: f.File.ChangeType,
+			Lines:      jsonFileLineStats{Added: f.File.Lines.Added, Deleted: f.File.Lines.Deleted},
+			Patch:      toJSONPatch(f.File.Patch),
+		})
+	}
+
+	return json.MarshalIndent(dto, "", "  ")
+}
+
+// ToPackMarkdown renders the bundle as a prompt-ready document: summary,
+// commits, the patches kept, the summarized files and a closing manifest
+// of what was truncated or dropped.
+func ToPackMarkdown(p *domain.Pack) ([]byte, error) {
+	r := p.Report
+	s := r.TreeDiff.Summary
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s: %s → %s\n\n", r.Repository.Name, r.Resolution.From.Ref, r.Resolution.To.Ref)
+	fmt.Fprintf(&b, "%d files added, %d modified, %d deleted, %d renamed, %d copied; +%d -%d lines.\n",
+		s.Files.Added, s.Files.Modified, s.Files.Deleted, s.Files.Renamed, s.Files.Copied, s.Lines.Added, s.Lines.Deleted)
+	fmt.Fprintf(&b, "Packed to about %d of %d tokens.\n", p.Tokens, p.MaxTokens)
+
+	if len(p.Commits) > 0 || p.OmittedCommits > 0 {
+		b.WriteString("\n## Commits\n\n")
+		for _, c := range p.Commits {
+			fmt.Fprintf(&b, "- `%s` %s — %s\n", shortHash(c.Hash), mdEscape(commitSubject(c.Message)), mdEscape(c.Author))
+		}
+		if p.OmittedCommits > 0 {
+			fmt.Fprintf(&b, "- _%d more commits omitted_\n", p.OmittedCommits)
+		}
+	}
+
+	var summarized, truncated []domain.PackedFile
+	dropped, changes := 0, false
+	for _, f := range p.Files {
+		switch f.Disposition {
+		case domain.PackSummarized:
+			summarized = append(summarized, f)
+			continue
+		case domain.PackDropped:
+			dropped++
+			continue
+		case domain.PackTruncated:
+			truncated = append(truncated, f)
+		}
+
+		if !changes {
+			b.WriteString("\n## Changes\n")
+			changes = true
+		}
+		diff := f.File.Patch.String()
+		fence := mdFence(diff)
+		fmt.Fprintf(&b, "\n### %s (%s, +%d -%d)\n\n%sdiff\n%s%s\n",
+			mdCode(mdEscape(displayPath(f.File))), f.File.ChangeType, f.File.Lines.Added, f.File.Lines.Deleted, fence, diff, fence)
+	}
+
+	if len(summarized) > 0 {
+		b.WriteString("\n## Other files\n\n")
+		b.WriteString("| Change | Path | Added | Deleted | Why no patch |\n")
+		b.WriteString("|---|---|---:|---:|---|\n")
+		for _, f := range summarized {
+			fmt.Fprintf(&b, "| %s | %s | +%d | -%d | %s |\n", f.File.ChangeType, mdCode(mdEscape(displayPath(f.File))), f.File.Lines.Added, f.File.Lines.Deleted, strings.ReplaceAll(f.Reason, "_", " "))
+		}
+	}
+
+	if len(truncated) > 0 || dropped > 0 {
+		b.WriteString("\n## Manifest\n\n")
+		for _, f := range truncated {
+			fmt.Fprintf(&b, "- %s: patch truncated, %d bytes omitted\n", mdCode(mdEscape(displayPath(f.File))), f.File.Patch.OmittedBytes)
+		}
+		if dropped > 0 {
+			fmt.Fprintf(&b, "- %d lower-priority files dropped to fit the token budget\n", dropped)
+		}
+	}
+
+	return []byte(b.String()), nil
+}
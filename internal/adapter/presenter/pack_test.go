@@ -0,0 +1,72 @@
+package presenter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func samplePack() *domain.Pack {
+	r := sampleReport()
+	files := r.TreeDiff.Files
+	cut := &domain.FilePatch{
+		Hunks:        []domain.PatchHunk{{Header: "@@ -0,0 +1,10 @@", Lines: "+package cmd\n" + domain.PatchTruncationLine(90)}},
+		Truncated:    true,
+		OmittedBytes: 90,
+	}
+	files[0].Patch = cut
+
+	return &domain.Pack{
+		Report:         r,
+		MaxTokens:      1000,
+		Tokens:         640,
+		Commits:        r.HistoryView.Commits,
+		OmittedCommits: 3,
+		Files: []domain.PackedFile{
+			{File: files[0], Disposition: domain.PackTruncated, Reason: domain.PackReasonBudget, Tokens: 40},
+			{File: domain.FileChange{Path: files[1].Path, ChangeType: files[1].ChangeType, Lines: files[1].Lines}, Disposition: domain.PackSummarized, Reason: domain.PackReasonNoPatch, Tokens: 16},
+			{File: files[2], Disposition: domain.PackDropped, Reason: domain.PackReasonBudget},
+		},
+	}
+}
+
+func TestToPackMarkdown(t *testing.T) {
+	out, err := RenderPack("markdown", samplePack())
+	require.NoError(t, err)
+	md := string(out)
+
+	assert.Contains(t, md, "Packed to about 640 of 1000 tokens.\n")
+	assert.Contains(t, md, "- `4444444` feat: add new command — Ada <script>\n- _3 more commits omitted_\n")
+	assert.Contains(t, md, "### `cmd/new.go` (added, +10 -0)\n\n```diff\n@@ -0,0 +1,10 @@\n+package cmd\n\\ truncated: 90 bytes omitted\n```\n")
+	assert.Contains(t, md, "| modified | `README.md` | +2 | -2 | no patch |\n")
+	assert.Contains(t, md, "## Manifest\n\n- `cmd/new.go`: patch truncated, 90 bytes omitted\n- 1 lower-priority files dropped to fit the token budget\n")
+	assert.NotContains(t, md, "c.txt")
+}
+
+func TestToPackJSON_ListsEveryFileInManifest(t *testing.T) {
+	out, err := RenderPack("JSON", samplePack())
+	require.NoError(t, err)
+
+	var decoded struct {
+		Files    []map[string]any `json:"files"`
+		Manifest []struct {
+			Disposition string `json:"disposition"`
+			Reason      string `json:"reason"`
+		} `json:"manifest"`
+	}
+	require.NoError(t, json.Unmarshal(out, &decoded))
+
+	assert.Len(t, decoded.Files, 2, "dropped files are only in the manifest")
+	require.Len(t, decoded.Manifest, 3)
+	assert.Equal(t, domain.PackDropped, decoded.Manifest[2].Disposition)
+	assert.Equal(t, domain.PackReasonNoPatch, decoded.Manifest[1].Reason)
+}
+
+func TestRenderPack_UnknownFormat(t *testing.T) {
+	_, err := RenderPack("html", samplePack())
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+}
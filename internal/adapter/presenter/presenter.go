@@ -0,0 +1,99 @@
+package presenter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// Presenter renders a diff report in one output format.
+type Presenter interface {
+	Render(r *domain.DiffReport) ([]byte, error)
+}
+
+// PresenterFunc adapts a plain function to the Presenter interface.
+type PresenterFunc func(r *domain.DiffReport) ([]byte, error)
+
+func (f PresenterFunc) Render(r *domain.DiffReport) ([]byte, error) {
+	return f(r)
+}
+
+var registry = map[string]Presenter{}
+
+// Register makes a presenter available under the given format name,
+// replacing any presenter previously registered under it.
+func Register(format string, p Presenter) {
+	registry[strings.ToLower(format)] = p
+}
+
+// Get returns the presenter registered for format.
+func Get(format string) (Presenter, error) {
+	p, ok := registry[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown format %q (available: %s)", domain.ErrInvalidOption, format, strings.Join(Formats(), ", "))
+	}
+	return p, nil
+}
+
+// Formats lists the registered format names in sorted order.
+func Formats() []string {
+	formats := make([]string, 0, len(registry))
+	for name := range registry {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// MultiPresenter renders a combined multi-repository report.
+type MultiPresenter interface {
+	RenderMulti(r *domain.MultiDiffReport) ([]byte, error)
+}
+
+// MultiPresenterFunc adapts a plain function to the MultiPresenter interface.
+type MultiPresenterFunc func(r *domain.MultiDiffReport) ([]byte, error)
+
+func (f MultiPresenterFunc) RenderMulti(r *domain.MultiDiffReport) ([]byte, error) {
+	return f(r)
+}
+
+var multiRegistry = map[string]MultiPresenter{}
+
+// RegisterMulti makes a multi-repository presenter available under the
+// given format name, replacing any presenter previously registered under it.
+func RegisterMulti(format string, p MultiPresenter) {
+	multiRegistry[strings.ToLower(format)] = p
+}
+
+// GetMulti returns the multi-repository presenter registered for format.
+func GetMulti(format string) (MultiPresenter, error) {
+	p, ok := multiRegistry[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("%w: format %q does not support multi-repository reports (available: %s)", domain.ErrInvalidOption, format, strings.Join(MultiFormats(), ", "))
+	}
+	return p, nil
+}
+
+// MultiFormats lists the registered multi-repository format names in sorted order.
+func MultiFormats() []string {
+	formats := make([]string, 0, len(multiRegistry))
+	for name := range multiRegistry {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func init() {
+	Register("json", PresenterFunc(ToJSON))
+	Register("markdown", PresenterFunc(ToMarkdown))
+	Register("text", PresenterFunc(ToText))
+	Register("html", PresenterFunc(ToHTML))
+	Register("confluence", PresenterFunc(ToConfluenceStorage))
+
+	RegisterMulti("json", MultiPresenterFunc(ToMultiJSON))
+	RegisterMulti("markdown", MultiPresenterFunc(ToMultiMarkdown))
+	RegisterMulti("text", MultiPresenterFunc(ToMultiText))
+}
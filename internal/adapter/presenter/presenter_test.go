@@ -0,0 +1,332 @@
+package presenter
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleReport() *domain.DiffReport {
+	return &domain.DiffReport{
+		SchemaVersion: "1.0",
+		Repository:    domain.RepoInfo{Name: "supervisor", URL: "https://github.com/NERVEbing/supervisor", VCS: "git"},
+		Request: domain.Request{
+			FromRef: "v1.0.0",
+			ToRef:   "v1.1.0",
+			Options: domain.RequestOptions{BaselineStrategy: domain.BaselineMergeBase, HistoryOrder: domain.HistoryOrderAuthorDate},
+		},
+		Resolution: domain.Resolution{
+			From: domain.ResolutionRef{Ref: "v1.0.0", Type: "tag", Commit: "1111111111111111111111111111111111111111"},
+			To:   domain.ResolutionRef{Ref: "v1.1.0", Type: "tag", Commit: "2222222222222222222222222222222222222222"},
+		},
+		Baseline: domain.Baseline{
+			Strategy:   domain.BaselineMergeBase,
+			BaseCommit: "3333333333333333333333333333333333333333",
+			Ancestry:   domain.Ancestry{Relationship: "branched"},
+		},
+		Filters: domain.ReportFilters{
+			FilesFilteredOut: 1,
+			Excluded: []domain.ExcludedFile{{
+				Path:       domain.FilePath{After: "testdata/golden.json"},
+				ChangeType: "added",
+				Lines:      domain.FileLineStats{Added: 40},
+				Match:      domain.FilterMatch{Rule: domain.FilterRuleGlob, Pattern: "**/testdata/**"},
+			}},
+		},
+		TreeDiff: domain.TreeDiff{
+			Summary: domain.DiffSummary{
+				Files: domain.FileStats{Added: 1, Modified: 1, Renamed: 1},
+				Lines: domain.SummaryLineStats{Added: 12, Deleted: 2, Net: 10},
+				ByClassification: []domain.BreakdownStats{
+					{Name: domain.ClassSource, Files: 2, Added: 10, FilesPercent: 66.7, ChurnPercent: 71.4},
+					{Name: domain.ClassTest},
+					{Name: domain.ClassGenerated},
+					{Name: domain.ClassConfig, Files: 1, Added: 2, Deleted: 2, FilesPercent: 33.3, ChurnPercent: 28.6},
+					{Name: domain.ClassBinary},
+				},
+				ByLanguage: []domain.BreakdownStats{
+					{Name: "Go", Files: 1, Added: 10, FilesPercent: 33.3, ChurnPercent: 71.4},
+					{Name: "Markdown", Files: 1, Added: 2, Deleted: 2, FilesPercent: 33.3, ChurnPercent: 28.6},
+					{Name: "Other", Files: 1, FilesPercent: 33.3},
+				},
+			},
+			Files: []domain.FileChange{
+				{Path: domain.FilePath{After: "cmd/new.go"}, ChangeType: "added", Language: "Go", Lines: domain.FileLineStats{Added: 10}},
+				{Path: domain.FilePath{Before: "README.md", After: "README.md"}, ChangeType: "modified", Language: "Markdown", Lines: domain.FileLineStats{Added: 2, Deleted: 2},
+					Patch: &domain.FilePatch{Hunks: []domain.PatchHunk{{Header: "@@ -1,2 +1,2 @@", Lines: "-```sh\n+```bash\n"}}}},
+				{Path: domain.FilePath{Before: "a|b.txt", After: "c.txt"}, ChangeType: "renamed", Similarity: 100},
+			},
+		},
+		HistoryView: domain.HistoryView{
+			Options: domain.HistoryOptions{Order: domain.HistoryOrderAuthorDate},
+			Commits: []domain.Commit{{
+				Hash:    "4444444444444444444444444444444444444444",
+				Author:  "Ada <script>",
+				Date:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+				Message: "feat: add new command\n\nLonger body.",
+				DiffURL: "https://github.com/NERVEbing/supervisor/commit/4444444444444444444444444444444444444444",
+			}},
+		},
+		DiffLinks: domain.DiffLinks{VersionDiff: domain.VersionDiffLink{URL: "https://github.com/NERVEbing/supervisor/compare/3333333...2222222"}},
+	}
+}
+
+func TestRegistry_BuiltinFormats(t *testing.T) {
+	assert.Equal(t, []string{"confluence", "html", "json", "markdown", "text"}, Formats())
+
+	for _, format := range Formats() {
+		p, err := Get(format)
+		require.NoError(t, err)
+
+		out, err := p.Render(sampleReport())
+		require.NoError(t, err, format)
+		assert.NotEmpty(t, out, format)
+	}
+
+	_, err := Get("yaml")
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+}
+
+func TestRegistry_CustomFormat(t *testing.T) {
+	t.Cleanup(func() { delete(registry, "count") })
+
+	Register("count", PresenterFunc(func(r *domain.DiffReport) ([]byte, error) {
+		return []byte(strings.Repeat("x", len(r.TreeDiff.Files))), nil
+	}))
+
+	p, err := Get("COUNT")
+	require.NoError(t, err)
+	out, err := p.Render(sampleReport())
+	require.NoError(t, err)
+	assert.Equal(t, "xxx", string(out))
+}
+
+func TestToJSON_RoundTripsFields(t *testing.T) {
+	out, err := ToJSON(sampleReport())
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	assert.Equal(t, "1.0", decoded["schema_version"])
+}
+
+func TestToMarkdown(t *testing.T) {
+	out, err := ToMarkdown(sampleReport())
+	require.NoError(t, err)
+	md := string(out)
+
+	assert.Contains(t, md, "# supervisor: v1.0.0 → v1.1.0")
+	assert.Contains(t, md, "| 1 | 1 | 0 | 1 | 0 | +12 | -2 | +10 |")
+	assert.Contains(t, md, "| Go | 1 | +10 | -0 |")
+	assert.Contains(t, md, "| source | 2 | +10 | -0 | 71.4% |\n| config | 1 | +2 | -2 | 28.6% |\n")
+	assert.Contains(t, md, "`a\\|b.txt → c.txt`")
+	assert.Contains(t, md, "[`4444444`](https://github.com/NERVEbing/supervisor/commit/4444444444444444444444444444444444444444) feat: add new command")
+	assert.Contains(t, md, "[3333333...2222222](https://github.com/NERVEbing/supervisor/compare/3333333...2222222)")
+	assert.Contains(t, md, "| added | `testdata/golden.json` | +40 | -0 | glob `**/testdata/**` |")
+	assert.Contains(t, md, "### `README.md`\n\n````diff\n@@ -1,2 +1,2 @@\n-```sh\n+```bash\n````\n")
+}
+
+func TestToText(t *testing.T) {
+	out, err := ToText(sampleReport())
+	require.NoError(t, err)
+	text := string(out)
+
+	assert.Contains(t, text, "supervisor: v1.0.0 -> v1.1.0")
+	assert.Contains(t, text, "files  1 added, 1 modified, 0 deleted, 1 renamed, 0 copied")
+	assert.Contains(t, text, "Classification\n  source  2 files  +10  -0  71.4% of churn\n")
+	assert.Contains(t, text, "4444444 feat: add new command (Ada <script>, 2024-05-01)")
+	assert.Contains(t, text, "Patches\n\n--- README.md\n@@ -1,2 +1,2 @@\n-```sh\n")
+	assert.Contains(t, text, "Excluded by filters\n  added  testdata/golden.json  +40 -0  glob **/testdata/**\n")
+}
+
+func TestToHTML_IsSelfContainedAndEscaped(t *testing.T) {
+	out, err := ToHTML(sampleReport())
+	require.NoError(t, err)
+	html := string(out)
+
+	assert.True(t, strings.HasPrefix(html, "<!DOCTYPE html>"))
+	assert.Contains(t, html, "<style>")
+	assert.NotContains(t, html, "<link")
+	assert.NotContains(t, html, "<script>")
+	assert.Contains(t, html, "Ada &lt;script&gt;")
+	assert.Contains(t, html, `<a href="https://github.com/NERVEbing/supervisor/commit/4444444444444444444444444444444444444444"><code>4444444</code></a>`)
+}
+
+func TestToConfluenceStorage_IsWellFormedXHTML(t *testing.T) {
+	out, err := ToConfluenceStorage(sampleReport())
+	require.NoError(t, err)
+	storage := string(out)
+
+	decoder := xml.NewDecoder(strings.NewReader("<root>" + storage + "</root>"))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	assert.NotContains(t, storage, "<br>")
+	assert.Contains(t, storage, "Ada &lt;script&gt;")
+	assert.Contains(t, storage, `<ac:parameter ac:name="title">renamed</ac:parameter>`)
+	assert.Contains(t, storage, "<code>a|b.txt → c.txt</code>")
+}
+
+func TestRender_NestsMergedCommits(t *testing.T) {
+	r := sampleReport()
+	r.HistoryView.Options.NestedMerges = true
+	r.HistoryView.Commits = append(r.HistoryView.Commits, domain.Commit{
+		Hash:    "5555555555555555555555555555555555555555",
+		Author:  "Grace",
+		Date:    time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC),
+		Message: "Merge branch 'fix'",
+		Merged: []domain.Commit{{
+			Hash:    "6666666666666666666666666666666666666666",
+			Author:  "Linus",
+			Date:    time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC),
+			Message: "fix: handle empty refs",
+		}},
+	})
+
+	md, err := ToMarkdown(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(md), "- `5555555` Merge branch 'fix' — Grace, 2024-05-03\n  - `6666666` fix: handle empty refs — Linus, 2024-05-02\n")
+
+	text, err := ToText(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(text), "  5555555 Merge branch 'fix' (Grace, 2024-05-03)\n    6666666 fix: handle empty refs (Linus, 2024-05-02)\n")
+
+	html, err := ToHTML(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "<code>5555555</code> Merge branch &#39;fix&#39; <span class=\"muted\">— Grace, 2024-05-03</span><ul>\n<li><code>6666666</code>")
+
+	out, err := ToJSON(r)
+	require.NoError(t, err)
+	violations, err := ValidateJSON(out)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+	var decoded struct {
+		HistoryView struct {
+			Options struct {
+				NestedMerges bool `json:"nested_merges"`
+			} `json:"options"`
+			Commits []struct {
+				Merged []struct {
+					Hash string `json:"hash"`
+				} `json:"merged"`
+			} `json:"commits"`
+		} `json:"history_view"`
+	}
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	assert.True(t, decoded.HistoryView.Options.NestedMerges)
+	require.Len(t, decoded.HistoryView.Commits, 2)
+	assert.Nil(t, decoded.HistoryView.Commits[0].Merged)
+	assert.Equal(t, "6666666666666666666666666666666666666666", decoded.HistoryView.Commits[1].Merged[0].Hash)
+}
+
+func TestRender_PullRequests(t *testing.T) {
+	r := sampleReport()
+	r.HistoryView.PullRequests = []domain.PullRequest{
+		{Number: 12, Title: "Add pagination", SourceBranch: "ada/pages", Style: domain.PullRequestMerge, MergeCommit: "5555555555555555555555555555555555555555",
+			CommitURL: "https://example.com/c/5555555", Author: "Ada", Commits: []string{"4444444444444444444444444444444444444444"},
+			Details: &domain.ForgePullRequest{Number: 12, URL: "https://example.com/pull/12", State: domain.PullRequestMerged,
+				Labels: []string{"api"}, Reviewers: []string{"grace", "linus"}}},
+		{Number: 15, Title: "fix: empty refs", Style: domain.PullRequestSquash, MergeCommit: "6666666666666666666666666666666666666666", Author: "Grace",
+			Commits: []string{"6666666666666666666666666666666666666666"}},
+	}
+
+	md, err := ToMarkdown(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(md), "## Pull Requests\n\n"+
+		"- [**#12**](https://example.com/pull/12) Add pagination — Ada, 1 commits from ada/pages, merged in [`5555555`](https://example.com/c/5555555); reviewed by grace, linus; labels api\n"+
+		"- **#15** fix: empty refs — Grace, squashed in `6666666`\n")
+
+	text, err := ToText(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(text), "Pull requests\n  #12 Add pagination (Ada, 1 commits from ada/pages, merged in 5555555; reviewed by grace, linus; labels api)\n")
+
+	html, err := ToHTML(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `<li><strong>#15</strong> fix: empty refs <span class="muted">— Grace, squashed in <code>6666666</code></span></li>`)
+	assert.Contains(t, string(html), `<a href="https://example.com/pull/12"><strong>#12</strong></a>`)
+
+	out, err := ToJSON(r)
+	require.NoError(t, err)
+	violations, err := ValidateJSON(out)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestRender_ForgeLinks(t *testing.T) {
+	r := sampleReport()
+	r.Resolution.From.URL = "https://example.com/tags/v1.0.0"
+	r.TreeDiff.Files[0].URL = "https://example.com/blob/2222222/cmd/new.go#L1"
+	r.HistoryView.PullRequests = []domain.PullRequest{
+		{Number: 15, Title: "fix: empty refs", Style: domain.PullRequestSquash, MergeCommit: "6666666666666666666666666666666666666666",
+			URL: "https://example.com/pull/15", Author: "Grace", Commits: []string{"6666666666666666666666666666666666666666"}},
+	}
+
+	md, err := ToMarkdown(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(md), "- **From:** [`v1.0.0`](https://example.com/tags/v1.0.0) (tag, `1111111`)\n")
+	assert.Contains(t, string(md), "- **To:** `v1.1.0` (tag, `2222222`)\n")
+	assert.Contains(t, string(md), "| added | [`cmd/new.go`](https://example.com/blob/2222222/cmd/new.go#L1) | Go |")
+	assert.Contains(t, string(md), "| modified | `README.md` | Markdown |")
+	assert.Contains(t, string(md), "- [**#15**](https://example.com/pull/15) fix: empty refs")
+
+	html, err := ToHTML(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `<dt>From</dt><dd><a href="https://example.com/tags/v1.0.0"><code>v1.0.0</code></a>`)
+	assert.Contains(t, string(html), `<td><a href="https://example.com/blob/2222222/cmd/new.go#L1"><code>cmd/new.go</code></a></td>`)
+	assert.Contains(t, string(html), `<a href="https://example.com/pull/15"><strong>#15</strong></a>`)
+
+	out, err := ToJSON(r)
+	require.NoError(t, err)
+	violations, err := ValidateJSON(out)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestRender_LanguagesFromSummary(t *testing.T) {
+	r := sampleReport()
+	r.TreeDiff.Summary.ByLanguage = r.TreeDiff.Summary.ByLanguage[1:2]
+
+	md, err := ToMarkdown(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(md), "| Markdown | 1 | +2 | -2 |")
+	assert.NotContains(t, string(md), "| Go | 1 |")
+}
+
+func TestToMarkdown_BackticksInCodeSpans(t *testing.T) {
+	r := sampleReport()
+	r.TreeDiff.Files[0].Path.After = "docs/`x`.md"
+	r.TreeDiff.Files[1].Path = domain.FilePath{Before: "a``b.md", After: "a``b.md"}
+
+	md, err := ToMarkdown(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(md), "| added | ``docs/`x`.md`` |")
+	assert.Contains(t, string(md), "### ```a``b.md```\n")
+}
+
+func TestMdCode(t *testing.T) {
+	tests := map[string]string{
+		"main.go":   "`main.go`",
+		"a`b":       "``a`b``",
+		"a``b`":     "``` a``b` ```",
+		" padded ":  "`  padded  `",
+		"  ":        "`  `",
+		"`leading":  "`` `leading ``",
+		"trailing ": "`trailing `",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, mdCode(in), in)
+	}
+}
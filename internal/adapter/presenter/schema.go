@@ -0,0 +1,120 @@
+package presenter
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+const (
+	jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+	reportSchemaID    = "https://github.com/NERVEbing/supervisor/schema/report-v" + domain.ReportSchemaVersion + ".json"
+)
+
+// JSONSchema returns the JSON Schema (draft 2020-12) describing the output of
+// ToJSON. It is derived from the JSON DTOs, so it cannot drift from them.
+func JSONSchema() ([]byte, error) {
+	return json.MarshalIndent(reportSchema(), "", "  ")
+}
+
+func reportSchema() map[string]any {
+	g := &schemaGenerator{defs: map[string]any{}}
+	root := g.schemaFor(reflect.TypeOf(jsonDiffReport{}))
+
+	return map[string]any{
+		"$schema":     jsonSchemaDialect,
+		"$id":         reportSchemaID,
+		"title":       "supervisor diff report",
+		"description": "Structured diff between two git references, schema v" + domain.ReportSchemaVersion + ".",
+		"$ref":        root["$ref"],
+		"$defs":       g.defs,
+	}
+}
+
+type schemaGenerator struct {
+	defs map[string]any
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return map[string]any{"anyOf": []any{g.schemaFor(t.Elem()), map[string]any{"type": "null"}}}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		// encoding/json writes nil slices as null.
+		return map[string]any{"type": []any{"array", "null"}, "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []any{"object", "null"}, "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g *schemaGenerator) structRef(t reflect.Type) map[string]any {
+	name := strings.TrimPrefix(t.Name(), "json")
+	ref := map[string]any{"$ref": "#/$defs/" + name}
+	if _, ok := g.defs[name]; ok {
+		return ref
+	}
+	// Reserve the name first so recursive types terminate.
+	g.defs[name] = map[string]any{}
+
+	properties := map[string]any{}
+	required := []any{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+
+		prop := g.schemaFor(field.Type)
+		if enum := field.Tag.Get("enum"); enum != "" {
+			values := []any{}
+			for _, v := range strings.Split(enum, ",") {
+				values = append(values, v)
+			}
+			prop = map[string]any{"type": "string", "enum": values}
+		}
+		properties[key] = prop
+
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, key)
+		}
+	}
+
+	g.defs[name] = map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	return ref
+}
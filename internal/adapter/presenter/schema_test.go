@@ -0,0 +1,117 @@
+package presenter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema_Draft202012(t *testing.T) {
+	out, err := JSONSchema()
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(out, &schema))
+
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+	assert.Equal(t, "#/$defs/DiffReport", schema["$ref"])
+
+	defs := schema["$defs"].(map[string]any)
+	for _, name := range []string{"DiffReport", "FileChange", "Commit", "HistoryView", "Baseline"} {
+		assert.Contains(t, defs, name)
+	}
+
+	fileChange := defs["FileChange"].(map[string]any)
+	assert.Equal(t, false, fileChange["additionalProperties"])
+	assert.Contains(t, fileChange["required"], "change_type")
+}
+
+func TestValidateJSON_AcceptsPresenterOutput(t *testing.T) {
+	out, err := ToJSON(sampleReport())
+	require.NoError(t, err)
+
+	violations, err := ValidateJSON(out)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+// TestValidateJSON_AcceptsFirstReleaseReports strips every field added to
+// the schema after v1.0 first shipped: reports saved by that release must
+// keep validating.
+func TestValidateJSON_AcceptsFirstReleaseReports(t *testing.T) {
+	out, err := ToJSON(sampleReport())
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(out, &doc))
+	object := func(v any) map[string]any { return v.(map[string]any) }
+	drop := func(m map[string]any, keys ...string) {
+		for _, k := range keys {
+			delete(m, k)
+		}
+	}
+
+	drop(doc, "issues")
+	drop(object(doc["filters"]), "ignore_file_patterns", "excluded")
+	request := object(doc["request"])
+	drop(object(request["options"]), "detect_copies", "similarity_threshold", "baseline_strategy", "first_parent", "history_order", "nest_merges", "issue_projects", "skip_ignore_file",
+		"explain_filters", "include_patches", "patch_context_lines", "patch_max_file_bytes", "patch_max_total_bytes")
+	drop(object(request["filters"]), "include_globs", "exclude_globs", "exclude_regexes", "ignore_patterns")
+	treeDiff := object(doc["tree_diff"])
+	summary := object(treeDiff["summary"])
+	drop(summary, "by_classification", "by_language", "patches")
+	drop(object(summary["files"]), "copied")
+	for _, f := range treeDiff["files"].([]any) {
+		drop(object(f), "similarity", "url", "patch")
+		drop(object(object(f)["classification"]), "is_copy", "is_vendored", "is_documentation", "generated_by", "tags")
+	}
+	history := object(doc["history_view"])
+	drop(history, "pull_requests")
+	drop(object(history["options"]), "first_parent", "order", "nested_merges")
+	for _, c := range history["commits"].([]any) {
+		drop(object(c), "type", "scope", "subject", "body", "breaking", "trailers", "merged")
+	}
+
+	stripped, err := json.Marshal(doc)
+	require.NoError(t, err)
+	violations, err := ValidateJSON(stripped)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestValidateJSON_ReportsDrift(t *testing.T) {
+	out, err := ToJSON(sampleReport())
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(out, &doc))
+	delete(doc, "metadata")
+	doc["repository"].(map[string]any)["stars"] = 5
+	doc["tree_diff"].(map[string]any)["summary"].(map[string]any)["lines"].(map[string]any)["net"] = "ten"
+	doc["tree_diff"].(map[string]any)["files"].([]any)[0].(map[string]any)["change_type"] = "exploded"
+
+	mutated, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	violations, err := ValidateJSON(mutated)
+	require.NoError(t, err)
+
+	var messages []string
+	for _, v := range violations {
+		messages = append(messages, v.Error())
+	}
+	joined := strings.Join(messages, "\n")
+
+	assert.Contains(t, joined, `$: missing required property "metadata"`)
+	assert.Contains(t, joined, "$.repository.stars: unexpected property")
+	assert.Contains(t, joined, "$.tree_diff.summary.lines.net: expected integer, got string")
+	assert.Contains(t, joined, "$.tree_diff.files[0].change_type: value exploded is not one of")
+}
+
+func TestValidateJSON_RejectsNonJSON(t *testing.T) {
+	_, err := ValidateJSON([]byte("not json"))
+	assert.Error(t, err)
+}
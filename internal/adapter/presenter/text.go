@@ -0,0 +1,123 @@
+package presenter
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+func ToText(r *domain.DiffReport) ([]byte, error) {
+	v := newReportView(r)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: %s -> %s\n", v.Title, v.From.Ref, v.To.Ref)
+	fmt.Fprintf(&b, "  from      %s %s (%s)\n", shortHash(v.From.Commit), v.From.Ref, v.From.Type)
+	fmt.Fprintf(&b, "  to        %s %s (%s)\n", shortHash(v.To.Commit), v.To.Ref, v.To.Type)
+	fmt.Fprintf(&b, "  baseline  %s %s (%s)\n", shortHash(v.Baseline.BaseCommit), v.Baseline.Strategy, v.Baseline.Ancestry.Relationship)
+	if v.CompareURL != "" {
+		fmt.Fprintf(&b, "  compare   %s\n", v.CompareURL)
+	}
+
+	b.WriteString("\nSummary\n")
+	fmt.Fprintf(&b, "  files  %d added, %d modified, %d deleted, %d renamed, %d copied\n",
+		v.Summary.Files.Added, v.Summary.Files.Modified, v.Summary.Files.Deleted, v.Summary.Files.Renamed, v.Summary.Files.Copied)
+	fmt.Fprintf(&b, "  lines  +%d -%d (net %+d)\n", v.Summary.Lines.Added, v.Summary.Lines.Deleted, v.Summary.Lines.Net)
+	fmt.Fprintf(&b, "  %d binary files detected, %d files filtered out\n", v.Filters.BinaryFilesDetected, v.Filters.FilesFilteredOut)
+
+	if len(v.Classes) > 0 {
+		b.WriteString("\nClassification\n")
+		tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+		for _, c := range v.Classes {
+			fmt.Fprintf(tw, "  %s\t%d files\t+%d\t-%d\t%.1f%% of churn\n", c.Name, c.Files, c.Added, c.Deleted, c.ChurnPercent)
+		}
+		if err := tw.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(v.Languages) > 0 {
+		b.WriteString("\nLanguages\n")
+		tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+		for _, l := range v.Languages {
+			fmt.Fprintf(tw, "  %s\t%d files\t+%d\t-%d\n", l.Name, l.Files, l.Added, l.Deleted)
+		}
+		if err := tw.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(v.Files) > 0 {
+		b.WriteString("\nFiles\n")
+		tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+		for _, f := range v.Files {
+			lines := fmt.Sprintf("+%d -%d", f.Added, f.Deleted)
+			if f.Binary {
+				lines = "binary"
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", f.ChangeType, f.Path, lines)
+		}
+		if err := tw.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(v.Patches) > 0 {
+		b.WriteString("\nPatches\n")
+		for _, p := range v.Patches {
+			fmt.Fprintf(&b, "\n--- %s\n%s", p.Path, p.Diff)
+		}
+	}
+
+	if len(v.Excluded) > 0 {
+		b.WriteString("\nExcluded by filters\n")
+		tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+		for _, e := range v.Excluded {
+			fmt.Fprintf(tw, "  %s\t%s\t+%d -%d\t%s %s\n", e.ChangeType, e.Path, e.Added, e.Deleted, e.Rule, e.Pattern)
+		}
+		if err := tw.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	b.WriteString("\nCommits\n")
+	if len(v.Commits) == 0 {
+		fmt.Fprintf(&b, "  %s\n", v.Note)
+	}
+	textCommits(&b, v.Commits, "  ")
+
+	if len(v.PRs) > 0 {
+		b.WriteString("\nPull requests\n")
+		for _, pr := range v.PRs {
+			detail := prDetail(pr, shortHash(pr.MergeCommit))
+			if review := prReview(pr); review != "" {
+				detail += "; " + review
+			}
+			fmt.Fprintf(&b, "  #%d %s (%s, %s)\n", pr.Number, pr.Title, pr.Author, detail)
+		}
+	}
+
+	if len(v.Issues) > 0 {
+		b.WriteString("\nIssues\n")
+		for _, issue := range v.Issues {
+			fmt.Fprintf(&b, "  %s (%d commits, %d files)", issue.Key, len(issue.Commits), len(issue.Files))
+			if d := issue.Details; d != nil {
+				fmt.Fprintf(&b, " %s [%s, %s]", d.Summary, d.Type, d.Status)
+			}
+			b.WriteString("\n")
+			for _, w := range issue.Warnings {
+				fmt.Fprintf(&b, "    warning: %s %s\n", shortHash(w.Commit), strings.ReplaceAll(w.Reason, "_", " "))
+			}
+		}
+	}
+
+	return []byte(b.String()), nil
+}
+
+func textCommits(b *strings.Builder, commits []commitView, indent string) {
+	for _, c := range commits {
+		fmt.Fprintf(b, "%s%s %s (%s, %s)\n", indent, c.ShortHash, c.Subject, c.Author, c.Date)
+		textCommits(b, c.Merged, indent+"  ")
+	}
+}
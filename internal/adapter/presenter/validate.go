@@ -0,0 +1,208 @@
+package presenter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationError describes one place where a document violates the schema.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidateJSON checks a saved report against JSONSchema. The returned error
+// is non-nil only when data is not JSON at all; schema violations are
+// reported as ValidationErrors sorted by path.
+func ValidateJSON(data []byte) ([]ValidationError, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	schema := reportSchema()
+	v := &schemaValidator{defs: schema["$defs"].(map[string]any)}
+	v.validate(schema, doc, "$")
+
+	sort.SliceStable(v.errs, func(i, j int) bool { return v.errs[i].Path < v.errs[j].Path })
+	return v.errs, nil
+}
+
+// schemaValidator implements the subset of JSON Schema that reportSchema
+// emits: $ref, anyOf, type, enum, format date-time, properties, required,
+// additionalProperties and items.
+type schemaValidator struct {
+	defs map[string]any
+	errs []ValidationError
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) validate(schema map[string]any, value any, path string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		if !ok {
+			v.fail(path, "unresolvable $ref %s", ref)
+			return
+		}
+		v.validate(def, value, path)
+		return
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, alt := range anyOf {
+			probe := &schemaValidator{defs: v.defs}
+			probe.validate(alt.(map[string]any), value, path)
+			if len(probe.errs) == 0 {
+				return
+			}
+		}
+		v.fail(path, "does not match any allowed schema")
+		return
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		v.fail(path, "expected %s, got %s", describeType(t), jsonTypeOf(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if e == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "value %v is not one of %v", value, enum)
+		}
+	}
+
+	if schema["format"] == "date-time" {
+		if s, ok := value.(string); ok {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				v.fail(path, "invalid date-time %q", s)
+			}
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]any:
+		v.validateObject(schema, val, path)
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				v.validate(items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(schema map[string]any, obj map[string]any, path string) {
+	properties, _ := schema["properties"].(map[string]any)
+
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			if _, ok := obj[r.(string)]; !ok {
+				v.fail(path, "missing required property %q", r)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		child := path + "." + k
+		if prop, ok := properties[k].(map[string]any); ok {
+			v.validate(prop, obj[k], child)
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				v.fail(child, "unexpected property")
+			}
+		case map[string]any:
+			v.validate(extra, obj[k], child)
+		}
+	}
+}
+
+func matchesType(t any, value any) bool {
+	switch t := t.(type) {
+	case string:
+		return jsonTypeMatches(t, value)
+	case []any:
+		for _, alt := range t {
+			if jsonTypeMatches(alt.(string), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonTypeMatches(t string, value any) bool {
+	switch t {
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	default:
+		return jsonTypeOf(value) == t
+	}
+}
+
+func jsonTypeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func describeType(t any) string {
+	if alts, ok := t.([]any); ok {
+		names := make([]string, len(alts))
+		for i, a := range alts {
+			names[i] = a.(string)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
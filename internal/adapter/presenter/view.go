@@ -0,0 +1,205 @@
+package presenter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// reportView is the format-neutral digest of a DiffReport shared by the
+// human-readable presenters.
+type reportView struct {
+	Title      string
+	Repository domain.RepoInfo
+	From       domain.ResolutionRef
+	To         domain.ResolutionRef
+	Baseline   domain.Baseline
+	CompareURL string
+	Summary    domain.DiffSummary
+	Filters    domain.ReportFilters
+	Classes    []domain.BreakdownStats
+	Languages  []domain.BreakdownStats
+	Files      []fileView
+	Patches    []patchView
+	Excluded   []excludedView
+	Commits    []commitView
+	PRs        []domain.PullRequest
+	Issues     []domain.IssueReference
+	Note       string
+	Generated  time.Time
+}
+
+type fileView struct {
+	ChangeType string
+	Path       string
+	Language   string
+	Added      int
+	Deleted    int
+	Binary     bool
+	URL        string
+}
+
+// patchView is the rendered unified diff of one file.
+type patchView struct {
+	Path string
+	Diff string
+}
+
+type excludedView struct {
+	ChangeType string
+	Path       string
+	Added      int
+	Deleted    int
+	Rule       string
+	Pattern    string
+}
+
+type commitView struct {
+	Hash      string
+	ShortHash string
+	Subject   string
+	Author    string
+	Date      string
+	URL       string
+	// Merged lists the commits a merge brought in when history nests them.
+	Merged []commitView
+}
+
+func newReportView(r *domain.DiffReport) reportView {
+	v := reportView{
+		Title:      r.Repository.Name,
+		Repository: r.Repository,
+		From:       r.Resolution.From,
+		To:         r.Resolution.To,
+		Baseline:   r.Baseline,
+		CompareURL: r.DiffLinks.VersionDiff.URL,
+		Summary:    r.TreeDiff.Summary,
+		Filters:    r.Filters,
+		Languages:  r.TreeDiff.Summary.ByLanguage,
+		Issues:     r.Issues,
+		Note:       r.Integrity.HistoryNote,
+		Generated:  r.Metadata.GeneratedAt,
+	}
+
+	for _, c := range r.TreeDiff.Summary.ByClassification {
+		if c.Files > 0 {
+			v.Classes = append(v.Classes, c)
+		}
+	}
+
+	for _, f := range r.TreeDiff.Files {
+		v.Files = append(v.Files, fileView{
+			ChangeType: f.ChangeType,
+			Path:       displayPath(f),
+			Language:   languageName(f.Language),
+			Added:      f.Lines.Added,
+			Deleted:    f.Lines.Deleted,
+			Binary:     f.Classification.IsBinary,
+			URL:        f.URL,
+		})
+		if f.Patch != nil && len(f.Patch.Hunks) > 0 {
+			v.Patches = append(v.Patches, patchView{Path: displayPath(f), Diff: f.Patch.String()})
+		}
+	}
+
+	for _, e := range r.Filters.Excluded {
+		v.Excluded = append(v.Excluded, excludedView{
+			ChangeType: e.ChangeType,
+			Path:       displayPath(domain.FileChange{Path: e.Path}),
+			Added:      e.Lines.Added,
+			Deleted:    e.Lines.Deleted,
+			Rule:       e.Match.Rule,
+			Pattern:    e.Match.Pattern,
+		})
+	}
+
+	v.Commits = newCommitViews(r.HistoryView.Commits)
+	v.PRs = r.HistoryView.PullRequests
+
+	return v
+}
+
+func newCommitViews(commits []domain.Commit) []commitView {
+	var views []commitView
+	for _, c := range commits {
+		views = append(views, commitView{
+			Hash:      c.Hash,
+			ShortHash: shortHash(c.Hash),
+			Subject:   commitSubject(c.Message),
+			Author:    c.Author,
+			Date:      c.Date.Format("2006-01-02"),
+			URL:       c.DiffURL,
+			Merged:    newCommitViews(c.Merged),
+		})
+	}
+	return views
+}
+
+// prDetail describes how pr landed, naming its landing commit as hash:
+// "squashed in abc1234" or "3 commits from feature/x, merged in abc1234".
+func prDetail(pr domain.PullRequest, hash string) string {
+	if pr.Style == domain.PullRequestSquash {
+		return "squashed in " + hash
+	}
+	detail := fmt.Sprintf("%d commits", len(pr.Commits))
+	if pr.SourceBranch != "" {
+		detail += " from " + pr.SourceBranch
+	}
+	return detail + ", merged in " + hash
+}
+
+// prURL links pr on the forge, preferring the URL the forge reported.
+func prURL(pr domain.PullRequest) string {
+	if pr.Details != nil && pr.Details.URL != "" {
+		return pr.Details.URL
+	}
+	return pr.URL
+}
+
+// prReview summarises the forge's review data for pr, or returns "" when
+// it was not fetched: "reviewed by grace, linus; labels api, feature".
+func prReview(pr domain.PullRequest) string {
+	if pr.Details == nil {
+		return ""
+	}
+	var parts []string
+	if len(pr.Details.Reviewers) > 0 {
+		parts = append(parts, "reviewed by "+strings.Join(pr.Details.Reviewers, ", "))
+	}
+	if len(pr.Details.Labels) > 0 {
+		parts = append(parts, "labels "+strings.Join(pr.Details.Labels, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func languageName(lang string) string {
+	if lang == "" {
+		return "Other"
+	}
+	return lang
+}
+
+func displayPath(f domain.FileChange) string {
+	switch {
+	case f.Path.After == "":
+		return f.Path.Before
+	case f.Path.Before != "" && f.Path.Before != f.Path.After:
+		return f.Path.Before + " → " + f.Path.After
+	default:
+		return f.Path.After
+	}
+}
+
+func commitSubject(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(subject)
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
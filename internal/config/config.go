@@ -1,30 +1,207 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
 )
 
-// Config holds global configuration from environment variables
+// Config holds global configuration from the config file and environment variables
 type Config struct {
 	RepoPath        string
 	ExcludeSuffixes []string
 	ExcludePaths    []string
+	IncludeGlobs    []string
+	ExcludeGlobs    []string
+	ExcludeRegexes  []string
+	IgnorePatterns  []string
+	ExcludeClasses  []string
+	OnlyClasses     []string
+	IssueProjects   []string
+	Classification  domain.ClassificationRules
+	URLTemplates    domain.URLTemplates
+	// ForgeHosts maps self-hosted hosts to their forge kind, and
+	// ForgeURLTemplates replaces a forge kind's built-in links.
+	ForgeHosts        map[string]string
+	ForgeURLTemplates map[string]domain.URLTemplates
+	Jira              JiraConfig
+	Confluence        ConfluenceConfig
+	Forge             ForgeConfig
+	Changelog         ChangelogConfig
+
+	// Source is the config file that was loaded, if any, and Profile the
+	// profile applied on top of it.
+	Source  string
+	Profile string
 }
 
-// LoadFromEnv loads configuration from environment variables
-func LoadFromEnv() *Config {
-	cfg := &Config{
-		RepoPath: os.Getenv("SUPERVISOR_REPO_PATH"),
+// JiraConfig holds connection settings for the JIRA REST API
+type JiraConfig struct {
+	URL        string
+	APIVersion string
+	AuthType   string
+	User       string
+	Token      string
+}
+
+// ConfluenceConfig holds connection and page settings for Confluence
+type ConfluenceConfig struct {
+	URL      string
+	AuthType string
+	User     string
+	Token    string
+	Space    string
+	ParentID string
+}
+
+// ForgeConfig holds settings for the GitHub, GitLab or Gitea API used to
+// enrich pull requests. Type, APIURL and Repository are derived from the
+// repository's remote when empty; CacheDir is relative to the config file.
+type ForgeConfig struct {
+	Type       string
+	APIURL     string
+	Repository string
+	Token      string
+	CacheDir   string
+}
+
+// ChangelogConfig holds the house style for `supervisor changelog`: a
+// text/template given inline or as a file relative to the config file.
+type ChangelogConfig struct {
+	Template     string
+	TemplateFile string
+}
+
+// FileNames are the config file names discovered by Load, in order of preference.
+var FileNames = []string{".supervisor.yaml", ".supervisor.yml", ".supervisor.toml"}
+
+// LoadOptions control how Load finds the config file.
+type LoadOptions struct {
+	// File is an explicit config file; discovery is skipped when it is set.
+	File string
+	// SearchDir is where discovery starts before walking up to the root.
+	SearchDir string
+	// Profile names a profile in the config file to apply.
+	Profile string
+}
+
+// Load builds the effective configuration: the config file (explicit or
+// discovered), then the selected profile, then environment variables.
+// Command-line flags are applied on top by the caller.
+func Load(opts LoadOptions) (*Config, error) {
+	cfg := &Config{}
+
+	path := opts.File
+	if path == "" {
+		found, err := Discover(opts.SearchDir)
+		if err != nil {
+			return nil, err
+		}
+		path = found
 	}
 
-	if suffixes := os.Getenv("SUPERVISOR_EXCLUDE_SUFFIXES"); suffixes != "" {
-		cfg.ExcludeSuffixes = strings.Split(suffixes, ",")
+	if path != "" {
+		file, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file.apply(cfg)
+		cfg.Source = path
+
+		if opts.Profile != "" {
+			profile, ok := file.Profiles[opts.Profile]
+			if !ok {
+				return nil, fmt.Errorf("%w: profile %q not found in %s", domain.ErrInvalidOption, opts.Profile, path)
+			}
+			profile.apply(cfg)
+			cfg.Profile = opts.Profile
+		}
+	} else if opts.Profile != "" {
+		return nil, fmt.Errorf("%w: profile %q requested but no config file found", domain.ErrInvalidOption, opts.Profile)
 	}
 
-	if paths := os.Getenv("SUPERVISOR_EXCLUDE_PATHS"); paths != "" {
-		cfg.ExcludePaths = strings.Split(paths, ",")
+	applyEnv(cfg)
+	return cfg, nil
+}
+
+// Discover looks for a config file in dir and each of its parents, returning
+// "" when there is none.
+func Discover(dir string) (string, error) {
+	if dir == "" {
+		dir = "."
 	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+
+	for {
+		for _, name := range FileNames {
+			candidate := filepath.Join(dir, name)
+			info, err := os.Stat(candidate)
+			if err == nil && !info.IsDir() {
+				return candidate, nil
+			}
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return "", fmt.Errorf("failed to check %s: %w", candidate, err)
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
 
+// LoadFromEnv loads configuration from environment variables
+func LoadFromEnv() *Config {
+	cfg := &Config{}
+	applyEnv(cfg)
 	return cfg
 }
+
+// applyEnv overrides cfg with every environment variable that is set.
+func applyEnv(cfg *Config) {
+	setString(&cfg.RepoPath, "SUPERVISOR_REPO_PATH")
+	setList(&cfg.ExcludeSuffixes, "SUPERVISOR_EXCLUDE_SUFFIXES")
+	setList(&cfg.ExcludePaths, "SUPERVISOR_EXCLUDE_PATHS")
+	setList(&cfg.IssueProjects, "SUPERVISOR_ISSUE_PROJECTS")
+
+	setString(&cfg.Jira.URL, "SUPERVISOR_JIRA_URL")
+	setString(&cfg.Jira.APIVersion, "SUPERVISOR_JIRA_API_VERSION")
+	setString(&cfg.Jira.AuthType, "SUPERVISOR_JIRA_AUTH")
+	setString(&cfg.Jira.User, "SUPERVISOR_JIRA_USER")
+	setString(&cfg.Jira.Token, "SUPERVISOR_JIRA_TOKEN")
+
+	setString(&cfg.Confluence.URL, "SUPERVISOR_CONFLUENCE_URL")
+	setString(&cfg.Confluence.AuthType, "SUPERVISOR_CONFLUENCE_AUTH")
+	setString(&cfg.Confluence.User, "SUPERVISOR_CONFLUENCE_USER")
+	setString(&cfg.Confluence.Token, "SUPERVISOR_CONFLUENCE_TOKEN")
+	setString(&cfg.Confluence.Space, "SUPERVISOR_CONFLUENCE_SPACE")
+	setString(&cfg.Confluence.ParentID, "SUPERVISOR_CONFLUENCE_PARENT")
+
+	setString(&cfg.Forge.Type, "SUPERVISOR_FORGE_TYPE")
+	setString(&cfg.Forge.APIURL, "SUPERVISOR_FORGE_API_URL")
+	setString(&cfg.Forge.Repository, "SUPERVISOR_FORGE_REPOSITORY")
+	setString(&cfg.Forge.Token, "SUPERVISOR_FORGE_TOKEN")
+	setString(&cfg.Forge.CacheDir, "SUPERVISOR_FORGE_CACHE_DIR")
+}
+
+func setString(dst *string, key string) {
+	if v := os.Getenv(key); v != "" {
+		*dst = v
+	}
+}
+
+func setList(dst *[]string, key string) {
+	if v := os.Getenv(key); v != "" {
+		*dst = strings.Split(v, ",")
+	}
+}
@@ -2,31 +2,331 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/NERVEbing/supervisor/internal/domain"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoadFromEnv_WithAllEnvVars(t *testing.T) {
 	t.Setenv("SUPERVISOR_REPO_PATH", "/test/repo")
 	t.Setenv("SUPERVISOR_EXCLUDE_SUFFIXES", ".png,.wasm,.gz")
 	t.Setenv("SUPERVISOR_EXCLUDE_PATHS", "vendor/,third_party/")
+	t.Setenv("SUPERVISOR_ISSUE_PROJECTS", "ABC,OPS")
+	t.Setenv("SUPERVISOR_JIRA_URL", "https://jira.example.com")
+	t.Setenv("SUPERVISOR_JIRA_USER", "bot")
+	t.Setenv("SUPERVISOR_JIRA_TOKEN", "secret")
+	t.Setenv("SUPERVISOR_CONFLUENCE_URL", "https://wiki.example.com")
+	t.Setenv("SUPERVISOR_CONFLUENCE_TOKEN", "pat")
+	t.Setenv("SUPERVISOR_CONFLUENCE_SPACE", "REL")
+	t.Setenv("SUPERVISOR_CONFLUENCE_PARENT", "4242")
 
 	cfg := LoadFromEnv()
 
 	assert.Equal(t, "/test/repo", cfg.RepoPath)
 	assert.Equal(t, []string{".png", ".wasm", ".gz"}, cfg.ExcludeSuffixes)
 	assert.Equal(t, []string{"vendor/", "third_party/"}, cfg.ExcludePaths)
+	assert.Equal(t, []string{"ABC", "OPS"}, cfg.IssueProjects)
+	assert.Equal(t, JiraConfig{URL: "https://jira.example.com", User: "bot", Token: "secret"}, cfg.Jira)
+	assert.Equal(t, ConfluenceConfig{URL: "https://wiki.example.com", Token: "pat", Space: "REL", ParentID: "4242"}, cfg.Confluence)
 }
 
 func TestLoadFromEnv_WithEmptyEnvVars(t *testing.T) {
 	_ = os.Unsetenv("SUPERVISOR_REPO_PATH")
 	_ = os.Unsetenv("SUPERVISOR_EXCLUDE_SUFFIXES")
 	_ = os.Unsetenv("SUPERVISOR_EXCLUDE_PATHS")
+	_ = os.Unsetenv("SUPERVISOR_ISSUE_PROJECTS")
 
 	cfg := LoadFromEnv()
 
 	assert.Equal(t, "", cfg.RepoPath)
 	assert.Empty(t, cfg.ExcludeSuffixes)
 	assert.Empty(t, cfg.ExcludePaths)
+	assert.Empty(t, cfg.IssueProjects)
+}
+
+// clearEnv blanks every variable applyEnv reads, so the developer's own
+// environment cannot leak into file-precedence tests.
+func clearEnv(t *testing.T) {
+	for _, key := range []string{
+		"SUPERVISOR_REPO_PATH", "SUPERVISOR_EXCLUDE_SUFFIXES", "SUPERVISOR_EXCLUDE_PATHS", "SUPERVISOR_ISSUE_PROJECTS",
+		"SUPERVISOR_JIRA_URL", "SUPERVISOR_JIRA_API_VERSION", "SUPERVISOR_JIRA_AUTH", "SUPERVISOR_JIRA_USER", "SUPERVISOR_JIRA_TOKEN",
+		"SUPERVISOR_CONFLUENCE_URL", "SUPERVISOR_CONFLUENCE_AUTH", "SUPERVISOR_CONFLUENCE_USER", "SUPERVISOR_CONFLUENCE_TOKEN",
+		"SUPERVISOR_CONFLUENCE_SPACE", "SUPERVISOR_CONFLUENCE_PARENT",
+		"SUPERVISOR_FORGE_TYPE", "SUPERVISOR_FORGE_API_URL", "SUPERVISOR_FORGE_REPOSITORY", "SUPERVISOR_FORGE_TOKEN",
+		"SUPERVISOR_FORGE_CACHE_DIR",
+	} {
+		t.Setenv(key, "")
+	}
+}
+
+const sampleConfig = `
+repo: ../checkout
+exclude_suffixes: [.png]
+exclude_paths: [vendor/]
+exclude_globs: ["**/testdata/**"]
+ignore_patterns: ["*.lock", "!Cargo.lock"]
+exclude_classes: [generated]
+issue_projects: [ABC]
+classification:
+  generated: ["*.gen.ts"]
+  rules:
+    - globs: ["db/migrations/**"]
+      tags: [is_migration]
+    - shebangs: [bun]
+      language: TypeScript
+url_templates:
+  commit: "{repo}/-/commit/{hash}"
+jira:
+  url: https://jira.example.com
+  token: file-token
+profiles:
+  release:
+    exclude_paths: []
+    issue_projects: [ABC, OPS]
+    confluence:
+      space: REL
+`
+
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, ".supervisor.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoad_DiscoversConfigUpward(t *testing.T) {
+	clearEnv(t)
+	root := t.TempDir()
+	path := writeConfig(t, root, sampleConfig)
+	nested := filepath.Join(root, "services", "api")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+
+	cfg, err := Load(LoadOptions{SearchDir: nested})
+	require.NoError(t, err)
+
+	assert.Equal(t, path, cfg.Source)
+	assert.Equal(t, filepath.Join(filepath.Dir(root), "checkout"), cfg.RepoPath)
+	assert.Equal(t, []string{".png"}, cfg.ExcludeSuffixes)
+	assert.Equal(t, []string{"vendor/"}, cfg.ExcludePaths)
+	assert.Equal(t, []string{"**/testdata/**"}, cfg.ExcludeGlobs)
+	assert.Equal(t, []string{"*.lock", "!Cargo.lock"}, cfg.IgnorePatterns)
+	assert.Equal(t, []string{"generated"}, cfg.ExcludeClasses)
+	assert.Equal(t, []string{"*.gen.ts"}, cfg.Classification.Generated)
+	assert.Equal(t, []domain.ClassificationRule{
+		{Globs: []string{"db/migrations/**"}, Tags: []string{"is_migration"}},
+		{Shebangs: []string{"bun"}, Language: "TypeScript"},
+	}, cfg.Classification.Rules)
+	assert.Equal(t, "{repo}/-/commit/{hash}", cfg.URLTemplates.Commit)
+	assert.Equal(t, "file-token", cfg.Jira.Token)
+}
+
+const sampleTOMLConfig = `
+repo = "../checkout"
+exclude_suffixes = [".png"]
+exclude_paths = ["vendor/"]
+exclude_globs = ["**/testdata/**"]
+ignore_patterns = ["*.lock", "!Cargo.lock"]
+exclude_classes = ["generated"]
+issue_projects = ["ABC"]
+
+[classification]
+generated = ["*.gen.ts"]
+
+[[classification.rules]]
+globs = ["db/migrations/**"]
+tags = ["is_migration"]
+
+[[classification.rules]]
+shebangs = ["bun"]
+language = "TypeScript"
+
+[url_templates]
+commit = "{repo}/-/commit/{hash}"
+
+[jira]
+url = "https://jira.example.com"
+token = "file-token"
+
+[profiles.release]
+exclude_paths = []
+issue_projects = ["ABC", "OPS"]
+
+[profiles.release.confluence]
+space = "REL"
+`
+
+func TestLoad_TOML(t *testing.T) {
+	clearEnv(t)
+	yamlCfg, err := Load(LoadOptions{File: writeConfig(t, t.TempDir(), sampleConfig), Profile: "release"})
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	path := filepath.Join(root, ".supervisor.toml")
+	require.NoError(t, os.WriteFile(path, []byte(sampleTOMLConfig), 0o644))
+	nested := filepath.Join(root, "services")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+
+	tomlCfg, err := Load(LoadOptions{SearchDir: nested, Profile: "release"})
+	require.NoError(t, err)
+
+	assert.Equal(t, path, tomlCfg.Source)
+	assert.Equal(t, filepath.Join(filepath.Dir(root), "checkout"), tomlCfg.RepoPath)
+	yamlCfg.Source, yamlCfg.RepoPath = tomlCfg.Source, tomlCfg.RepoPath
+	assert.Equal(t, yamlCfg, tomlCfg, "TOML and YAML files configure the same settings")
+
+	require.NoError(t, os.WriteFile(path, []byte("exclude_path = [\"vendor/\"]\n"), 0o644))
+	_, err = Load(LoadOptions{File: path})
+	assert.ErrorIs(t, err, domain.ErrInvalidOption, "unknown keys are rejected")
+}
+
+func TestLoad_NoConfigFile(t *testing.T) {
+	clearEnv(t)
+
+	cfg, err := Load(LoadOptions{SearchDir: t.TempDir()})
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Source)
+
+	_, err = Load(LoadOptions{SearchDir: t.TempDir(), Profile: "release"})
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+}
+
+func TestLoad_PrecedenceProfileThenEnv(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), sampleConfig)
+	t.Setenv("SUPERVISOR_JIRA_TOKEN", "env-token")
+
+	cfg, err := Load(LoadOptions{File: path, Profile: "release"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "release", cfg.Profile)
+	assert.Equal(t, []string{}, cfg.ExcludePaths, "an explicit empty list in a profile clears the file value")
+	assert.Equal(t, []string{".png"}, cfg.ExcludeSuffixes, "unset profile fields keep the file value")
+	assert.Equal(t, []string{"ABC", "OPS"}, cfg.IssueProjects)
+	assert.Equal(t, "REL", cfg.Confluence.Space)
+	assert.Equal(t, "https://jira.example.com", cfg.Jira.URL)
+	assert.Equal(t, "env-token", cfg.Jira.Token, "environment overrides the file")
+
+	_, err = Load(LoadOptions{File: path, Profile: "nightly"})
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+}
+
+func TestLoad_ChangelogTemplate(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	path := writeConfig(t, dir, `
+changelog:
+  template_file: docs/changelog.tmpl
+profiles:
+  inline:
+    changelog:
+      template: "{{.Version}}"
+`)
+
+	cfg, err := Load(LoadOptions{File: path})
+	require.NoError(t, err)
+	assert.Equal(t, ChangelogConfig{TemplateFile: filepath.Join(dir, "docs", "changelog.tmpl")}, cfg.Changelog,
+		"template_file is relative to the config file")
+
+	cfg, err = Load(LoadOptions{File: path, Profile: "inline"})
+	require.NoError(t, err)
+	assert.Equal(t, ChangelogConfig{Template: "{{.Version}}"}, cfg.Changelog, "an inline template replaces the file")
+}
+
+func TestLoad_Forge(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	path := writeConfig(t, dir, `
+forge:
+  type: gitea
+  api_url: https://git.example.com/api/v1
+  token: file-token
+  cache_dir: .cache/forge
+profiles:
+  mirror:
+    forge:
+      repository: mirrors/app
+      cache_dir: /var/cache/forge
+`)
+	t.Setenv("SUPERVISOR_FORGE_TOKEN", "env-token")
+
+	cfg, err := Load(LoadOptions{File: path})
+	require.NoError(t, err)
+	assert.Equal(t, ForgeConfig{
+		Type:     "gitea",
+		APIURL:   "https://git.example.com/api/v1",
+		Token:    "env-token",
+		CacheDir: filepath.Join(dir, ".cache", "forge"),
+	}, cfg.Forge, "cache_dir is relative to the config file")
+
+	cfg, err = Load(LoadOptions{File: path, Profile: "mirror"})
+	require.NoError(t, err)
+	assert.Equal(t, "mirrors/app", cfg.Forge.Repository)
+	assert.Equal(t, "/var/cache/forge", cfg.Forge.CacheDir)
+	assert.Equal(t, "gitea", cfg.Forge.Type)
+}
+
+func TestLoad_ForgeLinks(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), `
+url_templates:
+  commit: "{repo}/-/commit/{hash}"
+forge_hosts:
+  git.example.com: gitlab
+forge_url_templates:
+  gitlab:
+    tag: "{repo}/-/releases/{tag}"
+profiles:
+  review:
+    url_templates:
+      pull_request: "https://review.example.com/{number}"
+    forge_hosts:
+      code.example.com: gitea
+`)
+
+	cfg, err := Load(LoadOptions{File: path, Profile: "review"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.URLTemplates{
+		Commit:      "{repo}/-/commit/{hash}",
+		PullRequest: "https://review.example.com/{number}",
+	}, cfg.URLTemplates, "a profile adds to the file's templates")
+	assert.Equal(t, map[string]string{"code.example.com": domain.ForgeGitea}, cfg.ForgeHosts, "a profile's hosts replace the file's")
+	assert.Equal(t, map[string]domain.URLTemplates{domain.ForgeGitLab: {Tag: "{repo}/-/releases/{tag}"}}, cfg.ForgeURLTemplates)
+
+	for _, content := range []string{
+		"forge_hosts:\n  git.example.com: sourcehut\n",
+		"profiles:\n  p:\n    forge_url_templates:\n      gogs:\n        commit: x\n",
+	} {
+		_, err := Load(LoadOptions{File: writeConfig(t, t.TempDir(), content)})
+		assert.ErrorIs(t, err, domain.ErrInvalidOption, content)
+	}
+}
+
+func TestLoad_RejectsUnknownKeys(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, t.TempDir(), "exclude_path: [vendor/]\n")
+
+	_, err := Load(LoadOptions{File: path})
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+}
+
+func TestDump_RedactsSecrets(t *testing.T) {
+	out, err := Dump(&Config{
+		Source:       "/repo/.supervisor.yaml",
+		Profile:      "release",
+		ExcludePaths: []string{"vendor/"},
+		Jira:         JiraConfig{URL: "https://jira.example.com", Token: "secret"},
+		Forge:        ForgeConfig{Type: "github", Token: "forge-secret"},
+	})
+	require.NoError(t, err)
+	dump := string(out)
+
+	assert.True(t, strings.HasPrefix(dump, "# source: /repo/.supervisor.yaml\n# profile: release\n"))
+	assert.Contains(t, dump, "exclude_paths:\n  - vendor/\n")
+	assert.Contains(t, dump, "token: <redacted>")
+	assert.NotContains(t, dump, "secret")
 }
@@ -0,0 +1,350 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileSettings is the on-disk shape of a config file and of each profile.
+// Unset fields leave the value below them untouched; an explicit empty list
+// clears it.
+type fileSettings struct {
+	Repo              string                      `yaml:"repo" toml:"repo"`
+	ExcludeSuffixes   []string                    `yaml:"exclude_suffixes" toml:"exclude_suffixes"`
+	ExcludePaths      []string                    `yaml:"exclude_paths" toml:"exclude_paths"`
+	IncludeGlobs      []string                    `yaml:"include_globs" toml:"include_globs"`
+	ExcludeGlobs      []string                    `yaml:"exclude_globs" toml:"exclude_globs"`
+	ExcludeRegexes    []string                    `yaml:"exclude_regexes" toml:"exclude_regexes"`
+	IgnorePatterns    []string                    `yaml:"ignore_patterns" toml:"ignore_patterns"`
+	ExcludeClasses    []string                    `yaml:"exclude_classes" toml:"exclude_classes"`
+	OnlyClasses       []string                    `yaml:"only_classes" toml:"only_classes"`
+	IssueProjects     []string                    `yaml:"issue_projects" toml:"issue_projects"`
+	Classification    fileClassification          `yaml:"classification" toml:"classification"`
+	URLTemplates      fileURLTemplates            `yaml:"url_templates" toml:"url_templates"`
+	ForgeHosts        map[string]string           `yaml:"forge_hosts" toml:"forge_hosts"`
+	ForgeURLTemplates map[string]fileURLTemplates `yaml:"forge_url_templates" toml:"forge_url_templates"`
+	Jira              fileJira                    `yaml:"jira" toml:"jira"`
+	Confluence        fileConfluence              `yaml:"confluence" toml:"confluence"`
+	Forge             fileForge                   `yaml:"forge" toml:"forge"`
+	Changelog         fileChangelog               `yaml:"changelog" toml:"changelog"`
+}
+
+type fileChangelog struct {
+	Template     string `yaml:"template" toml:"template"`
+	TemplateFile string `yaml:"template_file" toml:"template_file"`
+}
+
+type fileClassification struct {
+	Generated []string                 `yaml:"generated" toml:"generated"`
+	Test      []string                 `yaml:"test" toml:"test"`
+	Config    []string                 `yaml:"config" toml:"config"`
+	Rules     []fileClassificationRule `yaml:"rules" toml:"rules"`
+}
+
+type fileClassificationRule struct {
+	Globs      []string `yaml:"globs,omitempty" toml:"globs"`
+	Extensions []string `yaml:"extensions,omitempty" toml:"extensions"`
+	Shebangs   []string `yaml:"shebangs,omitempty" toml:"shebangs"`
+	Language   string   `yaml:"language,omitempty" toml:"language"`
+	Class      string   `yaml:"class,omitempty" toml:"class"`
+	Tags       []string `yaml:"tags,omitempty" toml:"tags"`
+}
+
+type fileURLTemplates struct {
+	Commit      string `yaml:"commit" toml:"commit"`
+	Compare     string `yaml:"compare" toml:"compare"`
+	Blob        string `yaml:"blob" toml:"blob"`
+	Tag         string `yaml:"tag" toml:"tag"`
+	PullRequest string `yaml:"pull_request" toml:"pull_request"`
+}
+
+type fileJira struct {
+	URL        string `yaml:"url" toml:"url"`
+	APIVersion string `yaml:"api_version" toml:"api_version"`
+	Auth       string `yaml:"auth" toml:"auth"`
+	User       string `yaml:"user" toml:"user"`
+	Token      string `yaml:"token" toml:"token"`
+}
+
+type fileConfluence struct {
+	URL    string `yaml:"url" toml:"url"`
+	Auth   string `yaml:"auth" toml:"auth"`
+	User   string `yaml:"user" toml:"user"`
+	Token  string `yaml:"token" toml:"token"`
+	Space  string `yaml:"space" toml:"space"`
+	Parent string `yaml:"parent" toml:"parent"`
+}
+
+type fileForge struct {
+	Type       string `yaml:"type" toml:"type"`
+	APIURL     string `yaml:"api_url" toml:"api_url"`
+	Repository string `yaml:"repository" toml:"repository"`
+	Token      string `yaml:"token" toml:"token"`
+	CacheDir   string `yaml:"cache_dir" toml:"cache_dir"`
+}
+
+type fileConfig struct {
+	fileSettings `yaml:",inline"`
+	Profiles     map[string]fileSettings `yaml:"profiles" toml:"profiles"`
+}
+
+func readFile(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var file fileConfig
+	if err := decodeFile(path, data, &file); err != nil {
+		return nil, fmt.Errorf("%w: invalid config %s: %v", domain.ErrInvalidOption, path, err)
+	}
+
+	if err := file.validateForges(); err != nil {
+		return nil, fmt.Errorf("%w: invalid config %s: %v", domain.ErrInvalidOption, path, err)
+	}
+
+	// A relative repo is relative to the config file, not the working directory.
+	dir := filepath.Dir(path)
+	file.Repo = resolvePath(dir, file.Repo)
+	file.Changelog.TemplateFile = resolvePath(dir, file.Changelog.TemplateFile)
+	file.Forge.CacheDir = resolvePath(dir, file.Forge.CacheDir)
+	for name, profile := range file.Profiles {
+		profile.Repo = resolvePath(dir, profile.Repo)
+		profile.Changelog.TemplateFile = resolvePath(dir, profile.Changelog.TemplateFile)
+		profile.Forge.CacheDir = resolvePath(dir, profile.Forge.CacheDir)
+		file.Profiles[name] = profile
+	}
+
+	return &file, nil
+}
+
+// decodeFile decodes a config file as TOML when its name ends in .toml and
+// as YAML otherwise, rejecting unknown keys either way.
+func decodeFile(path string, data []byte, file *fileConfig) error {
+	if filepath.Ext(path) == ".toml" {
+		meta, err := toml.Decode(string(data), file)
+		if err != nil {
+			return err
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown key %s", undecoded[0])
+		}
+		return nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(file); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// validateForges rejects forge kinds supervisor does not know, in the file
+// and in every profile.
+func (f *fileConfig) validateForges() error {
+	all := []fileSettings{f.fileSettings}
+	for _, profile := range f.Profiles {
+		all = append(all, profile)
+	}
+	for _, s := range all {
+		for host, kind := range s.ForgeHosts {
+			if !slices.Contains(domain.Forges, kind) {
+				return fmt.Errorf("forge_hosts: %s: unknown forge %q (expected one of %s)", host, kind, strings.Join(domain.Forges, ", "))
+			}
+		}
+		for kind := range s.ForgeURLTemplates {
+			if !slices.Contains(domain.Forges, kind) {
+				return fmt.Errorf("forge_url_templates: unknown forge %q (expected one of %s)", kind, strings.Join(domain.Forges, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func resolvePath(dir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+func (s fileSettings) apply(cfg *Config) {
+	overrideString(&cfg.RepoPath, s.Repo)
+	overrideList(&cfg.ExcludeSuffixes, s.ExcludeSuffixes)
+	overrideList(&cfg.ExcludePaths, s.ExcludePaths)
+	overrideList(&cfg.IncludeGlobs, s.IncludeGlobs)
+	overrideList(&cfg.ExcludeGlobs, s.ExcludeGlobs)
+	overrideList(&cfg.ExcludeRegexes, s.ExcludeRegexes)
+	overrideList(&cfg.IgnorePatterns, s.IgnorePatterns)
+	overrideList(&cfg.ExcludeClasses, s.ExcludeClasses)
+	overrideList(&cfg.OnlyClasses, s.OnlyClasses)
+	overrideList(&cfg.IssueProjects, s.IssueProjects)
+
+	overrideList(&cfg.Classification.Generated, s.Classification.Generated)
+	overrideList(&cfg.Classification.Test, s.Classification.Test)
+	overrideList(&cfg.Classification.Config, s.Classification.Config)
+	if s.Classification.Rules != nil {
+		cfg.Classification.Rules = make([]domain.ClassificationRule, len(s.Classification.Rules))
+		for i, r := range s.Classification.Rules {
+			cfg.Classification.Rules[i] = domain.ClassificationRule(r)
+		}
+	}
+
+	cfg.URLTemplates = domain.URLTemplates(s.URLTemplates).Or(cfg.URLTemplates)
+	if s.ForgeHosts != nil {
+		cfg.ForgeHosts = s.ForgeHosts
+	}
+	if s.ForgeURLTemplates != nil {
+		cfg.ForgeURLTemplates = make(map[string]domain.URLTemplates, len(s.ForgeURLTemplates))
+		for kind, t := range s.ForgeURLTemplates {
+			cfg.ForgeURLTemplates[kind] = domain.URLTemplates(t)
+		}
+	}
+
+	overrideString(&cfg.Jira.URL, s.Jira.URL)
+	overrideString(&cfg.Jira.APIVersion, s.Jira.APIVersion)
+	overrideString(&cfg.Jira.AuthType, s.Jira.Auth)
+	overrideString(&cfg.Jira.User, s.Jira.User)
+	overrideString(&cfg.Jira.Token, s.Jira.Token)
+
+	overrideString(&cfg.Confluence.URL, s.Confluence.URL)
+	overrideString(&cfg.Confluence.AuthType, s.Confluence.Auth)
+	overrideString(&cfg.Confluence.User, s.Confluence.User)
+	overrideString(&cfg.Confluence.Token, s.Confluence.Token)
+	overrideString(&cfg.Confluence.Space, s.Confluence.Space)
+	overrideString(&cfg.Confluence.ParentID, s.Confluence.Parent)
+
+	overrideString(&cfg.Forge.Type, s.Forge.Type)
+	overrideString(&cfg.Forge.APIURL, s.Forge.APIURL)
+	overrideString(&cfg.Forge.Repository, s.Forge.Repository)
+	overrideString(&cfg.Forge.Token, s.Forge.Token)
+	overrideString(&cfg.Forge.CacheDir, s.Forge.CacheDir)
+
+	// The two template forms replace each other, so a profile can swap one
+	// for the other.
+	if s.Changelog.Template != "" {
+		cfg.Changelog.Template, cfg.Changelog.TemplateFile = s.Changelog.Template, ""
+	}
+	if s.Changelog.TemplateFile != "" {
+		cfg.Changelog.Template, cfg.Changelog.TemplateFile = "", s.Changelog.TemplateFile
+	}
+}
+
+func overrideString(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+func overrideList(dst *[]string, v []string) {
+	if v != nil {
+		*dst = v
+	}
+}
+
+// Dump renders cfg in config-file form with secrets redacted, headed by a
+// comment naming the file and profile it came from.
+func Dump(cfg *Config) ([]byte, error) {
+	settings := fileSettings{
+		Repo:            cfg.RepoPath,
+		ExcludeSuffixes: nonNil(cfg.ExcludeSuffixes),
+		ExcludePaths:    nonNil(cfg.ExcludePaths),
+		IncludeGlobs:    nonNil(cfg.IncludeGlobs),
+		ExcludeGlobs:    nonNil(cfg.ExcludeGlobs),
+		ExcludeRegexes:  nonNil(cfg.ExcludeRegexes),
+		IgnorePatterns:  nonNil(cfg.IgnorePatterns),
+		ExcludeClasses:  nonNil(cfg.ExcludeClasses),
+		OnlyClasses:     nonNil(cfg.OnlyClasses),
+		IssueProjects:   nonNil(cfg.IssueProjects),
+		Classification: fileClassification{
+			Generated: nonNil(cfg.Classification.Generated),
+			Test:      nonNil(cfg.Classification.Test),
+			Config:    nonNil(cfg.Classification.Config),
+			Rules:     make([]fileClassificationRule, len(cfg.Classification.Rules)),
+		},
+		URLTemplates:      fileURLTemplates(cfg.URLTemplates),
+		ForgeHosts:        cfg.ForgeHosts,
+		ForgeURLTemplates: map[string]fileURLTemplates{},
+		Jira: fileJira{
+			URL:        cfg.Jira.URL,
+			APIVersion: cfg.Jira.APIVersion,
+			Auth:       cfg.Jira.AuthType,
+			User:       cfg.Jira.User,
+			Token:      redact(cfg.Jira.Token),
+		},
+		Confluence: fileConfluence{
+			URL:    cfg.Confluence.URL,
+			Auth:   cfg.Confluence.AuthType,
+			User:   cfg.Confluence.User,
+			Token:  redact(cfg.Confluence.Token),
+			Space:  cfg.Confluence.Space,
+			Parent: cfg.Confluence.ParentID,
+		},
+		Forge: fileForge{
+			Type:       cfg.Forge.Type,
+			APIURL:     cfg.Forge.APIURL,
+			Repository: cfg.Forge.Repository,
+			Token:      redact(cfg.Forge.Token),
+			CacheDir:   cfg.Forge.CacheDir,
+		},
+		Changelog: fileChangelog{
+			Template:     cfg.Changelog.Template,
+			TemplateFile: cfg.Changelog.TemplateFile,
+		},
+	}
+	for i, r := range cfg.Classification.Rules {
+		settings.Classification.Rules[i] = fileClassificationRule(r)
+	}
+	if settings.ForgeHosts == nil {
+		settings.ForgeHosts = map[string]string{}
+	}
+	for kind, t := range cfg.ForgeURLTemplates {
+		settings.ForgeURLTemplates[kind] = fileURLTemplates(t)
+	}
+
+	var buf bytes.Buffer
+	source := cfg.Source
+	if source == "" {
+		source = "(none)"
+	}
+	fmt.Fprintf(&buf, "# source: %s\n", source)
+	if cfg.Profile != "" {
+		fmt.Fprintf(&buf, "# profile: %s\n", cfg.Profile)
+	}
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(settings); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func nonNil(v []string) []string {
+	if v == nil {
+		return []string{}
+	}
+	return v
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "<redacted>"
+}
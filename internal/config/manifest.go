@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"gopkg.in/yaml.v3"
+)
+
+type manifestFile struct {
+	Name  string          `yaml:"name"`
+	From  string          `yaml:"from"`
+	To    string          `yaml:"to"`
+	Repos []manifestEntry `yaml:"repos"`
+}
+
+type manifestEntry struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// LoadManifest reads a release manifest. Top-level from/to act as defaults
+// for entries that omit them, and relative repository paths are resolved
+// against the directory containing the manifest.
+func LoadManifest(path string) (*domain.Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return ParseManifest(data, filepath.Dir(path))
+}
+
+// ParseManifest parses manifest YAML, resolving relative paths against dir.
+func ParseManifest(data []byte, dir string) (*domain.Manifest, error) {
+	var file manifestFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: invalid manifest: %v", domain.ErrInvalidOption, err)
+	}
+	if len(file.Repos) == 0 {
+		return nil, fmt.Errorf("%w: manifest lists no repos", domain.ErrInvalidOption)
+	}
+
+	manifest := &domain.Manifest{Name: file.Name, Repos: make([]domain.ManifestRepo, 0, len(file.Repos))}
+	seen := make(map[string]bool, len(file.Repos))
+	for i, entry := range file.Repos {
+		if entry.Path == "" {
+			return nil, fmt.Errorf("%w: manifest repo #%d has no path", domain.ErrInvalidOption, i+1)
+		}
+
+		repo := domain.ManifestRepo{
+			Name: entry.Name,
+			Path: entry.Path,
+			From: entry.From,
+			To:   entry.To,
+		}
+		if !filepath.IsAbs(repo.Path) {
+			repo.Path = filepath.Join(dir, repo.Path)
+		}
+		if repo.Name == "" {
+			repo.Name = filepath.Base(repo.Path)
+		}
+		if repo.From == "" {
+			repo.From = file.From
+		}
+		if repo.To == "" {
+			repo.To = file.To
+		}
+
+		if repo.From == "" || repo.To == "" {
+			return nil, fmt.Errorf("%w: manifest repo %q needs from and to refs", domain.ErrInvalidOption, repo.Name)
+		}
+		if seen[repo.Name] {
+			return nil, fmt.Errorf("%w: duplicate manifest repo name %q", domain.ErrInvalidOption, repo.Name)
+		}
+		seen[repo.Name] = true
+
+		manifest.Repos = append(manifest.Repos, repo)
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifest_AppliesDefaults(t *testing.T) {
+	data := []byte(`
+name: Release 24.05
+from: v1.0.0
+to: v1.1.0
+repos:
+  - path: services/api
+  - name: web
+    path: /src/web
+    to: main
+  - name: cli
+    path: ../cli
+    from: v0.9.0
+    to: v1.0.0
+`)
+
+	manifest, err := ParseManifest(data, "/releases")
+	require.NoError(t, err)
+
+	assert.Equal(t, &domain.Manifest{
+		Name: "Release 24.05",
+		Repos: []domain.ManifestRepo{
+			{Name: "api", Path: "/releases/services/api", From: "v1.0.0", To: "v1.1.0"},
+			{Name: "web", Path: "/src/web", From: "v1.0.0", To: "main"},
+			{Name: "cli", Path: "/cli", From: "v0.9.0", To: "v1.0.0"},
+		},
+	}, manifest)
+}
+
+func TestParseManifest_RejectsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"not yaml":       "repos: [",
+		"no repos":       "name: empty\n",
+		"missing path":   "from: a\nto: b\nrepos:\n  - name: api\n",
+		"missing refs":   "repos:\n  - path: api\n    from: v1\n",
+		"duplicate name": "from: a\nto: b\nrepos:\n  - path: x/api\n  - path: y/api\n",
+	}
+
+	for name, data := range tests {
+		_, err := ParseManifest([]byte(data), ".")
+		assert.ErrorIs(t, err, domain.ErrInvalidOption, name)
+	}
+}
+
+func TestLoadManifest_ResolvesRelativeToFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "release.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("from: a\nto: b\nrepos:\n  - path: api\n"), 0o644))
+
+	manifest, err := LoadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "api"), manifest.Repos[0].Path)
+
+	_, err = LoadManifest(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
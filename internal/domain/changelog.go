@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// Changelog is the commit history of a DiffReport grouped for a Keep a
+// Changelog (https://keepachangelog.com) release section.
+type Changelog struct {
+	Version         string
+	PreviousVersion string
+	// Date is the date of the newest commit in the range.
+	Date       time.Time
+	CompareURL string
+	// Breaking repeats every breaking entry, whatever its section.
+	Breaking []ChangelogEntry
+	Sections []ChangelogSection
+	// Omitted counts the entries whose type is left out of changelogs
+	// (chore, ci, docs and the like).
+	Omitted int
+}
+
+// ChangelogSection collects the entries of one heading such as "Added".
+// Scopes are sorted by name, with unscoped entries last.
+type ChangelogSection struct {
+	Title  string
+	Scopes []ChangelogScope
+}
+
+type ChangelogScope struct {
+	Name    string
+	Entries []ChangelogEntry
+}
+
+type ChangelogEntry struct {
+	Type     string
+	Scope    string
+	Subject  string
+	Breaking bool
+	// BreakingNote is the text of a BREAKING CHANGE footer, if any.
+	BreakingNote string
+	Hash         string
+	URL          string
+	Author       string
+	// PullRequest is the number of the request the entry stands for, or 0
+	// for a commit landed directly. Hash is then the commit that landed it.
+	PullRequest int
+	// PullRequestURL links the request on the forge.
+	PullRequestURL string
+}
@@ -0,0 +1,65 @@
+package domain
+
+// Classifier decides the language, classes and custom tags of a changed file.
+type Classifier interface {
+	Classify(file ClassifyInput) FileClass
+}
+
+// ClassifyInput describes a changed file. Head holds the first bytes of its
+// content at the target side (the source side for deletions) and is empty
+// for binary files. Attributes are the file's git attributes on that side:
+// "true" when set, "false" when unset, otherwise the assigned value; the
+// "binary" macro also unsets diff, merge and text.
+type ClassifyInput struct {
+	Path       string
+	Head       []byte
+	Attributes map[string]string
+}
+
+// Signals recorded in FileClass.GeneratedBy.
+const (
+	GeneratedByAttributes = "gitattributes"
+	GeneratedByPath       = "path"
+	GeneratedByHeader     = "generated_header"
+	GeneratedByMarker     = "generated_marker"
+	GeneratedByMinified   = "minified"
+)
+
+// FileClass is the result of classifying a file. GeneratedBy names the
+// signal that marked it generated.
+type FileClass struct {
+	Language        string
+	IsGenerated     bool
+	GeneratedBy     string
+	IsTest          bool
+	IsConfig        bool
+	IsVendored      bool
+	IsDocumentation bool
+	Tags            []string
+}
+
+// ClassificationRules add repository-specific rules on top of the built-in
+// ruleset. Generated, Test and Config are shorthand for path-pattern rules
+// setting that class.
+type ClassificationRules struct {
+	Generated []string
+	Test      []string
+	Config    []string
+	Rules     []ClassificationRule
+}
+
+// ClassificationRule matches a file when any of its globs, extensions or
+// shebang interpreters does, and then applies its language, class and tags.
+// Globs use doublestar syntax; a glob ending in "/" matches that directory
+// at any depth and one without "/" also matches the base name.
+type ClassificationRule struct {
+	Globs      []string
+	Extensions []string
+	Shebangs   []string
+
+	Language string
+	// Class is ClassTest, ClassGenerated, ClassConfig, ClassVendored or
+	// ClassDocumentation.
+	Class string
+	Tags  []string
+}
@@ -1,5 +1,10 @@
 package domain
 
+import (
+	"fmt"
+	"strings"
+)
+
 type FilePath struct {
 	Before string
 	After  string
@@ -8,10 +13,53 @@ type FilePath struct {
 type Classification struct {
 	IsNew       bool
 	IsRename    bool
+	IsCopy      bool
 	IsBinary    bool
 	IsGenerated bool
-	IsTest      bool
-	IsConfig    bool
+	// GeneratedBy is one of the GeneratedBy* signals when IsGenerated is set.
+	GeneratedBy     string
+	IsTest          bool
+	IsConfig        bool
+	IsVendored      bool
+	IsDocumentation bool
+	// Tags are the custom labels of the classification rules that matched.
+	Tags []string
+}
+
+// File classes used for classification filters and summary breakdowns.
+// ClassSource covers every file that is none of the others.
+const (
+	ClassSource        = "source"
+	ClassTest          = "test"
+	ClassGenerated     = "generated"
+	ClassConfig        = "config"
+	ClassBinary        = "binary"
+	ClassVendored      = "vendored"
+	ClassDocumentation = "documentation"
+)
+
+// Classes lists the file classes in report order.
+var Classes = []string{ClassSource, ClassTest, ClassGenerated, ClassConfig, ClassBinary, ClassVendored, ClassDocumentation}
+
+// Has reports whether the classification belongs to class.
+func (c Classification) Has(class string) bool {
+	switch class {
+	case ClassTest:
+		return c.IsTest
+	case ClassGenerated:
+		return c.IsGenerated
+	case ClassConfig:
+		return c.IsConfig
+	case ClassBinary:
+		return c.IsBinary
+	case ClassVendored:
+		return c.IsVendored
+	case ClassDocumentation:
+		return c.IsDocumentation
+	case ClassSource:
+		return !c.IsTest && !c.IsGenerated && !c.IsConfig && !c.IsBinary && !c.IsVendored && !c.IsDocumentation
+	}
+	return false
 }
 
 type FileHistory struct {
@@ -26,15 +74,93 @@ type FileLineStats struct {
 type FileChange struct {
 	Path           FilePath
 	ChangeType     string
+	Similarity     int
 	Language       string
 	Lines          FileLineStats
 	Classification Classification
 	History        FileHistory
+	// Patch is set for text files when RequestOptions.Patches.Include is.
+	Patch *FilePatch
+	// URL links the file on the forge as of the target, or as of the
+	// baseline when it was deleted, at its first changed line when the
+	// patch is known.
+	URL string
+}
+
+// FilePatch holds the unified-diff hunks of a file. When a byte budget cut
+// it short, Truncated is set, OmittedBytes counts what was left out and the
+// last kept hunk ends with a PatchTruncatedMarker line; when no hunk was
+// kept, a hunk without a header holds just that line.
+type FilePatch struct {
+	Hunks        []PatchHunk
+	Truncated    bool
+	OmittedBytes int
+}
+
+// PatchHunk is one "@@ -a,b +c,d @@" header and its lines, each prefixed
+// with ' ', '+', '-' or '\' and terminated by a newline.
+type PatchHunk struct {
+	Header string
+	Lines  string
+}
+
+// PatchTruncatedMarker starts the line appended where a patch was cut.
+// Like git's "\ No newline at end of file" it is not part of the content.
+const PatchTruncatedMarker = "\\ truncated"
+
+// PatchTruncationLine is the marker line noting omitted bytes.
+func PatchTruncationLine(omitted int) string {
+	return fmt.Sprintf("%s: %d bytes omitted\n", PatchTruncatedMarker, omitted)
+}
+
+// Size is the length of the hunk in unified-diff form.
+func (h PatchHunk) Size() int {
+	if h.Header == "" {
+		return len(h.Lines)
+	}
+	return len(h.Header) + 1 + len(h.Lines)
+}
+
+// String renders the hunks in unified-diff form, without file headers.
+func (p FilePatch) String() string {
+	var b strings.Builder
+	for _, h := range p.Hunks {
+		if h.Header != "" {
+			b.WriteString(h.Header)
+			b.WriteByte('\n')
+		}
+		b.WriteString(h.Lines)
+	}
+	return b.String()
 }
 
 type DiffSummary struct {
 	Files FileStats
 	Lines SummaryLineStats
+	// ByClassification has one entry per Classes value; a file may count
+	// towards several classes. ByLanguage is ordered by churn, largest first.
+	ByClassification []BreakdownStats
+	ByLanguage       []BreakdownStats
+	// Patches is set when patches were requested.
+	Patches *PatchSummary
+}
+
+// PatchSummary reports how much patch text the report carries.
+type PatchSummary struct {
+	Bytes          int
+	TruncatedFiles int
+}
+
+// BreakdownStats aggregates the files of one class or language. Percentages
+// are relative to all files in the tree diff and to its total churn (lines
+// added plus deleted), rounded to one decimal place.
+type BreakdownStats struct {
+	Name         string
+	Files        int
+	Added        int
+	Deleted      int
+	FilesPercent float64
+	ChurnPercent float64
 }
 
 type FileStats struct {
@@ -42,6 +168,7 @@ type FileStats struct {
 	Modified int
 	Deleted  int
 	Renamed  int
+	Copied   int
 }
 
 type SummaryLineStats struct {
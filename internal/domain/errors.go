@@ -6,4 +6,10 @@ var (
 	ErrRefNotFound   = errors.New("reference not found")
 	ErrRepoNotFound  = errors.New("repository not found")
 	ErrStopIteration = errors.New("stop iteration")
+	ErrNotAncestor   = errors.New("reference is not an ancestor")
+	ErrInvalidOption = errors.New("invalid option")
+	ErrIssueNotFound = errors.New("issue not found")
+	ErrFileNotFound  = errors.New("file not found")
+
+	ErrPullRequestNotFound = errors.New("pull request not found")
 )
@@ -1,10 +1,55 @@
 package domain
 
+// IgnoreFileName is the gitignore-style rules file read from the target ref.
+const IgnoreFileName = ".supervisorignore"
+
+// Filter rule kinds reported in FilterMatch.Rule.
+const (
+	FilterRuleInclude    = "include_glob"
+	FilterRuleSuffix     = "suffix"
+	FilterRulePath       = "path"
+	FilterRuleGlob       = "glob"
+	FilterRuleRegex      = "regex"
+	FilterRuleIgnore     = "ignore"
+	FilterRuleIgnoreFile = "ignore_file"
+	FilterRuleClass      = "classification"
+	FilterRuleOnlyClass  = "only_classification"
+)
+
 type Filter interface {
-	ShouldExclude(path string) bool
+	// Match returns the rule that excludes change, and false when it is kept.
+	Match(change FileChange) (FilterMatch, bool)
+}
+
+// FilterMatch names the rule that excluded a file. Pattern is the matching
+// pattern, or the allowlist it missed for FilterRuleInclude.
+type FilterMatch struct {
+	Rule    string
+	Pattern string
 }
 
 type FilterRule struct {
 	ExcludeSuffixes []string
 	ExcludePaths    []string
+	// IncludeGlobs is an allowlist: when set, paths matching none of them
+	// are excluded. Globs use doublestar syntax; a glob without "/" also
+	// matches the base name at any depth.
+	IncludeGlobs   []string
+	ExcludeGlobs   []string
+	ExcludeRegexes []string
+	// IgnorePatterns are gitignore-syntax lines; a later "!pattern" re-includes
+	// paths excluded by an earlier ignore pattern.
+	IgnorePatterns []string
+	// ExcludeClasses drops files of the given Classes; OnlyClasses keeps
+	// only files belonging to at least one of them.
+	ExcludeClasses []string
+	OnlyClasses    []string
+}
+
+// ExcludedFile records a change dropped by a filter rule.
+type ExcludedFile struct {
+	Path       FilePath
+	ChangeType string
+	Lines      FileLineStats
+	Match      FilterMatch
 }
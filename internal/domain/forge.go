@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Forges whose links supervisor builds. All but Bitbucket can also enrich
+// pull requests through their REST APIs.
+const (
+	ForgeGitHub    = "github"
+	ForgeGitLab    = "gitlab"
+	ForgeGitea     = "gitea"
+	ForgeBitbucket = "bitbucket"
+)
+
+var Forges = []string{ForgeGitHub, ForgeGitLab, ForgeGitea, ForgeBitbucket}
+
+// KnownForgeHosts maps the public forges to their kind.
+var KnownForgeHosts = map[string]string{
+	"github.com":    ForgeGitHub,
+	"gitlab.com":    ForgeGitLab,
+	"gitea.com":     ForgeGitea,
+	"codeberg.org":  ForgeGitea,
+	"bitbucket.org": ForgeBitbucket,
+}
+
+// ForgeForHost returns the kind of forge serving host, looking in hosts
+// before KnownForgeHosts, or "" when it is neither. Hosts are compared
+// without case.
+func ForgeForHost(host string, hosts map[string]string) string {
+	host = strings.ToLower(host)
+	for h, kind := range hosts {
+		if strings.ToLower(h) == host {
+			return kind
+		}
+	}
+	return KnownForgeHosts[host]
+}
+
+// Pull request states as reported by a forge, normalised across forges.
+const (
+	PullRequestOpen   = "open"
+	PullRequestClosed = "closed"
+	PullRequestMerged = "merged"
+)
+
+// ForgePullRequest is the forge's view of a pull (or merge) request.
+type ForgePullRequest struct {
+	Number int
+	URL    string
+	Title  string
+	State  string
+	Author string
+	Labels []string
+	// Reviewers are everyone who reviewed the request or was asked to,
+	// sorted.
+	Reviewers []string
+	MergedAt  *time.Time
+}
+
+type PullRequestProvider interface {
+	GetPullRequest(ctx context.Context, number int) (*ForgePullRequest, error)
+}
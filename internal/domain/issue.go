@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Reasons attached to an IssueWarning.
+const (
+	IssueWarningNotFound          = "not_found"
+	IssueWarningClosedBeforeMerge = "closed_before_merge"
+)
+
+// IssueReference is an issue key found in the history of a diff, with the
+// commits, refs and files that point at it.
+type IssueReference struct {
+	Key      string
+	Commits  []string
+	Branches []string
+	Files    []string
+	// Details is filled in when an IssueTracker is configured.
+	Details  *TrackedIssue
+	Warnings []IssueWarning
+}
+
+// IssueWarning flags a commit whose issue reference looks wrong.
+type IssueWarning struct {
+	Commit string
+	Reason string
+}
+
+// TrackedIssue is the tracker's view of an issue.
+type TrackedIssue struct {
+	Key         string
+	URL         string
+	Summary     string
+	Status      string
+	Closed      bool
+	Type        string
+	Assignee    string
+	FixVersions []string
+	Labels      []string
+	ResolvedAt  *time.Time
+}
+
+type IssueTracker interface {
+	GetIssue(ctx context.Context, key string) (*TrackedIssue, error)
+}
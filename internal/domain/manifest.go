@@ -0,0 +1,43 @@
+package domain
+
+// Manifest lists the repositories that make up one multi-repository release.
+type Manifest struct {
+	Name  string
+	Repos []ManifestRepo
+}
+
+type ManifestRepo struct {
+	Name string
+	Path string
+	From string
+	To   string
+}
+
+type MultiDiffReport struct {
+	SchemaVersion string
+	Name          string
+	Summary       CrossRepoSummary
+	Repositories  []RepoSection
+	Metadata      Metadata
+}
+
+type CrossRepoSummary struct {
+	Repositories int
+	Succeeded    int
+	Failed       int
+	Files        FileStats
+	Lines        SummaryLineStats
+	Commits      int
+	Issues       []string
+}
+
+// RepoSection holds either the report for one manifest entry or the error
+// that prevented it from being generated.
+type RepoSection struct {
+	Name   string
+	Path   string
+	From   string
+	To     string
+	Report *DiffReport
+	Error  string
+}
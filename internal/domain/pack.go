@@ -0,0 +1,50 @@
+package domain
+
+// DefaultPackMaxTokens leaves room for instructions and an answer in the
+// context window of current long-context models.
+const DefaultPackMaxTokens = 100_000
+
+// PackOptions bound a Pack. Token counts are estimates; no tokenizer is
+// involved.
+type PackOptions struct {
+	MaxTokens int
+}
+
+// Pack dispositions describe how a file appears in a Pack.
+const (
+	PackIncluded   = "included"   // full patch
+	PackTruncated  = "truncated"  // patch cut to fit the budget
+	PackSummarized = "summarized" // path and line counts only
+	PackDropped    = "dropped"    // left out of the bundle entirely
+)
+
+// Pack reasons explain why a file is not included in full.
+const (
+	PackReasonGenerated = "generated"
+	PackReasonBinary    = "binary"
+	PackReasonVendored  = "vendored"
+	PackReasonLockfile  = "lockfile"
+	PackReasonNoPatch   = "no_patch"
+	PackReasonBudget    = "budget"
+)
+
+// Pack is a DiffReport cut down to a token budget for an LLM prompt.
+// Files lists every file of the report, highest signal first, and doubles
+// as the manifest of what was summarized, truncated or dropped.
+type Pack struct {
+	Report         *DiffReport
+	MaxTokens      int
+	Tokens         int
+	Commits        []Commit
+	OmittedCommits int
+	Files          []PackedFile
+}
+
+// PackedFile is one file of a Pack. File.Patch is set only for included
+// and truncated files; Tokens is what the file costs in the bundle.
+type PackedFile struct {
+	File        FileChange
+	Disposition string
+	Reason      string
+	Tokens      int
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "context"
+
+// Outcomes reported by a PagePublisher.
+const (
+	PageCreated   = "created"
+	PageUpdated   = "updated"
+	PageUnchanged = "unchanged"
+)
+
+// Page is a document to publish to a wiki. When PageID is set the page is
+// updated in place; otherwise it is looked up by Title within SpaceKey.
+type Page struct {
+	SpaceKey string
+	ParentID string
+	PageID   string
+	Title    string
+	Body     string
+}
+
+type PublishedPage struct {
+	ID      string
+	Title   string
+	URL     string
+	Version int
+	Action  string
+}
+
+type PagePublisher interface {
+	PublishPage(ctx context.Context, page Page) (*PublishedPage, error)
+}
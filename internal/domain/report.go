@@ -1,6 +1,14 @@
 package domain
 
-import "time"
+import (
+	"cmp"
+	"time"
+)
+
+// ReportSchemaVersion is the version of the report's JSON schema. Fields
+// added since it was published are optional, so reports written by older
+// releases still validate.
+const ReportSchemaVersion = "1.0"
 
 type DiffReport struct {
 	SchemaVersion string
@@ -11,6 +19,7 @@ type DiffReport struct {
 	Filters       ReportFilters
 	TreeDiff      TreeDiff
 	HistoryView   HistoryView
+	Issues        []IssueReference
 	DiffLinks     DiffLinks
 	Integrity     Integrity
 	Metadata      Metadata
@@ -31,12 +40,55 @@ type Request struct {
 
 type RequestOptions struct {
 	IgnoreMergeCommits bool
-	DetectRenames      bool
+	// FirstParent limits history to the first-parent chain of the target,
+	// the mainline as seen by whoever merged into it; it keeps merge commits.
+	FirstParent bool
+	// HistoryOrder is one of the HistoryOrder* values; empty means
+	// HistoryOrderAuthorDate.
+	HistoryOrder string
+	// NestMerges lists the commits each merge brought in under it in
+	// Commit.Merged; it implies FirstParent and keeps merge commits.
+	NestMerges          bool
+	DetectRenames       bool
+	DetectCopies        bool
+	SimilarityThreshold int
+	BaselineStrategy    string
+	// IssueProjects holds project-key patterns (e.g. "ABC" or "OPS[0-9]?")
+	// used to extract issue keys; extraction is off when it is empty.
+	IssueProjects []string
+	// SkipIgnoreFile disables reading IgnoreFileName from the target ref.
+	SkipIgnoreFile bool
+	// ExplainFilters lists every excluded file in ReportFilters.Excluded.
+	ExplainFilters bool
+	// Patches embeds unified-diff hunks in each FileChange.
+	Patches PatchOptions
+}
+
+// DefaultPatchContextLines matches git diff's default context; the byte
+// budgets keep a patched report within reach of an LLM context window.
+const (
+	DefaultPatchContextLines  = 3
+	DefaultPatchMaxFileBytes  = 16 << 10
+	DefaultPatchMaxTotalBytes = 256 << 10
+)
+
+// PatchOptions control the hunks embedded in FileChange.Patch. A zero byte
+// limit means no limit; the total budget is spent in file order after
+// filtering.
+type PatchOptions struct {
+	Include       bool
+	ContextLines  int
+	MaxFileBytes  int
+	MaxTotalBytes int
 }
 
 type RequestFilters struct {
 	ExcludeSuffixes []string
 	ExcludePaths    []string
+	IncludeGlobs    []string
+	ExcludeGlobs    []string
+	ExcludeRegexes  []string
+	IgnorePatterns  []string
 }
 
 type Resolution struct {
@@ -48,8 +100,20 @@ type ResolutionRef struct {
 	Ref    string
 	Type   string
 	Commit string
+	// URL links a tag's page on the forge; other refs have none.
+	URL string
 }
 
+// Baseline strategies select which commit the tree diff starts from.
+const (
+	// BaselineMergeBase diffs from the merge-base of from and to (git diff from...to).
+	BaselineMergeBase = "merge-base"
+	// BaselineDirect diffs from the from commit and requires it to be an ancestor of to.
+	BaselineDirect = "direct"
+	// BaselineTwoDot diffs from the from commit regardless of ancestry (git diff from..to).
+	BaselineTwoDot = "two-dot"
+)
+
 type Baseline struct {
 	Strategy   string
 	BaseCommit string
@@ -62,10 +126,15 @@ type Ancestry struct {
 }
 
 type ReportFilters struct {
-	SuffixExcluded      []string
-	PathExcluded        []string
+	SuffixExcluded []string
+	PathExcluded   []string
+	// IgnoreFilePatterns are the rules read from IgnoreFileName at the
+	// target ref, empty when the file is absent or skipped.
+	IgnoreFilePatterns  []string
 	BinaryFilesDetected int
 	FilesFilteredOut    int
+	// Excluded is only populated when RequestOptions.ExplainFilters is set.
+	Excluded []ExcludedFile
 }
 
 type TreeDiff struct {
@@ -77,12 +146,64 @@ type HistoryView struct {
 	Options     HistoryOptions
 	CommitRange CommitRange
 	Commits     []Commit
+	// PullRequests groups the history by the pull or merge request that
+	// brought it in, in the order the requests were merged.
+	PullRequests []PullRequest
+}
+
+// Pull request merge styles: a merge commit joining the request's branch,
+// or a single commit squashing it, recognised by a "(#123)" subject suffix.
+const (
+	PullRequestMerge  = "merge"
+	PullRequestSquash = "squash"
+)
+
+// PullRequest is a pull (or merge) request recognised from the message of
+// the commit that landed it.
+type PullRequest struct {
+	Number int
+	Title  string
+	// SourceBranch is the branch the request was merged from, as named in
+	// the merge message; squashes do not record it.
+	SourceBranch string
+	Style        string
+	MergeCommit  string
+	// CommitURL links MergeCommit, and URL the request on the forge.
+	CommitURL string
+	URL       string
+	// Author is the author of the oldest member commit, who usually opened
+	// the request, rather than whoever merged it.
+	Author string
+	Date   time.Time
+	// Commits lists the hashes of the commits the request brought in that
+	// are in the range, oldest first, even when the history is limited to
+	// the first-parent chain; a squash lists its own commit. A commit is
+	// listed under every request that brought it in, so one merged into
+	// another request's branch appears in both.
+	Commits []string
+	// Details is filled in when a PullRequestProvider is configured.
+	Details *ForgePullRequest
 }
 
 type HistoryOptions struct {
 	MergeCommitsIncluded bool
+	FirstParent          bool
+	Order                string
+	NestedMerges         bool
 }
 
+// History orders, oldest first. Topological order lists every commit after
+// its parents and keeps the commits of a merged branch together, right
+// before their merge.
+const (
+	HistoryOrderTopo          = "topo"
+	HistoryOrderAuthorDate    = "author-date"
+	HistoryOrderCommitterDate = "committer-date"
+)
+
+// HistoryOrders lists the valid HistoryOptions.Order values.
+var HistoryOrders = []string{HistoryOrderTopo, HistoryOrderAuthorDate, HistoryOrderCommitterDate}
+
 type CommitRange struct {
 	From string
 	To   string
@@ -94,6 +215,50 @@ type Commit struct {
 	Date    time.Time
 	Message string
 	DiffURL string
+	// CommittedAt is the committer date, when the commit was last applied
+	// to a branch. It is not part of the report.
+	CommittedAt time.Time
+	// Parsed is Message split into its Conventional Commits parts.
+	Parsed CommitMessage
+	// Files lists the paths changed relative to the first parent; renames
+	// carry both names. It feeds FileHistory and is not part of the report.
+	Files []FilePath
+	// Parents are the hashes of the parent commits, first parent first.
+	Parents []string
+	// Merged holds, for a merge when HistoryOptions.NestedMerges is set,
+	// the commits it brought into the mainline, in history order.
+	Merged []Commit
+}
+
+// FlattenCommits lists commits with the nested Merged commits of each merge
+// placed right before it, so an oldest-first history stays oldest first.
+func FlattenCommits(commits []Commit) []Commit {
+	var out []Commit
+	for _, c := range commits {
+		out = append(out, FlattenCommits(c.Merged)...)
+		out = append(out, c)
+	}
+	return out
+}
+
+// CommitMessage is a commit message parsed per
+// https://www.conventionalcommits.org. Type and Scope are empty when the
+// subject line does not follow the convention; Subject is then the whole
+// line. Trailers are the git trailers of the final paragraph, in order,
+// and are not repeated in Body.
+type CommitMessage struct {
+	Type     string
+	Scope    string
+	Subject  string
+	Body     string
+	Breaking bool
+	Trailers []Trailer
+}
+
+// Trailer is one "Key: value" line such as "Signed-off-by: Ada <ada@example.com>".
+type Trailer struct {
+	Key   string
+	Value string
 }
 
 type DiffLinks struct {
@@ -106,6 +271,30 @@ type VersionDiffLink struct {
 	URL    string
 }
 
+// URLTemplates are the links built from the origin remote. Every template
+// may use {repo}, the remote's web URL; Commit adds {hash}, Compare {base}
+// and {target}, Blob {ref}, {path} and {line}, Tag {tag} and PullRequest
+// {number}. Blob's line anchor, from "#" on, is dropped when there is no
+// line to point at.
+type URLTemplates struct {
+	Commit      string
+	Compare     string
+	Blob        string
+	Tag         string
+	PullRequest string
+}
+
+// Or fills the templates t leaves empty from fallback.
+func (t URLTemplates) Or(fallback URLTemplates) URLTemplates {
+	return URLTemplates{
+		Commit:      cmp.Or(t.Commit, fallback.Commit),
+		Compare:     cmp.Or(t.Compare, fallback.Compare),
+		Blob:        cmp.Or(t.Blob, fallback.Blob),
+		Tag:         cmp.Or(t.Tag, fallback.Tag),
+		PullRequest: cmp.Or(t.PullRequest, fallback.PullRequest),
+	}
+}
+
 type Integrity struct {
 	DiffBasis                      string
 	HistoryRole                    string
@@ -7,21 +7,29 @@ type RefResolver interface {
 }
 
 type DiffCalculator interface {
-	CalculateDiff(ctx context.Context, fromHash, toHash string) ([]FileChange, DiffStats, error)
+	CalculateDiff(ctx context.Context, fromHash, toHash string, opts RequestOptions) ([]FileChange, DiffStats, error)
 }
 
 type HistoryProvider interface {
-	GetHistory(ctx context.Context, fromHash, toHash string, excludeMerges bool) ([]Commit, error)
+	GetHistory(ctx context.Context, fromHash, toHash string, opts HistoryOptions) ([]Commit, error)
 }
 
 type BaselineCalculator interface {
-	CalculateBaseline(ctx context.Context, fromHash, toHash string) (baseHash, strategy string, isLinear bool, err error)
+	CalculateBaseline(ctx context.Context, fromHash, toHash, strategy string) (baseHash string, isLinear bool, err error)
+}
+
+type FileReader interface {
+	ReadFile(ctx context.Context, commitHash, path string) ([]byte, error)
 }
 
 type MetadataProvider interface {
 	GetRepoURL() string
 	GetRepoName() string
 	GetDiffURL(base, target string) string
+	// GetBlobURL links path at ref, at line when it is positive.
+	GetBlobURL(ref, path string, line int) string
+	GetTagURL(tag string) string
+	GetPullRequestURL(number int) string
 }
 
 type Repository interface {
@@ -29,5 +37,6 @@ type Repository interface {
 	DiffCalculator
 	HistoryProvider
 	BaselineCalculator
+	FileReader
 	MetadataProvider
 }
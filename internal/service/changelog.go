@@ -0,0 +1,215 @@
+package service
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// changelogSections maps Conventional Commits types to Keep a Changelog
+// headings, in the order they are listed. Commits of any other type, or
+// without one, go to "Other"; the types in omittedTypes are left out.
+var changelogSections = []struct {
+	title string
+	types []string
+}{
+	{"Added", []string{"feat"}},
+	{"Changed", []string{"perf", "refactor", "revert"}},
+	{"Deprecated", []string{"deprecate", "deprecated"}},
+	{"Removed", []string{"remove"}},
+	{"Fixed", []string{"fix"}},
+	{"Security", []string{"security", "sec"}},
+	{"Other", nil},
+}
+
+var omittedTypes = []string{"build", "chore", "ci", "docs", "style", "test"}
+
+// revertedCommit matches the line git revert writes into a revert message.
+var revertedCommit = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-f]{7,40})`)
+
+// BuildChangelog groups the commits of r by type, then by scope. Commits
+// landed by a recognised pull request are replaced by one entry for the
+// request, typed by its title or else by its first typed commit. A revert
+// and the commit it reverts cancel out when both are in range, and so does
+// a request they make up. Breaking entries are kept even when their type
+// would be omitted.
+func BuildChangelog(r *domain.DiffReport) *domain.Changelog {
+	cl := &domain.Changelog{
+		Version:         r.Resolution.To.Ref,
+		PreviousVersion: r.Resolution.From.Ref,
+		CompareURL:      r.DiffLinks.VersionDiff.URL,
+		Date:            r.Metadata.GeneratedAt,
+	}
+
+	commits := domain.FlattenCommits(r.HistoryView.Commits)
+	byHash := make(map[string]domain.Commit, len(commits))
+	for _, c := range commits {
+		byHash[c.Hash] = c
+	}
+	landedBy := map[string]*domain.PullRequest{}
+	for i := range r.HistoryView.PullRequests {
+		pr := &r.HistoryView.PullRequests[i]
+		landedBy[pr.MergeCommit] = pr
+		for _, h := range pr.Commits {
+			landedBy[h] = pr
+		}
+	}
+
+	cancelled := cancelledReverts(commits)
+	var entries []domain.ChangelogEntry
+	added := map[*domain.PullRequest]bool{}
+	for i := range r.HistoryView.PullRequests {
+		pr := &r.HistoryView.PullRequests[i]
+		added[pr] = cancelled[pr.MergeCommit] || len(pr.Commits) > 0 && !slices.ContainsFunc(pr.Commits, func(h string) bool {
+			return !cancelled[h]
+		})
+	}
+	for i, c := range commits {
+		if i == 0 || c.Date.After(cl.Date) {
+			cl.Date = c.Date
+		}
+		pr, ok := landedBy[c.Hash]
+		switch {
+		case !ok && cancelled[c.Hash]:
+		case !ok:
+			entries = append(entries, commitEntry(c))
+		case !added[pr]:
+			added[pr] = true
+			entries = append(entries, pullRequestEntry(*pr, byHash))
+		}
+	}
+	// Requests landed by merges left out of the history still count.
+	for i := range r.HistoryView.PullRequests {
+		if pr := &r.HistoryView.PullRequests[i]; !added[pr] {
+			entries = append(entries, pullRequestEntry(*pr, byHash))
+		}
+	}
+
+	byTitle := map[string]map[string][]domain.ChangelogEntry{}
+	for _, entry := range entries {
+		if entry.Breaking {
+			cl.Breaking = append(cl.Breaking, entry)
+		}
+
+		title := changelogTitle(entry.Type)
+		if slices.Contains(omittedTypes, entry.Type) {
+			if !entry.Breaking {
+				cl.Omitted++
+				continue
+			}
+			title = "Other"
+		}
+		if byTitle[title] == nil {
+			byTitle[title] = map[string][]domain.ChangelogEntry{}
+		}
+		byTitle[title][entry.Scope] = append(byTitle[title][entry.Scope], entry)
+	}
+
+	for _, s := range changelogSections {
+		scopes, ok := byTitle[s.title]
+		if !ok {
+			continue
+		}
+		section := domain.ChangelogSection{Title: s.title}
+		for name, entries := range scopes {
+			section.Scopes = append(section.Scopes, domain.ChangelogScope{Name: name, Entries: entries})
+		}
+		sort.Slice(section.Scopes, func(i, j int) bool {
+			a, b := section.Scopes[i].Name, section.Scopes[j].Name
+			if (a == "") != (b == "") {
+				return b == ""
+			}
+			return a < b
+		})
+		cl.Sections = append(cl.Sections, section)
+	}
+	return cl
+}
+
+// cancelledReverts returns the commits that cancel out: every revert made
+// by git revert, with the commit it reverts when that is in range too. A
+// revert of a revert that was cancelled already stands, as it reapplies a
+// change.
+func cancelledReverts(commits []domain.Commit) map[string]bool {
+	cancelled := map[string]bool{}
+	for _, c := range commits {
+		m := revertedCommit.FindStringSubmatch(c.Message)
+		if m == nil {
+			continue
+		}
+		for _, reverted := range commits {
+			if strings.HasPrefix(reverted.Hash, m[1]) && !cancelled[reverted.Hash] {
+				cancelled[c.Hash], cancelled[reverted.Hash] = true, true
+				break
+			}
+		}
+	}
+	return cancelled
+}
+
+func commitEntry(c domain.Commit) domain.ChangelogEntry {
+	msg := c.Parsed
+	return domain.ChangelogEntry{
+		Type:         msg.Type,
+		Scope:        msg.Scope,
+		Subject:      msg.Subject,
+		Breaking:     msg.Breaking,
+		BreakingNote: breakingNote(msg),
+		Hash:         c.Hash,
+		URL:          c.DiffURL,
+		Author:       c.Author,
+	}
+}
+
+// pullRequestEntry is the entry for pr. Its title is parsed like a commit
+// subject; an untyped title takes the type and scope of the first typed
+// member commit, and any breaking member makes the request breaking.
+func pullRequestEntry(pr domain.PullRequest, byHash map[string]domain.Commit) domain.ChangelogEntry {
+	title := ParseCommitMessage(pr.Title)
+	entry := domain.ChangelogEntry{
+		Type:        title.Type,
+		Scope:       title.Scope,
+		Subject:     title.Subject,
+		Breaking:    title.Breaking,
+		Hash:        pr.MergeCommit,
+		URL:         pr.CommitURL,
+		Author:      pr.Author,
+		PullRequest: pr.Number,
+	}
+	entry.PullRequestURL = pr.URL
+	if pr.Details != nil && pr.Details.URL != "" {
+		entry.PullRequestURL = pr.Details.URL
+	}
+	for _, h := range pr.Commits {
+		msg := byHash[h].Parsed
+		if entry.Type == "" && msg.Type != "" {
+			entry.Type, entry.Scope = msg.Type, msg.Scope
+		}
+		entry.Breaking = entry.Breaking || msg.Breaking
+		if entry.BreakingNote == "" {
+			entry.BreakingNote = breakingNote(msg)
+		}
+	}
+	return entry
+}
+
+func breakingNote(msg domain.CommitMessage) string {
+	for _, t := range msg.Trailers {
+		if t.Key == "BREAKING CHANGE" || strings.EqualFold(t.Key, "BREAKING-CHANGE") {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+func changelogTitle(commitType string) string {
+	for _, s := range changelogSections {
+		if slices.Contains(s.types, commitType) {
+			return s.title
+		}
+	}
+	return "Other"
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildChangelog(t *testing.T) {
+	commit := func(hash, message string, day int) domain.Commit {
+		return domain.Commit{
+			Hash:    hash,
+			Author:  "Ada",
+			Date:    time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC),
+			Message: message,
+			DiffURL: "https://example.com/commit/" + hash,
+			Parsed:  ParseCommitMessage(message),
+		}
+	}
+	report := &domain.DiffReport{
+		Resolution: domain.Resolution{
+			From: domain.ResolutionRef{Ref: "v1.0.0"},
+			To:   domain.ResolutionRef{Ref: "v1.1.0"},
+		},
+		DiffLinks: domain.DiffLinks{VersionDiff: domain.VersionDiffLink{URL: "https://example.com/compare"}},
+		HistoryView: domain.HistoryView{Commits: []domain.Commit{
+			commit("a1", "feat(api): add pagination", 3),
+			commit("a2", "fix: handle empty input", 7),
+			commit("a3", "chore: bump deps", 2),
+			commit("a4", "feat: add export", 1),
+			commit("a5", "ci!: require Go 1.25\n\nBREAKING CHANGE: older toolchains fail", 4),
+			commit("a6", "Update README", 5),
+			commit("a7", "feat(cli): add --quiet", 6),
+			commit("a8", "feat(api): add filters", 6),
+		}},
+	}
+
+	cl := BuildChangelog(report)
+
+	assert.Equal(t, "v1.1.0", cl.Version)
+	assert.Equal(t, "v1.0.0", cl.PreviousVersion)
+	assert.Equal(t, "https://example.com/compare", cl.CompareURL)
+	assert.Equal(t, time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC), cl.Date)
+	assert.Equal(t, 1, cl.Omitted)
+
+	entry := func(hash, typ, scope, subject string) domain.ChangelogEntry {
+		return domain.ChangelogEntry{Type: typ, Scope: scope, Subject: subject, Hash: hash, URL: "https://example.com/commit/" + hash, Author: "Ada"}
+	}
+	breaking := entry("a5", "ci", "", "require Go 1.25")
+	breaking.Breaking, breaking.BreakingNote = true, "older toolchains fail"
+
+	assert.Equal(t, []domain.ChangelogEntry{breaking}, cl.Breaking)
+	assert.Equal(t, []domain.ChangelogSection{
+		{Title: "Added", Scopes: []domain.ChangelogScope{
+			{Name: "api", Entries: []domain.ChangelogEntry{entry("a1", "feat", "api", "add pagination"), entry("a8", "feat", "api", "add filters")}},
+			{Name: "cli", Entries: []domain.ChangelogEntry{entry("a7", "feat", "cli", "add --quiet")}},
+			{Name: "", Entries: []domain.ChangelogEntry{entry("a4", "feat", "", "add export")}},
+		}},
+		{Title: "Fixed", Scopes: []domain.ChangelogScope{
+			{Entries: []domain.ChangelogEntry{entry("a2", "fix", "", "handle empty input")}},
+		}},
+		{Title: "Other", Scopes: []domain.ChangelogScope{
+			{Entries: []domain.ChangelogEntry{breaking, entry("a6", "", "", "Update README")}},
+		}},
+	}, cl.Sections)
+}
+
+func TestBuildChangelog_NoCommits(t *testing.T) {
+	generated := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cl := BuildChangelog(&domain.DiffReport{Metadata: domain.Metadata{GeneratedAt: generated}})
+
+	assert.Equal(t, generated, cl.Date)
+	assert.Empty(t, cl.Sections)
+}
+
+func TestBuildChangelog_PullRequests(t *testing.T) {
+	history := prHistory()
+	report := &domain.DiffReport{HistoryView: domain.HistoryView{
+		Commits:      dropMerges(history),
+		PullRequests: GroupPullRequests(history),
+	}}
+
+	cl := BuildChangelog(report)
+
+	entry := func(pr int, hash, typ, scope, subject, author string) domain.ChangelogEntry {
+		return domain.ChangelogEntry{
+			Type: typ, Scope: scope, Subject: subject, Hash: hash,
+			URL: "https://example.com/commit/" + hash, Author: author, PullRequest: pr,
+		}
+	}
+	assert.Equal(t, 1, cl.Omitted)
+	assert.Equal(t, []domain.ChangelogSection{
+		{Title: "Added", Scopes: []domain.ChangelogScope{
+			{Name: "api", Entries: []domain.ChangelogEntry{entry(12, "m1", "feat", "api", "add x", "Ada")}},
+			{Entries: []domain.ChangelogEntry{entry(7, "g2", "feat", "", "Add y", "Grace")}},
+		}},
+		{Title: "Fixed", Scopes: []domain.ChangelogScope{
+			{Name: "cli", Entries: []domain.ChangelogEntry{entry(15, "s1", "fix", "cli", "handle empty refs", "Grace")}},
+			{Entries: []domain.ChangelogEntry{entry(3, "b1", "fix", "", "z", "Linus")}},
+		}},
+	}, cl.Sections)
+}
+
+func TestBuildChangelog_Reverts(t *testing.T) {
+	commit := func(hash, message string) domain.Commit {
+		return domain.Commit{
+			Hash:    hash,
+			Author:  "Ada",
+			Message: message,
+			DiffURL: "https://example.com/commit/" + hash,
+			Parsed:  ParseCommitMessage(message),
+		}
+	}
+	history := []domain.Commit{
+		commit("1111111aaa", "feat: add export"),
+		commit("2222222aaa", "Revert \"feat: add export\"\n\nThis reverts commit 1111111aaa."),
+		commit("3333333aaa", "revert: drop the flaky cache\n\nThis reverts commit 9999999."),
+		commit("4444444aaa", "fix: handle empty input"),
+		commit("5555555aaa", "Revert \"fix: handle empty input\"\n\nThis reverts commit 4444444."),
+		commit("6666666aaa", "fix: handle empty input again\n\nThis reverts commit 5555555."),
+		commit("7777777aaa", "feat: add import (#7)"),
+		commit("8888888aaa", "Revert \"feat: add import (#7)\" (#8)\n\nThis reverts commit 7777777aaa."),
+	}
+	report := &domain.DiffReport{HistoryView: domain.HistoryView{
+		Commits:      history,
+		PullRequests: GroupPullRequests(history),
+	}}
+
+	cl := BuildChangelog(report)
+
+	entry := func(hash, typ, subject string) domain.ChangelogEntry {
+		return domain.ChangelogEntry{Type: typ, Subject: subject, Hash: hash, URL: "https://example.com/commit/" + hash, Author: "Ada"}
+	}
+	assert.Equal(t, []domain.ChangelogSection{
+		{Title: "Changed", Scopes: []domain.ChangelogScope{
+			{Entries: []domain.ChangelogEntry{entry("3333333aaa", "revert", "drop the flaky cache")}},
+		}},
+		{Title: "Fixed", Scopes: []domain.ChangelogScope{
+			{Entries: []domain.ChangelogEntry{entry("6666666aaa", "fix", "handle empty input again")}},
+		}},
+	}, cl.Sections)
+	assert.Zero(t, cl.Omitted)
+}
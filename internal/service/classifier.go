@@ -0,0 +1,327 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// defaultClassificationRules is the built-in ruleset, applied after any
+// user-supplied rules.
+var defaultClassificationRules = []domain.ClassificationRule{
+	{Extensions: []string{".go"}, Language: "Go"},
+	{Extensions: []string{".py"}, Language: "Python"},
+	{Extensions: []string{".js", ".jsx"}, Language: "JavaScript"},
+	{Extensions: []string{".ts", ".tsx"}, Language: "TypeScript"},
+	{Extensions: []string{".java"}, Language: "Java"},
+	{Extensions: []string{".c", ".h"}, Language: "C"},
+	{Extensions: []string{".cpp", ".cc", ".hpp"}, Language: "C++"},
+	{Extensions: []string{".rs"}, Language: "Rust"},
+	{Extensions: []string{".rb"}, Language: "Ruby"},
+	{Extensions: []string{".php"}, Language: "PHP"},
+	{Extensions: []string{".swift"}, Language: "Swift"},
+	{Extensions: []string{".kt"}, Language: "Kotlin"},
+	{Extensions: []string{".scala"}, Language: "Scala"},
+	{Extensions: []string{".sh", ".bash", ".zsh"}, Language: "Shell"},
+	{Extensions: []string{".sql"}, Language: "SQL"},
+	{Extensions: []string{".md"}, Language: "Markdown"},
+	{Extensions: []string{".json"}, Language: "JSON"},
+	{Extensions: []string{".yaml", ".yml"}, Language: "YAML"},
+	{Extensions: []string{".xml"}, Language: "XML"},
+	{Extensions: []string{".html"}, Language: "HTML"},
+	{Extensions: []string{".css"}, Language: "CSS"},
+	{Extensions: []string{".scss"}, Language: "SCSS"},
+	{Extensions: []string{".sass"}, Language: "Sass"},
+	{Extensions: []string{".proto"}, Language: "Protocol Buffers"},
+
+	{Shebangs: []string{"sh", "bash", "zsh", "dash", "ksh"}, Language: "Shell"},
+	{Shebangs: []string{"python", "python2", "python3"}, Language: "Python"},
+	{Shebangs: []string{"node", "nodejs"}, Language: "JavaScript"},
+	{Shebangs: []string{"deno", "ts-node"}, Language: "TypeScript"},
+	{Shebangs: []string{"ruby"}, Language: "Ruby"},
+	{Shebangs: []string{"php"}, Language: "PHP"},
+
+	{
+		Globs: []string{
+			"vendor/", "node_modules/", "dist/", "build/",
+			"*.pb.go", "*.pb.gw.go", "*_generated.go", "*.gen.go", "*.min.js", "*.min.css",
+		},
+		Class: domain.ClassGenerated,
+	},
+	{
+		Globs: []string{
+			"test/", "tests/", "__tests__/",
+			"*_test.go", "*_test.py", "*.test.js", "*.test.ts", "*.spec.js", "*.spec.ts",
+		},
+		Class: domain.ClassTest,
+	},
+	{
+		Globs: []string{
+			"Makefile", "Dockerfile", ".gitignore", ".dockerignore",
+			"go.mod", "go.sum", "package.json", "package-lock.json", "yarn.lock",
+			"Cargo.toml", "Cargo.lock", "pom.xml", "build.gradle", "CMakeLists.txt",
+			".golangci.yml", ".golangci.yaml",
+			".github/**", ".vscode/**", ".idea/**", "**/*/config/**",
+		},
+		Extensions: []string{".yaml", ".yml", ".toml", ".ini", ".conf", ".config"},
+		Class:      domain.ClassConfig,
+	},
+}
+
+var tagPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ruleClasses are the classes a rule may assign; source and binary are
+// derived rather than assigned.
+var ruleClasses = []string{domain.ClassTest, domain.ClassGenerated, domain.ClassConfig, domain.ClassVendored, domain.ClassDocumentation}
+
+// goGeneratedHeader is the convention from https://go.dev/s/generatedcode,
+// which other code generators follow as well.
+var goGeneratedHeader = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.\r?$`)
+
+// generatedMarkerLines bounds how far into a file an @generated marker is
+// looked for; beyond the header it is more likely prose about generation.
+const generatedMarkerLines = 20
+
+var generatedMarker = regexp.MustCompile(`@generated\b`)
+
+// minifiedExtensions and minifiedLineLength follow GitHub Linguist: a
+// script or stylesheet whose lines average more than 110 characters.
+var minifiedExtensions = []string{".js", ".mjs", ".cjs", ".css"}
+
+const minifiedLineLength = 110
+
+type classifier struct {
+	rules []domain.ClassificationRule
+}
+
+// NewClassifier returns a classifier applying rules ahead of the built-in
+// ruleset. The first matching rule with a language decides the language;
+// classes and tags accumulate over every matching rule.
+func NewClassifier(rules domain.ClassificationRules) (domain.Classifier, error) {
+	var user []domain.ClassificationRule
+	for _, shorthand := range []struct {
+		class string
+		globs []string
+	}{
+		{domain.ClassGenerated, rules.Generated},
+		{domain.ClassTest, rules.Test},
+		{domain.ClassConfig, rules.Config},
+	} {
+		if len(shorthand.globs) > 0 {
+			user = append(user, domain.ClassificationRule{Globs: shorthand.globs, Class: shorthand.class})
+		}
+	}
+	user = append(user, rules.Rules...)
+
+	for i, rule := range user {
+		if err := validateClassificationRule(rule); err != nil {
+			return nil, fmt.Errorf("%w: classification rule %d: %v", domain.ErrInvalidOption, i+1, err)
+		}
+	}
+
+	return &classifier{rules: slices.Concat(user, defaultClassificationRules)}, nil
+}
+
+func validateClassificationRule(rule domain.ClassificationRule) error {
+	if len(rule.Globs) == 0 && len(rule.Extensions) == 0 && len(rule.Shebangs) == 0 {
+		return fmt.Errorf("needs globs, extensions or shebangs")
+	}
+	if rule.Language == "" && rule.Class == "" && len(rule.Tags) == 0 {
+		return fmt.Errorf("needs a language, class or tags")
+	}
+	for _, glob := range rule.Globs {
+		if !doublestar.ValidatePattern(strings.TrimSuffix(glob, "/")) {
+			return fmt.Errorf("invalid glob %q", glob)
+		}
+	}
+	if rule.Class != "" && !slices.Contains(ruleClasses, rule.Class) {
+		return fmt.Errorf("class %q must be one of %s", rule.Class, strings.Join(ruleClasses, ", "))
+	}
+	for _, tag := range rule.Tags {
+		if !tagPattern.MatchString(tag) {
+			return fmt.Errorf("tag %q must be lowercase letters, digits and underscores", tag)
+		}
+	}
+	return nil
+}
+
+func (c *classifier) Classify(file domain.ClassifyInput) domain.FileClass {
+	interpreter := shebangInterpreter(file.Head)
+
+	var class domain.FileClass
+	var generatedPath bool
+	for _, rule := range c.rules {
+		if !matchesRule(rule, file.Path, interpreter) {
+			continue
+		}
+		if class.Language == "" {
+			class.Language = rule.Language
+		}
+		switch rule.Class {
+		case domain.ClassGenerated:
+			generatedPath = true
+		case domain.ClassTest:
+			class.IsTest = true
+		case domain.ClassConfig:
+			class.IsConfig = true
+		case domain.ClassVendored:
+			class.IsVendored = true
+		case domain.ClassDocumentation:
+			class.IsDocumentation = true
+		}
+		for _, tag := range rule.Tags {
+			if !slices.Contains(class.Tags, tag) {
+				class.Tags = append(class.Tags, tag)
+			}
+		}
+	}
+
+	class.GeneratedBy = generatedBy(file, generatedPath)
+	class.IsGenerated = class.GeneratedBy != ""
+	applyLinguistAttributes(&class, file.Attributes)
+	return class
+}
+
+// applyLinguistAttributes lets .gitattributes override the rules the way
+// GitHub Linguist does: linguist-vendored and linguist-documentation set or
+// clear the class, and linguist-language names the language.
+func applyLinguistAttributes(class *domain.FileClass, attributes map[string]string) {
+	for attr, flag := range map[string]*bool{
+		"linguist-vendored":      &class.IsVendored,
+		"linguist-documentation": &class.IsDocumentation,
+	} {
+		switch attributes[attr] {
+		case "true":
+			*flag = true
+		case "false":
+			*flag = false
+		}
+	}
+
+	if language := attributes["linguist-language"]; language != "" && language != "true" && language != "false" {
+		class.Language = language
+	}
+}
+
+// generatedBy returns the signal marking file as generated, or "". An
+// explicit linguist-generated attribute wins either way; otherwise path
+// rules are checked before the content.
+func generatedBy(file domain.ClassifyInput, byPath bool) string {
+	switch file.Attributes["linguist-generated"] {
+	case "true":
+		return domain.GeneratedByAttributes
+	case "false":
+		return ""
+	}
+
+	switch {
+	case byPath:
+		return domain.GeneratedByPath
+	case goGeneratedHeader.Match(file.Head):
+		return domain.GeneratedByHeader
+	case hasGeneratedMarker(file.Head):
+		return domain.GeneratedByMarker
+	case isMinified(file.Path, file.Head):
+		return domain.GeneratedByMinified
+	}
+	return ""
+}
+
+// hasGeneratedMarker looks for "@generated" in a comment near the top of
+// the file.
+func hasGeneratedMarker(head []byte) bool {
+	lines := bytes.SplitN(head, []byte("\n"), generatedMarkerLines+1)
+	for _, line := range lines[:min(len(lines), generatedMarkerLines)] {
+		line = bytes.TrimSpace(line)
+		isComment := false
+		for _, prefix := range []string{"//", "#", "/*", "*", "--", "<!--", ";", "%"} {
+			if bytes.HasPrefix(line, []byte(prefix)) {
+				isComment = true
+				break
+			}
+		}
+		if isComment && generatedMarker.Match(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func isMinified(p string, head []byte) bool {
+	if !slices.Contains(minifiedExtensions, strings.ToLower(path.Ext(p))) || len(head) == 0 {
+		return false
+	}
+	lines := bytes.Count(head, []byte("\n"))
+	if !bytes.HasSuffix(head, []byte("\n")) {
+		lines++
+	}
+	return len(head)/lines > minifiedLineLength
+}
+
+func matchesRule(rule domain.ClassificationRule, p, interpreter string) bool {
+	base := strings.ToLower(path.Base(p))
+	for _, ext := range rule.Extensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if strings.HasSuffix(base, strings.ToLower(ext)) {
+			return true
+		}
+	}
+
+	if interpreter != "" && slices.Contains(rule.Shebangs, interpreter) {
+		return true
+	}
+
+	return matchesPattern(p, rule.Globs)
+}
+
+// matchesPattern extends matchGlob with directory patterns: "dir/" matches
+// everything below that directory at any depth.
+func matchesPattern(p string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+			if doublestar.MatchUnvalidated("**/"+dir+"/**", path.Dir(p)) {
+				return true
+			}
+			continue
+		}
+		if _, ok := matchGlob(p, []string{pattern}); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// shebangInterpreter returns the interpreter named by a "#!" first line,
+// looking through /usr/bin/env.
+func shebangInterpreter(head []byte) string {
+	line, ok := bytes.CutPrefix(head, []byte("#!"))
+	if !ok {
+		return ""
+	}
+	line, _, _ = bytes.Cut(line, []byte("\n"))
+
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	name := path.Base(fields[0])
+	if name == "env" {
+		name = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				name = path.Base(field)
+				break
+			}
+		}
+	}
+	return name
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifier_DefaultRules(t *testing.T) {
+	classifier, err := NewClassifier(domain.ClassificationRules{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		path string
+		head string
+		want domain.FileClass
+	}{
+		{"cmd/main.go", "", domain.FileClass{Language: "Go"}},
+		{"cmd/main_test.go", "", domain.FileClass{Language: "Go", IsTest: true}},
+		{"api/v1/svc.pb.go", "", domain.FileClass{Language: "Go", IsGenerated: true, GeneratedBy: domain.GeneratedByPath}},
+		{"web/dist/app.js", "", domain.FileClass{Language: "JavaScript", IsGenerated: true, GeneratedBy: domain.GeneratedByPath}},
+		{"web/src/app.spec.ts", "", domain.FileClass{Language: "TypeScript", IsTest: true}},
+		{"contest/entry.py", "", domain.FileClass{Language: "Python"}},
+		{"pkg/tests/helpers.py", "", domain.FileClass{Language: "Python", IsTest: true}},
+		{".github/workflows/ci.yml", "", domain.FileClass{Language: "YAML", IsConfig: true}},
+		{"deploy/.github/notes.md", "", domain.FileClass{Language: "Markdown"}},
+		{"services/api/config/db.go", "", domain.FileClass{Language: "Go", IsConfig: true}},
+		{"config/db.go", "", domain.FileClass{Language: "Go"}},
+		{"Makefile", "", domain.FileClass{IsConfig: true}},
+		{"LICENSE", "", domain.FileClass{}},
+		{"IMAGE.PNG.MD", "", domain.FileClass{Language: "Markdown"}},
+
+		{"bin/release", "#!/bin/bash\nset -e\n", domain.FileClass{Language: "Shell"}},
+		{"bin/tool", "#!/usr/bin/env python3\n", domain.FileClass{Language: "Python"}},
+		{"bin/run", "#!/usr/bin/env -S deno run\n", domain.FileClass{Language: "TypeScript"}},
+		{"bin/run.rb", "#!/usr/bin/env python3\n", domain.FileClass{Language: "Ruby"}},
+	}
+
+	for _, tt := range tests {
+		got := classifier.Classify(domain.ClassifyInput{Path: tt.path, Head: []byte(tt.head)})
+		assert.Equal(t, tt.want, got, tt.path)
+	}
+}
+
+func TestClassifier_UserRules(t *testing.T) {
+	classifier, err := NewClassifier(domain.ClassificationRules{
+		Generated: []string{"api/generated/"},
+		Rules: []domain.ClassificationRule{
+			{Globs: []string{"db/migrations/**"}, Tags: []string{"is_migration"}},
+			{Extensions: []string{"proto", ".graphql"}, Tags: []string{"is_api_contract"}},
+			{Globs: []string{"api/**/*.proto"}, Tags: []string{"is_api_contract", "is_public"}},
+			{Extensions: []string{".tpl"}, Language: "Go Template", Class: domain.ClassConfig},
+			{Shebangs: []string{"bun"}, Language: "TypeScript"},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		path string
+		head string
+		want domain.FileClass
+	}{
+		{"db/migrations/0001_init.sql", "", domain.FileClass{Language: "SQL", Tags: []string{"is_migration"}}},
+		{"api/v1/svc.proto", "", domain.FileClass{Language: "Protocol Buffers", Tags: []string{"is_api_contract", "is_public"}}},
+		{"schema/query.graphql", "", domain.FileClass{Tags: []string{"is_api_contract"}}},
+		{"api/generated/client.ts", "", domain.FileClass{Language: "TypeScript", IsGenerated: true, GeneratedBy: domain.GeneratedByPath}},
+		{"charts/values.tpl", "", domain.FileClass{Language: "Go Template", IsConfig: true}},
+		{"scripts/build", "#!/usr/bin/env bun\n", domain.FileClass{Language: "TypeScript"}},
+	}
+
+	for _, tt := range tests {
+		got := classifier.Classify(domain.ClassifyInput{Path: tt.path, Head: []byte(tt.head)})
+		assert.Equal(t, tt.want, got, tt.path)
+	}
+}
+
+func TestClassifier_GeneratedSignals(t *testing.T) {
+	classifier, err := NewClassifier(domain.ClassificationRules{})
+	require.NoError(t, err)
+
+	minified := "!function(){" + strings.Repeat("var a=1;", 40) + "}();\n"
+
+	tests := []struct {
+		name       string
+		path       string
+		head       string
+		attributes map[string]string
+		want       string
+	}{
+		{"go header", "api/client.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n", nil, domain.GeneratedByHeader},
+		{"go header after license", "api/client.go", "// Copyright 2024\n\n// Code generated by mockgen. DO NOT EDIT.\r\npackage api\n", nil, domain.GeneratedByHeader},
+		{"go header not whole line", "api/client.go", "package api\n\nconst doc = \"// Code generated x. DO NOT EDIT.\"\n", nil, ""},
+		{"marker", "schema.sql", "-- @generated by dbtool\ncreate table t ();\n", nil, domain.GeneratedByMarker},
+		{"marker in docblock", "src/Foo.php", "<?php\n/**\n * @generated SignedSource<<abc>>\n */\n", nil, domain.GeneratedByMarker},
+		{"marker outside comment", "src/gen.go", "package gen\n\nconst tag = \"@generated\"\n", nil, ""},
+		{"marker too deep", "src/a.py", strings.Repeat("x = 1\n", generatedMarkerLines) + "# @generated\n", nil, ""},
+		{"marker prefix word", "src/a.py", "# @generatedness is not a marker\n", nil, ""},
+		{"minified js", "web/vendor.bundle.js", minified, nil, domain.GeneratedByMinified},
+		{"minified needs script", "web/notes.md", minified, nil, ""},
+		{"readable js", "web/app.js", "function main() {\n  return 1;\n}\n", nil, ""},
+		{"attribute", "docs/api.md", "# API\n", map[string]string{"linguist-generated": "true"}, domain.GeneratedByAttributes},
+		{"attribute beats content", "api/client.go", "// Code generated by x. DO NOT EDIT.\n", map[string]string{"linguist-generated": "false"}, ""},
+		{"attribute beats path", "dist/app.js", "", map[string]string{"linguist-generated": "false"}, ""},
+		{"path beats content", "dist/app.js", minified, nil, domain.GeneratedByPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifier.Classify(domain.ClassifyInput{Path: tt.path, Head: []byte(tt.head), Attributes: tt.attributes})
+			assert.Equal(t, tt.want, got.GeneratedBy)
+			assert.Equal(t, tt.want != "", got.IsGenerated)
+		})
+	}
+}
+
+func TestClassifier_LinguistAttributes(t *testing.T) {
+	classifier, err := NewClassifier(domain.ClassificationRules{
+		Rules: []domain.ClassificationRule{{Globs: []string{"docs/"}, Class: domain.ClassDocumentation}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		path       string
+		attributes map[string]string
+		want       domain.FileClass
+	}{
+		{"third_party/lib.c", map[string]string{"linguist-vendored": "true"}, domain.FileClass{Language: "C", IsVendored: true}},
+		{"docs/guide.md", nil, domain.FileClass{Language: "Markdown", IsDocumentation: true}},
+		{"docs/guide.md", map[string]string{"linguist-documentation": "false"}, domain.FileClass{Language: "Markdown"}},
+		{"templates/page.html", map[string]string{"linguist-language": "Go Template"}, domain.FileClass{Language: "Go Template"}},
+		{"tools/build", map[string]string{"linguist-language": "Shell"}, domain.FileClass{Language: "Shell"}},
+		{"main.go", map[string]string{"linguist-language": "true"}, domain.FileClass{Language: "Go"}},
+	}
+
+	for _, tt := range tests {
+		got := classifier.Classify(domain.ClassifyInput{Path: tt.path, Attributes: tt.attributes})
+		assert.Equal(t, tt.want, got, "%s %v", tt.path, tt.attributes)
+	}
+}
+
+func TestNewClassifier_RejectsMalformedRules(t *testing.T) {
+	for _, rules := range []domain.ClassificationRules{
+		{Generated: []string{"src/[a-"}},
+		{Rules: []domain.ClassificationRule{{Tags: []string{"is_orphan"}}}},
+		{Rules: []domain.ClassificationRule{{Globs: []string{"*.sql"}}}},
+		{Rules: []domain.ClassificationRule{{Globs: []string{"*.sql"}, Class: domain.ClassBinary}}},
+		{Rules: []domain.ClassificationRule{{Globs: []string{"*.sql"}, Tags: []string{"Is Migration"}}}},
+	} {
+		_, err := NewClassifier(rules)
+		assert.ErrorIs(t, err, domain.ErrInvalidOption, "%+v", rules)
+	}
+}
+
+func TestMatchesPattern(t *testing.T) {
+	tests := []struct {
+		path     string
+		patterns []string
+		want     bool
+	}{
+		{"api/openapi.gen.ts", []string{"*.gen.ts"}, true},
+		{"api/v1/types.go", []string{"api/*/types.go"}, true},
+		{"third_party/lib/a.c", []string{"third_party/"}, true},
+		{"src/third_party/lib/a.c", []string{"third_party/"}, true},
+		{"my_third_party/a.c", []string{"third_party/"}, false},
+		{"scripts/third_party", []string{"third_party/"}, false},
+		{"docs/guide.md", []string{"*.go", "fixtures/"}, false},
+		{"docs/guide.md", nil, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchesPattern(tt.path, tt.patterns), "%s %v", tt.path, tt.patterns)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// conventionalHeader matches "type(scope)!: subject".
+var conventionalHeader = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?(!)?: +(\S.*)$`)
+
+// trailerLine matches a git trailer or Conventional Commits footer: a
+// token followed by ": " or " #". "BREAKING CHANGE" is the one token
+// allowed to contain a space.
+var trailerLine = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z0-9][A-Za-z0-9-]*)(?:: *(.*)| (#.*))$`)
+
+// ParseCommitMessage splits message into its Conventional Commits header,
+// body and trailers. A commit is breaking when its header carries "!" or a
+// BREAKING CHANGE (or BREAKING-CHANGE) trailer is present.
+func ParseCommitMessage(message string) domain.CommitMessage {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	header, rest, _ := strings.Cut(message, "\n")
+
+	var parsed domain.CommitMessage
+	if m := conventionalHeader.FindStringSubmatch(strings.TrimSpace(header)); m != nil {
+		parsed.Type = strings.ToLower(m[1])
+		parsed.Scope = strings.TrimSpace(m[2])
+		parsed.Breaking = m[3] == "!"
+		parsed.Subject = strings.TrimSpace(m[4])
+	} else {
+		parsed.Subject = strings.TrimSpace(header)
+	}
+
+	body := strings.TrimSpace(rest)
+	paragraphs := strings.Split(body, "\n\n")
+	if trailers, ok := parseTrailers(paragraphs[len(paragraphs)-1]); ok {
+		parsed.Trailers = trailers
+		body = strings.TrimSpace(strings.Join(paragraphs[:len(paragraphs)-1], "\n\n"))
+	}
+	parsed.Body = body
+
+	for _, t := range parsed.Trailers {
+		if t.Key == "BREAKING CHANGE" || strings.EqualFold(t.Key, "BREAKING-CHANGE") {
+			parsed.Breaking = true
+		}
+	}
+	return parsed
+}
+
+// parseTrailers reads paragraph as a trailer block. Like git, every line
+// must be a trailer or an indented continuation of the one before it.
+func parseTrailers(paragraph string) ([]domain.Trailer, bool) {
+	if paragraph == "" {
+		return nil, false
+	}
+
+	var trailers []domain.Trailer
+	for _, line := range strings.Split(paragraph, "\n") {
+		if n := len(trailers); n > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			trailers[n-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		m := trailerLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, false
+		}
+		trailers = append(trailers, domain.Trailer{Key: m[1], Value: strings.TrimSpace(m[2] + m[3])})
+	}
+	return trailers, true
+}
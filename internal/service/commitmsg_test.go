@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommitMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    domain.CommitMessage
+	}{
+		{
+			name:    "plain subject",
+			message: "Update README\n",
+			want:    domain.CommitMessage{Subject: "Update README"},
+		},
+		{
+			name:    "type and scope",
+			message: "feat(api): add pagination",
+			want:    domain.CommitMessage{Type: "feat", Scope: "api", Subject: "add pagination"},
+		},
+		{
+			name:    "breaking marker, type normalised",
+			message: "Fix!: drop the v1 endpoint",
+			want:    domain.CommitMessage{Type: "fix", Subject: "drop the v1 endpoint", Breaking: true},
+		},
+		{
+			name:    "merge commit is not conventional",
+			message: "Merge branch 'feature/x' into main",
+			want:    domain.CommitMessage{Subject: "Merge branch 'feature/x' into main"},
+		},
+		{
+			name: "body and trailers",
+			message: "fix(parser): handle CRLF\r\n\r\nWindows checkouts broke the header match.\r\n\r\nSecond paragraph.\r\n\r\n" +
+				"Signed-off-by: Ada <ada@example.com>\r\nCo-authored-by: Bob <bob@example.com>\r\nReviewed-by: Eve <eve@example.com>\r\nRefs: #42\r\n",
+			want: domain.CommitMessage{
+				Type:    "fix",
+				Scope:   "parser",
+				Subject: "handle CRLF",
+				Body:    "Windows checkouts broke the header match.\n\nSecond paragraph.",
+				Trailers: []domain.Trailer{
+					{Key: "Signed-off-by", Value: "Ada <ada@example.com>"},
+					{Key: "Co-authored-by", Value: "Bob <bob@example.com>"},
+					{Key: "Reviewed-by", Value: "Eve <eve@example.com>"},
+					{Key: "Refs", Value: "#42"},
+				},
+			},
+		},
+		{
+			name:    "breaking change footer with continuation and hash separator",
+			message: "refactor: rename config keys\n\nBREAKING CHANGE: `repo` is now `repo_path`\n  and `to` is required.\nCloses #7",
+			want: domain.CommitMessage{
+				Type:     "refactor",
+				Subject:  "rename config keys",
+				Breaking: true,
+				Trailers: []domain.Trailer{
+					{Key: "BREAKING CHANGE", Value: "`repo` is now `repo_path` and `to` is required."},
+					{Key: "Closes", Value: "#7"},
+				},
+			},
+		},
+		{
+			name:    "BREAKING-CHANGE is a synonym",
+			message: "chore: bump\n\nBREAKING-CHANGE: needs Go 1.25",
+			want: domain.CommitMessage{
+				Type: "chore", Subject: "bump", Breaking: true,
+				Trailers: []domain.Trailer{{Key: "BREAKING-CHANGE", Value: "needs Go 1.25"}},
+			},
+		},
+		{
+			name:    "last paragraph with prose is body",
+			message: "docs: explain\n\nSee the guide.\nSigned-off-by: Ada <ada@example.com>",
+			want: domain.CommitMessage{
+				Type: "docs", Subject: "explain",
+				Body: "See the guide.\nSigned-off-by: Ada <ada@example.com>",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseCommitMessage(tt.message))
+		})
+	}
+}
@@ -2,6 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/NERVEbing/supervisor/internal/domain"
@@ -22,6 +27,32 @@ func NewDiffService(repo domain.Repository, filter domain.Filter, rule domain.Fi
 }
 
 func (s *DiffService) GenerateReport(ctx context.Context, fromRef, toRef string, opts domain.RequestOptions) (*domain.DiffReport, error) {
+	var issues *IssueExtractor
+	if len(opts.IssueProjects) > 0 {
+		extractor, err := NewIssueExtractor(opts.IssueProjects)
+		if err != nil {
+			return nil, err
+		}
+		issues = extractor
+	}
+
+	if p := opts.Patches; p.ContextLines < 0 || p.MaxFileBytes < 0 || p.MaxTotalBytes < 0 {
+		return nil, fmt.Errorf("%w: patch context and byte limits must not be negative", domain.ErrInvalidOption)
+	}
+
+	if opts.HistoryOrder == "" {
+		opts.HistoryOrder = domain.HistoryOrderAuthorDate
+	}
+	if !slices.Contains(domain.HistoryOrders, opts.HistoryOrder) {
+		return nil, fmt.Errorf("%w: history order %q (available: %s)", domain.ErrInvalidOption, opts.HistoryOrder, strings.Join(domain.HistoryOrders, ", "))
+	}
+	historyOpts := domain.HistoryOptions{
+		MergeCommitsIncluded: !opts.IgnoreMergeCommits || opts.FirstParent || opts.NestMerges,
+		FirstParent:          opts.FirstParent || opts.NestMerges,
+		Order:                opts.HistoryOrder,
+		NestedMerges:         opts.NestMerges,
+	}
+
 	// 1. Resolve Refs
 	fromHash, fromType, err := s.repo.ResolveRef(ctx, fromRef)
 	if err != nil {
@@ -33,32 +64,50 @@ func (s *DiffService) GenerateReport(ctx context.Context, fromRef, toRef string,
 	}
 
 	// 2. Calculate Baseline
-	baseHash, strategy, isLinear, err := s.repo.CalculateBaseline(ctx, fromHash, toHash)
+	strategy := opts.BaselineStrategy
+	if strategy == "" {
+		strategy = domain.BaselineMergeBase
+	}
+	opts.BaselineStrategy = strategy
+
+	baseHash, isLinear, err := s.repo.CalculateBaseline(ctx, fromHash, toHash, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Calculate Diff (Raw) against the baseline, not the from commit
+	rawChanges, rawStats, err := s.repo.CalculateDiff(ctx, baseHash, toHash, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Calculate Diff (Raw)
-	rawChanges, rawStats, err := s.repo.CalculateDiff(ctx, fromHash, toHash)
+	// 4. Filter and Process Changes, honouring the repository's own ignore file
+	filter, ignorePatterns, err := s.targetFilter(ctx, toHash, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	// 4. Filter and Process Changes
 	var filteredChanges []domain.FileChange
+	var excluded []domain.ExcludedFile
+	if opts.ExplainFilters {
+		excluded = []domain.ExcludedFile{}
+	}
 	filesFilteredOut := 0
 
 	summaryFiles := domain.FileStats{}
 	summaryLines := domain.SummaryLineStats{}
 
 	for _, change := range rawChanges {
-		path := change.Path.After
-		if path == "" {
-			path = change.Path.Before
-		}
-
-		if s.filter.ShouldExclude(path) {
+		if match, ok := filter.Match(change); ok {
 			filesFilteredOut++
+			if opts.ExplainFilters {
+				excluded = append(excluded, domain.ExcludedFile{
+					Path:       change.Path,
+					ChangeType: change.ChangeType,
+					Lines:      change.Lines,
+					Match:      match,
+				})
+			}
 			continue
 		}
 
@@ -73,15 +122,27 @@ func (s *DiffService) GenerateReport(ctx context.Context, fromRef, toRef string,
 			summaryFiles.Deleted++
 		case "renamed":
 			summaryFiles.Renamed++
+		case "copied":
+			summaryFiles.Copied++
 		}
 
 		summaryLines.Added += change.Lines.Added
 		summaryLines.Deleted += change.Lines.Deleted
 	}
 	summaryLines.Net = summaryLines.Added - summaryLines.Deleted
+	byClass, byLanguage := breakdowns(filteredChanges)
+
+	var patches *domain.PatchSummary
+	if opts.Patches.Include {
+		patches = applyPatchBudget(filteredChanges, opts.Patches)
+	}
 
 	// 5. Get History
-	history, err := s.repo.GetHistory(ctx, baseHash, toHash, opts.IgnoreMergeCommits)
+	// Merges are always fetched so that pull requests merged with a merge
+	// commit can be recognised, then dropped if they were not asked for.
+	fetchOpts := historyOpts
+	fetchOpts.MergeCommitsIncluded = true
+	history, err := s.repo.GetHistory(ctx, baseHash, toHash, fetchOpts)
 	if err != nil {
 		// As per original logic, we might want to return empty history on error,
 		// but typically in domain service we should return error unless it's non-critical.
@@ -93,10 +154,32 @@ func (s *DiffService) GenerateReport(ctx context.Context, fromRef, toRef string,
 		// Given instructions "Strict Go Style Guide", returning error is better.
 		return nil, err
 	}
+	// The first-parent chain holds the merges but not the commits they
+	// brought in, so pull requests are grouped over the whole range.
+	prHistory := history
+	if historyOpts.FirstParent && !historyOpts.NestedMerges {
+		prHistory, err = s.repo.GetHistory(ctx, baseHash, toHash, domain.HistoryOptions{
+			MergeCommitsIncluded: true,
+			Order:                historyOpts.Order,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	pullRequests := GroupPullRequests(prHistory)
+	for i := range pullRequests {
+		pullRequests[i].URL = s.repo.GetPullRequestURL(pullRequests[i].Number)
+	}
+	attachRelatedCommits(filteredChanges, domain.FlattenCommits(history), historyOpts.MergeCommitsIncluded)
+	if !historyOpts.MergeCommitsIncluded {
+		history = dropMerges(history)
+	}
+	s.linkFiles(filteredChanges, baseHash, toHash)
+	parseMessages(history)
 
 	// 6. Assemble Report
 	report := &domain.DiffReport{
-		SchemaVersion: "1.0",
+		SchemaVersion: domain.ReportSchemaVersion,
 		Repository: domain.RepoInfo{
 			Name: s.repo.GetRepoName(),
 			URL:  s.repo.GetRepoURL(),
@@ -109,11 +192,15 @@ func (s *DiffService) GenerateReport(ctx context.Context, fromRef, toRef string,
 			Filters: domain.RequestFilters{
 				ExcludeSuffixes: s.filterRule.ExcludeSuffixes,
 				ExcludePaths:    s.filterRule.ExcludePaths,
+				IncludeGlobs:    s.filterRule.IncludeGlobs,
+				ExcludeGlobs:    s.filterRule.ExcludeGlobs,
+				ExcludeRegexes:  s.filterRule.ExcludeRegexes,
+				IgnorePatterns:  s.filterRule.IgnorePatterns,
 			},
 		},
 		Resolution: domain.Resolution{
-			From: domain.ResolutionRef{Ref: fromRef, Type: fromType, Commit: fromHash},
-			To:   domain.ResolutionRef{Ref: toRef, Type: toType, Commit: toHash},
+			From: s.resolutionRef(fromRef, fromType, fromHash),
+			To:   s.resolutionRef(toRef, toType, toHash),
 		},
 		Baseline: domain.Baseline{
 			Strategy:   strategy,
@@ -126,25 +213,29 @@ func (s *DiffService) GenerateReport(ctx context.Context, fromRef, toRef string,
 		Filters: domain.ReportFilters{
 			SuffixExcluded:      s.filterRule.ExcludeSuffixes,
 			PathExcluded:        s.filterRule.ExcludePaths,
+			IgnoreFilePatterns:  ignorePatterns,
+			Excluded:            excluded,
 			BinaryFilesDetected: rawStats.BinaryFilesDetected,
 			FilesFilteredOut:    filesFilteredOut,
 		},
 		TreeDiff: domain.TreeDiff{
 			Summary: domain.DiffSummary{
-				Files: summaryFiles,
-				Lines: summaryLines,
+				Files:            summaryFiles,
+				Lines:            summaryLines,
+				ByClassification: byClass,
+				ByLanguage:       byLanguage,
+				Patches:          patches,
 			},
 			Files: filteredChanges,
 		},
 		HistoryView: domain.HistoryView{
-			Options: domain.HistoryOptions{
-				MergeCommitsIncluded: !opts.IgnoreMergeCommits,
-			},
+			Options: historyOpts,
 			CommitRange: domain.CommitRange{
 				From: baseHash,
 				To:   toHash,
 			},
-			Commits: history,
+			Commits:      history,
+			PullRequests: pullRequests,
 		},
 		DiffLinks: domain.DiffLinks{
 			VersionDiff: domain.VersionDiffLink{
@@ -168,9 +259,69 @@ func (s *DiffService) GenerateReport(ctx context.Context, fromRef, toRef string,
 		},
 	}
 
+	// 7. Extract Issue References
+	report.Issues = []domain.IssueReference{}
+	if issues != nil {
+		report.Issues = issues.Extract(report)
+	}
+
 	return report, nil
 }
 
+// targetFilter extends the configured filter with the rules committed in
+// domain.IgnoreFileName at the target commit, returning those rules too.
+func (s *DiffService) targetFilter(ctx context.Context, toHash string, opts domain.RequestOptions) (domain.Filter, []string, error) {
+	if opts.SkipIgnoreFile {
+		return s.filter, []string{}, nil
+	}
+
+	data, err := s.repo.ReadFile(ctx, toHash, domain.IgnoreFileName)
+	if errors.Is(err, domain.ErrFileNotFound) {
+		return s.filter, []string{}, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", domain.IgnoreFileName, err)
+	}
+
+	patterns := []string{}
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimRight(line, " \t\r\n")
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	if len(patterns) == 0 {
+		return s.filter, patterns, nil
+	}
+
+	ignore, err := NewFilterService(domain.FilterRule{IgnorePatterns: patterns})
+	if err != nil {
+		return nil, nil, err
+	}
+	return anyFilter{s.filter, ruleFilter{filter: ignore, rule: domain.FilterRuleIgnoreFile}}, patterns, nil
+}
+
+func (s *DiffService) resolutionRef(ref, refType, hash string) domain.ResolutionRef {
+	r := domain.ResolutionRef{Ref: ref, Type: refType, Commit: hash}
+	if refType == "tag" {
+		r.URL = s.repo.GetTagURL(ref)
+	}
+	return r
+}
+
+// linkFiles links every file on the forge: at the target commit, or at the
+// baseline for deleted files.
+func (s *DiffService) linkFiles(files []domain.FileChange, baseHash, toHash string) {
+	for i := range files {
+		f := &files[i]
+		if f.ChangeType == "deleted" {
+			f.URL = s.repo.GetBlobURL(baseHash, f.Path.Before, firstChangedLine(f.Patch, true))
+		} else {
+			f.URL = s.repo.GetBlobURL(toHash, f.Path.After, firstChangedLine(f.Patch, false))
+		}
+	}
+}
+
 func determineRelationship(isLinear bool) string {
 	if isLinear {
 		return "linear"
@@ -184,3 +335,110 @@ func getHistoryNote(commits []domain.Commit) string {
 	}
 	return ""
 }
+
+func dropMerges(commits []domain.Commit) []domain.Commit {
+	kept := make([]domain.Commit, 0, len(commits))
+	for _, c := range commits {
+		if len(c.Parents) < 2 {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// parseMessages fills in Commit.Parsed, nested merged commits included.
+func parseMessages(commits []domain.Commit) {
+	for i := range commits {
+		commits[i].Parsed = ParseCommitMessage(commits[i].Message)
+		parseMessages(commits[i].Merged)
+	}
+}
+
+// attachRelatedCommits records, for every file in the tree diff, the commits
+// in history that touched it, in history order and without merges unless
+// merges is set. Whatever the history order, commits are walked children
+// first so that a rename seen in a commit extends the set of names its
+// ancestors may use; history must hold the merges for that.
+func attachRelatedCommits(files []domain.FileChange, history []domain.Commit, merges bool) {
+	walk := topoSortCommits(history)
+	for i := range files {
+		names := map[string]bool{}
+		if files[i].Path.After != "" {
+			names[files[i].Path.After] = true
+		}
+		if files[i].Path.Before != "" {
+			names[files[i].Path.Before] = true
+		}
+
+		touched := map[string]bool{}
+		for j := len(walk) - 1; j >= 0; j-- {
+			for _, p := range walk[j].Files {
+				if !names[p.After] && !names[p.Before] {
+					continue
+				}
+				touched[walk[j].Hash] = true
+				if p.Before != "" {
+					names[p.Before] = true
+				}
+			}
+		}
+
+		related := []string{}
+		for _, c := range history {
+			if touched[c.Hash] && (merges || len(c.Parents) < 2) {
+				related = append(related, c.Hash)
+			}
+		}
+		files[i].History.RelatedCommits = related
+	}
+}
+
+// topoSortCommits lists commits parents first, walking depth first from the
+// most recently committed one, first parents before the others, like the
+// git adapter orders the history for HistoryOrderTopo.
+func topoSortCommits(commits []domain.Commit) []domain.Commit {
+	byHash := make(map[string]*domain.Commit, len(commits))
+	for i := range commits {
+		byHash[commits[i].Hash] = &commits[i]
+	}
+	starts := make([]*domain.Commit, len(commits))
+	for i := range commits {
+		starts[i] = &commits[i]
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		wi, wj := starts[i].CommittedAt, starts[j].CommittedAt
+		if !wi.Equal(wj) {
+			return wi.After(wj)
+		}
+		return starts[i].Hash < starts[j].Hash
+	})
+
+	type frame struct {
+		commit *domain.Commit
+		next   int
+	}
+	sorted := make([]domain.Commit, 0, len(commits))
+	visited := make(map[string]bool, len(commits))
+	for _, start := range starts {
+		if visited[start.Hash] {
+			continue
+		}
+		visited[start.Hash] = true
+		stack := []frame{{commit: start}}
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if top.next < len(top.commit.Parents) {
+				p := top.commit.Parents[top.next]
+				top.next++
+				if parent, ok := byHash[p]; ok && !visited[p] {
+					visited[p] = true
+					stack = append(stack, frame{commit: parent})
+				}
+				continue
+			}
+			sorted = append(sorted, *top.commit)
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return sorted
+}
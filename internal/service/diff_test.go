@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	gitadapter "github.com/NERVEbing/supervisor/internal/adapter/git"
+	"github.com/NERVEbing/supervisor/internal/adapter/git/gittest"
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAdapter opens r through the git adapter, as the CLI does.
+func newAdapter(t *testing.T, r *gittest.Repo, opts ...gitadapter.Option) domain.Repository {
+	t.Helper()
+	repo, err := gitadapter.NewAdapter(r.Path, opts...)
+	require.NoError(t, err)
+	return repo
+}
+
+func generateReport(t *testing.T, repo domain.Repository, from, to, strategy string) (*domain.DiffReport, error) {
+	t.Helper()
+
+	return generateReportWithOptions(t, repo, from, to, domain.RequestOptions{
+		IgnoreMergeCommits: true,
+		BaselineStrategy:   strategy,
+	}, domain.FilterRule{})
+}
+
+func generateReportWithOptions(t *testing.T, repo domain.Repository, from, to string, opts domain.RequestOptions, rule domain.FilterRule) (*domain.DiffReport, error) {
+	t.Helper()
+
+	filter, err := NewFilterService(rule)
+	require.NoError(t, err)
+
+	return NewDiffService(repo, filter, rule).GenerateReport(context.Background(), from, to, opts)
+}
+
+func changedPaths(files []domain.FileChange) map[string]string {
+	out := make(map[string]string, len(files))
+	for _, f := range files {
+		p := f.Path.After
+		if p == "" {
+			p = f.Path.Before
+		}
+		out[p] = f.ChangeType
+	}
+	return out
+}
+
+func findChange(t *testing.T, files []domain.FileChange, path string) domain.FileChange {
+	t.Helper()
+	for _, f := range files {
+		if f.Path.After == path || (f.Path.After == "" && f.Path.Before == path) {
+			return f
+		}
+	}
+	require.Failf(t, "change not found", "no change for %s", path)
+	return domain.FileChange{}
+}
+
+func commitMessages(commits []domain.Commit) []string {
+	out := make([]string, len(commits))
+	for i, c := range commits {
+		out[i] = c.Message
+	}
+	sort.Strings(out)
+	return out
+}
+
+// inOrder lists commit messages as returned, nesting merged commits as
+// "M[F1 F2]".
+func inOrder(commits []domain.Commit) []string {
+	out := make([]string, len(commits))
+	for i, c := range commits {
+		out[i] = c.Message
+		if len(c.Merged) > 0 {
+			out[i] += fmt.Sprint(inOrder(c.Merged))
+		}
+	}
+	return out
+}
+
+func hashes(hs ...plumbing.Hash) []string {
+	out := make([]string, len(hs))
+	for i, h := range hs {
+		out[i] = h.String()
+	}
+	return out
+}
+
+func numberedLines(prefix string, n int) string {
+	var b strings.Builder
+	for i := range n {
+		b.WriteString(prefix)
+		b.WriteString(strings.Repeat("x", i%7))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestGenerateReport_MergeBaseIgnoresFromSideChanges(t *testing.T) {
+	r := gittest.NewReadmeTopology(t)
+
+	report, err := generateReport(t, newAdapter(t, r.Repo), "v1.0.0", "v1.1.0", domain.BaselineMergeBase)
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.BaselineMergeBase, report.Baseline.Strategy)
+	assert.Equal(t, r.B.String(), report.Baseline.BaseCommit)
+	assert.False(t, report.Baseline.Ancestry.IsLinear)
+	assert.Equal(t, "branched", report.Baseline.Ancestry.Relationship)
+
+	assert.Equal(t, map[string]string{
+		"feature.go":      "added",
+		"core.go":         "modified",
+		"docs/feature.md": "added",
+	}, changedPaths(report.TreeDiff.Files))
+	assert.Equal(t, 2, report.TreeDiff.Summary.Files.Added)
+	assert.Equal(t, 1, report.TreeDiff.Summary.Files.Modified)
+	assert.Equal(t, 0, report.TreeDiff.Summary.Files.Deleted)
+
+	assert.Equal(t, []string{"C: add feature", "D: bump core", "E: document feature"}, commitMessages(report.HistoryView.Commits))
+}
+
+func TestGenerateReport_DefaultStrategyIsMergeBase(t *testing.T) {
+	r := gittest.NewReadmeTopology(t)
+
+	report, err := generateReport(t, newAdapter(t, r.Repo), "main", "feature", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.BaselineMergeBase, report.Baseline.Strategy)
+	assert.Equal(t, domain.BaselineMergeBase, report.Request.Options.BaselineStrategy)
+	assert.Equal(t, r.B.String(), report.Baseline.BaseCommit)
+}
+
+func TestGenerateReport_TwoDotDiffsEndpointsDirectly(t *testing.T) {
+	r := gittest.NewReadmeTopology(t)
+
+	report, err := generateReport(t, newAdapter(t, r.Repo), "v1.0.0", "v1.1.0", domain.BaselineTwoDot)
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.BaselineTwoDot, report.Baseline.Strategy)
+	assert.Equal(t, r.G.String(), report.Baseline.BaseCommit)
+	assert.False(t, report.Baseline.Ancestry.IsLinear)
+
+	assert.Equal(t, map[string]string{
+		"feature.go":      "added",
+		"core.go":         "modified",
+		"docs/feature.md": "added",
+		"hotfix.go":       "deleted",
+		"README.md":       "modified",
+	}, changedPaths(report.TreeDiff.Files))
+}
+
+func TestGenerateReport_DirectRejectsBranchedHistory(t *testing.T) {
+	r := gittest.NewReadmeTopology(t)
+
+	_, err := generateReport(t, newAdapter(t, r.Repo), "v1.0.0", "v1.1.0", domain.BaselineDirect)
+	assert.ErrorIs(t, err, domain.ErrNotAncestor)
+}
+
+func TestGenerateReport_DirectOnLinearHistory(t *testing.T) {
+	r := gittest.NewReadmeTopology(t)
+
+	report, err := generateReport(t, newAdapter(t, r.Repo), r.C.String(), "v1.1.0", domain.BaselineDirect)
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.BaselineDirect, report.Baseline.Strategy)
+	assert.Equal(t, r.C.String(), report.Baseline.BaseCommit)
+	assert.True(t, report.Baseline.Ancestry.IsLinear)
+	assert.Equal(t, "linear", report.Baseline.Ancestry.Relationship)
+	assert.Equal(t, map[string]string{
+		"core.go":         "modified",
+		"docs/feature.md": "added",
+	}, changedPaths(report.TreeDiff.Files))
+}
+
+func TestGenerateReport_UnknownStrategy(t *testing.T) {
+	r := gittest.NewReadmeTopology(t)
+
+	_, err := generateReport(t, newAdapter(t, r.Repo), "v1.0.0", "v1.1.0", "octopus")
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+}
+
+func TestGenerateReport_HonoursIgnoreFileAtTargetRef(t *testing.T) {
+	r := gittest.New(t)
+
+	base := map[string]string{"main.go": "package main\n"}
+	from := r.Commit("initial", gittest.With(base, domain.IgnoreFileName, "*.snap\n"))
+	to := r.Commit("change", gittest.With(base,
+		domain.IgnoreFileName, "# generated fixtures\nfixtures/**\n!fixtures/keep.json\n",
+		"fixtures/big.json", "{}\n",
+		"fixtures/keep.json", "{}\n",
+		"ui/view.snap", "snapshot\n",
+		"ui/view.go", "package ui\n",
+	), from)
+
+	report, err := generateReportWithOptions(t, newAdapter(t, r), from.String(), to.String(), domain.RequestOptions{}, domain.FilterRule{})
+	require.NoError(t, err)
+
+	// Rules come from the target ref only: *.snap from the old file no longer applies.
+	assert.Equal(t, map[string]string{
+		domain.IgnoreFileName: "modified",
+		"fixtures/keep.json":  "added",
+		"ui/view.snap":        "added",
+		"ui/view.go":          "added",
+	}, changedPaths(report.TreeDiff.Files))
+	assert.Equal(t, []string{"fixtures/**", "!fixtures/keep.json"}, report.Filters.IgnoreFilePatterns)
+	assert.Equal(t, 1, report.Filters.FilesFilteredOut)
+	assert.Nil(t, report.Filters.Excluded, "exclusions are only listed with ExplainFilters")
+
+	report, err = generateReportWithOptions(t, newAdapter(t, r), from.String(), to.String(), domain.RequestOptions{SkipIgnoreFile: true}, domain.FilterRule{})
+	require.NoError(t, err)
+	assert.Len(t, report.TreeDiff.Files, 5)
+	assert.Empty(t, report.Filters.IgnoreFilePatterns)
+}
+
+func TestGenerateReport_ExplainFilters(t *testing.T) {
+	r := gittest.New(t)
+
+	base := map[string]string{"main.go": "package main\n"}
+	from := r.Commit("initial", gittest.With(base, "logo.png", "png", "vendor/lib.go", "package lib\n"))
+	to := r.Commit("change", gittest.With(base,
+		domain.IgnoreFileName, "*.snap\n",
+		"vendor/lib.go", "",
+		"logo.png", "png2",
+		"ui/view.snap", "one\ntwo\n",
+		"ui/view.go", "package ui\n",
+	), from)
+
+	rule := domain.FilterRule{ExcludeSuffixes: []string{".png"}, ExcludePaths: []string{"vendor/"}}
+	report, err := generateReportWithOptions(t, newAdapter(t, r), from.String(), to.String(), domain.RequestOptions{ExplainFilters: true}, rule)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Filters.FilesFilteredOut)
+	assert.ElementsMatch(t, []domain.ExcludedFile{
+		{
+			Path:       domain.FilePath{Before: "logo.png", After: "logo.png"},
+			ChangeType: "modified",
+			Match:      domain.FilterMatch{Rule: domain.FilterRuleSuffix, Pattern: ".png"},
+		},
+		{
+			Path:       domain.FilePath{Before: "vendor/lib.go"},
+			ChangeType: "deleted",
+			Lines:      domain.FileLineStats{Deleted: 1},
+			Match:      domain.FilterMatch{Rule: domain.FilterRulePath, Pattern: "vendor/"},
+		},
+		{
+			Path:       domain.FilePath{After: "ui/view.snap"},
+			ChangeType: "added",
+			Lines:      domain.FileLineStats{Added: 2},
+			Match:      domain.FilterMatch{Rule: domain.FilterRuleIgnoreFile, Pattern: "*.snap"},
+		},
+	}, report.Filters.Excluded)
+}
+
+func TestGenerateReport_RelatedCommitsOnBranchedHistory(t *testing.T) {
+	r := gittest.NewReadmeTopology(t)
+
+	report, err := generateReport(t, newAdapter(t, r.Repo), "v1.0.0", "v1.1.0", domain.BaselineMergeBase)
+	require.NoError(t, err)
+
+	assert.Equal(t, hashes(r.C), findChange(t, report.TreeDiff.Files, "feature.go").History.RelatedCommits)
+	assert.Equal(t, hashes(r.D), findChange(t, report.TreeDiff.Files, "core.go").History.RelatedCommits)
+	assert.Equal(t, hashes(r.E), findChange(t, report.TreeDiff.Files, "docs/feature.md").History.RelatedCommits)
+}
+
+func TestGenerateReport_RelatedCommitsFollowRenames(t *testing.T) {
+	r := gittest.New(t)
+
+	body := numberedLines("body ", 20)
+	base := r.Commit("base", map[string]string{"a.go": body, "other.go": "package other\n"})
+	edit := r.Commit("edit a", map[string]string{"a.go": body + "edit\n", "other.go": "package other\n"}, base)
+	move := r.Commit("move a to b", map[string]string{"b.go": body + "edit\n", "other.go": "package other\n"}, edit)
+	touch := r.Commit("touch b", map[string]string{"b.go": body + "edit\nagain\n", "other.go": "package other\n"}, move)
+	unrelated := r.Commit("touch other", map[string]string{"b.go": body + "edit\nagain\n", "other.go": "package other2\n"}, touch)
+
+	for _, detect := range []bool{true, false} {
+		report, err := generateReportWithOptions(t, newAdapter(t, r), base.String(), unrelated.String(), domain.RequestOptions{
+			IgnoreMergeCommits: true,
+			DetectRenames:      detect,
+		}, domain.FilterRule{})
+		require.NoError(t, err)
+
+		assert.Equal(t, hashes(edit, move, touch), findChange(t, report.TreeDiff.Files, "b.go").History.RelatedCommits)
+		assert.Equal(t, hashes(unrelated), findChange(t, report.TreeDiff.Files, "other.go").History.RelatedCommits)
+		if !detect {
+			assert.Equal(t, hashes(edit, move), findChange(t, report.TreeDiff.Files, "a.go").History.RelatedCommits)
+		}
+	}
+}
+
+func TestGenerateReport_RelatedCommitsFollowRenamesOutOfDateOrder(t *testing.T) {
+	r := gittest.New(t)
+
+	body := numberedLines("body ", 20)
+	base := r.Commit("base", map[string]string{"a.go": body})
+	edit := r.Commit("edit a", map[string]string{"a.go": body + "edit\n"}, base)
+	// Authored before the edit it was rebased onto, so the author-date
+	// history lists it first.
+	move := r.Backdated("move a to b", map[string]string{"b.go": body + "edit\n"}, 2*time.Hour, edit)
+	touch := r.Commit("touch b", map[string]string{"b.go": body + "edit\nagain\n"}, move)
+
+	// Without rename detection in the tree diff, only the history links
+	// b.go to the edit made under its old name.
+	report, err := generateReportWithOptions(t, newAdapter(t, r), base.String(), touch.String(), domain.RequestOptions{
+		IgnoreMergeCommits: true,
+	}, domain.FilterRule{})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"move a to b", "edit a", "touch b"}, inOrder(report.HistoryView.Commits))
+	assert.Equal(t, hashes(move, edit, touch), findChange(t, report.TreeDiff.Files, "b.go").History.RelatedCommits)
+}
+
+func TestGenerateReport_HistoryOptions(t *testing.T) {
+	r, a, n := gittest.NewOrderTopology(t)
+
+	report, err := generateReportWithOptions(t, newAdapter(t, r), a.String(), n.String(), domain.RequestOptions{
+		IgnoreMergeCommits: true,
+		HistoryOrder:       domain.HistoryOrderTopo,
+		NestMerges:         true,
+	}, domain.FilterRule{})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.HistoryOptions{
+		MergeCommitsIncluded: true,
+		FirstParent:          true,
+		Order:                domain.HistoryOrderTopo,
+		NestedMerges:         true,
+	}, report.HistoryView.Options)
+	assert.Equal(t, []string{"B", "M[F1 F2]", "N"}, inOrder(report.HistoryView.Commits))
+	assert.Equal(t, "F1", report.HistoryView.Commits[1].Merged[0].Parsed.Subject)
+
+	_, err = generateReportWithOptions(t, newAdapter(t, r), a.String(), n.String(), domain.RequestOptions{
+		HistoryOrder: "date",
+	}, domain.FilterRule{})
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+}
+
+func TestGenerateReport_PullRequestsWithoutMerges(t *testing.T) {
+	r := gittest.New(t)
+
+	a := r.Commit("A", map[string]string{"a": "1"})
+	f := r.Commit("feat: add f", map[string]string{"a": "1", "f": "1"}, a)
+	b := r.Commit("fix: b (#4)", map[string]string{"a": "1", "b": "1"}, a)
+	m := r.Commit("Merge pull request #5 from ada/f\n\nAdd f", map[string]string{"a": "1", "b": "1", "f": "1"}, b, f)
+
+	report, err := generateReportWithOptions(t, newAdapter(t, r), a.String(), m.String(), domain.RequestOptions{
+		IgnoreMergeCommits: true,
+	}, domain.FilterRule{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"feat: add f", "fix: b (#4)"}, commitMessages(report.HistoryView.Commits))
+	prs := report.HistoryView.PullRequests
+	require.Len(t, prs, 2)
+	assert.Equal(t, 4, prs[0].Number)
+	assert.Equal(t, domain.PullRequestSquash, prs[0].Style)
+	assert.Equal(t, 5, prs[1].Number)
+	assert.Equal(t, m.String(), prs[1].MergeCommit)
+	assert.Equal(t, hashes(f), prs[1].Commits)
+}
+
+func TestGenerateReport_FirstParentPullRequests(t *testing.T) {
+	r := gittest.New(t)
+
+	a := r.Commit("A", map[string]string{"a": "1"})
+	f1 := r.Commit("feat: add f", map[string]string{"a": "1", "f": "1"}, a)
+	f2 := r.Commit("test: cover f", map[string]string{"a": "1", "f": "1", "f_test": "1"}, f1)
+	b := r.Commit("B", map[string]string{"a": "1", "b": "1"}, a)
+	m := r.Commit("Merge pull request #5 from ada/f\n\nAdd f", map[string]string{"a": "1", "b": "1", "f": "1", "f_test": "1"}, b, f2)
+	n := r.Commit("fix: n (#6)", map[string]string{"a": "1", "b": "1", "f": "1", "f_test": "1", "n": "1"}, m)
+
+	report, err := generateReportWithOptions(t, newAdapter(t, r), a.String(), n.String(), domain.RequestOptions{
+		IgnoreMergeCommits: true,
+		FirstParent:        true,
+	}, domain.FilterRule{})
+	require.NoError(t, err)
+
+	assert.True(t, report.HistoryView.Options.MergeCommitsIncluded)
+	assert.Equal(t, []string{"B", "Merge pull request #5 from ada/f\n\nAdd f", "fix: n (#6)"}, inOrder(report.HistoryView.Commits))
+
+	prs := report.HistoryView.PullRequests
+	require.Len(t, prs, 2)
+	assert.Equal(t, 5, prs[0].Number)
+	assert.Equal(t, m.String(), prs[0].MergeCommit)
+	assert.Equal(t, hashes(f1, f2), prs[0].Commits)
+	assert.Equal(t, 6, prs[1].Number)
+	assert.Equal(t, hashes(n), prs[1].Commits)
+}
+
+func TestGenerateReport_Links(t *testing.T) {
+	r := gittest.New(t)
+	_, err := r.Repository.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"git@git.example.com:group/widget.git"}})
+	require.NoError(t, err)
+	a := r.Commit("init", map[string]string{"main.go": "a\nb\nc\n", "old.go": "x\ny\n"})
+	_, err = r.Repository.CreateTag("v1.0.0", a, nil)
+	require.NoError(t, err)
+	b := r.Commit("fix: keep c (#9)", map[string]string{"main.go": "a\nb\nC\n"}, a)
+
+	adapter := newAdapter(t, r, gitadapter.WithForgeHosts(map[string]string{"git.example.com": domain.ForgeGitLab}))
+	report, err := generateReportWithOptions(t, adapter, "v1.0.0", b.String(),
+		domain.RequestOptions{Patches: domain.PatchOptions{Include: true, ContextLines: 3}}, domain.FilterRule{})
+	require.NoError(t, err)
+
+	repo := "https://git.example.com/group/widget"
+	assert.Equal(t, repo+"/-/tags/v1.0.0", report.Resolution.From.URL)
+	assert.Empty(t, report.Resolution.To.URL, "commits have no page of their own")
+
+	urls := map[string]string{}
+	for _, f := range report.TreeDiff.Files {
+		urls[f.ChangeType] = f.URL
+	}
+	assert.Equal(t, map[string]string{
+		"modified": repo + "/-/blob/" + b.String() + "/main.go#L3",
+		"deleted":  repo + "/-/blob/" + a.String() + "/old.go#L1",
+	}, urls)
+
+	require.Len(t, report.HistoryView.PullRequests, 1)
+	assert.Equal(t, repo+"/-/merge_requests/9", report.HistoryView.PullRequests[0].URL)
+}
+
+func TestGenerateReport_IncludePatches(t *testing.T) {
+	r := gittest.New(t)
+
+	from := r.Commit("initial", map[string]string{
+		"main.go":  "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n",
+		"logo.png": "png",
+	})
+	to := r.Commit("change", map[string]string{
+		"main.go":  "package main\n\nfunc a() {}\n\nfunc b() { return }\n\nfunc c() {}\n",
+		"logo.png": "png2",
+	}, from)
+
+	opts := domain.RequestOptions{Patches: domain.PatchOptions{Include: true, ContextLines: 1}}
+	report, err := generateReportWithOptions(t, newAdapter(t, r), from.String(), to.String(), opts, domain.FilterRule{})
+	require.NoError(t, err)
+
+	main := findChange(t, report.TreeDiff.Files, "main.go")
+	require.NotNil(t, main.Patch)
+	assert.Equal(t, []domain.PatchHunk{
+		{Header: "@@ -4,3 +4,3 @@ func a() {}", Lines: " \n-func b() {}\n+func b() { return }\n \n"},
+	}, main.Patch.Hunks)
+	assert.False(t, main.Patch.Truncated)
+
+	assert.Nil(t, findChange(t, report.TreeDiff.Files, "logo.png").Patch, "binary files carry no patch")
+	assert.Equal(t, &domain.PatchSummary{Bytes: main.Patch.Hunks[0].Size()}, report.TreeDiff.Summary.Patches)
+}
+
+func TestGenerateReport_PatchesOffByDefault(t *testing.T) {
+	r := gittest.New(t)
+
+	from := r.Commit("initial", map[string]string{"main.go": "package main\n"})
+	to := r.Commit("change", map[string]string{"main.go": "package app\n"}, from)
+
+	report, err := generateReportWithOptions(t, newAdapter(t, r), from.String(), to.String(), domain.RequestOptions{}, domain.FilterRule{})
+	require.NoError(t, err)
+
+	assert.Nil(t, findChange(t, report.TreeDiff.Files, "main.go").Patch)
+	assert.Nil(t, report.TreeDiff.Summary.Patches)
+}
+
+func TestGenerateReport_CountsRenamesAndCopies(t *testing.T) {
+	r := gittest.New(t)
+
+	template := numberedLines("tmpl ", 30)
+	from := r.Commit("initial", map[string]string{"a.go": template, "b.go": "package b\n"})
+	to := r.Commit("reshuffle", map[string]string{
+		"a.go":      template + "// tweak\n",
+		"a_copy.go": template,
+		"c.go":      "package b\n",
+	}, from)
+
+	rule := domain.FilterRule{}
+	report, err := generateReportWithOptions(t, newAdapter(t, r), from.String(), to.String(), domain.RequestOptions{
+		DetectRenames: true,
+		DetectCopies:  true,
+	}, rule)
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.FileStats{Modified: 1, Renamed: 1, Copied: 1}, report.TreeDiff.Summary.Files)
+}
@@ -1,33 +1,176 @@
 package service
 
 import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/go-git/go-git/v6/plumbing/format/gitignore"
 )
 
 type filterService struct {
-	rule domain.FilterRule
+	rule    domain.FilterRule
+	regexes []*regexp.Regexp
+	ignore  []ignorePattern
 }
 
-func NewFilterService(rule domain.FilterRule) domain.Filter {
-	return &filterService{
-		rule: rule,
+type ignorePattern struct {
+	line    string
+	pattern gitignore.Pattern
+}
+
+// NewFilterService compiles rule, failing on malformed globs or regexes so
+// that a typo cannot silently let files through.
+func NewFilterService(rule domain.FilterRule) (domain.Filter, error) {
+	for _, glob := range slices.Concat(rule.IncludeGlobs, rule.ExcludeGlobs) {
+		if !doublestar.ValidatePattern(glob) {
+			return nil, fmt.Errorf("%w: invalid glob %q", domain.ErrInvalidOption, glob)
+		}
+	}
+
+	for _, class := range slices.Concat(rule.ExcludeClasses, rule.OnlyClasses) {
+		if !slices.Contains(domain.Classes, class) {
+			return nil, fmt.Errorf("%w: unknown file class %q (available: %s)", domain.ErrInvalidOption, class, strings.Join(domain.Classes, ", "))
+		}
+	}
+
+	regexes := make([]*regexp.Regexp, 0, len(rule.ExcludeRegexes))
+	for _, expr := range rule.ExcludeRegexes {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid regex %q: %v", domain.ErrInvalidOption, expr, err)
+		}
+		regexes = append(regexes, re)
 	}
+
+	return &filterService{
+		rule:    rule,
+		regexes: regexes,
+		ignore:  parseIgnorePatterns(rule.IgnorePatterns),
+	}, nil
 }
 
-func (f *filterService) ShouldExclude(path string) bool {
+func (f *filterService) Match(change domain.FileChange) (domain.FilterMatch, bool) {
+	path := change.Path.After
+	if path == "" {
+		path = change.Path.Before
+	}
+
+	if len(f.rule.IncludeGlobs) > 0 {
+		if _, ok := matchGlob(path, f.rule.IncludeGlobs); !ok {
+			return domain.FilterMatch{Rule: domain.FilterRuleInclude, Pattern: strings.Join(f.rule.IncludeGlobs, ", ")}, true
+		}
+	}
+
 	for _, suffix := range f.rule.ExcludeSuffixes {
 		if strings.HasSuffix(path, suffix) {
-			return true
+			return domain.FilterMatch{Rule: domain.FilterRuleSuffix, Pattern: suffix}, true
 		}
 	}
 
 	for _, prefix := range f.rule.ExcludePaths {
 		if strings.HasPrefix(path, prefix) {
-			return true
+			return domain.FilterMatch{Rule: domain.FilterRulePath, Pattern: prefix}, true
 		}
 	}
 
-	return false
+	if glob, ok := matchGlob(path, f.rule.ExcludeGlobs); ok {
+		return domain.FilterMatch{Rule: domain.FilterRuleGlob, Pattern: glob}, true
+	}
+
+	for _, re := range f.regexes {
+		if re.MatchString(path) {
+			return domain.FilterMatch{Rule: domain.FilterRuleRegex, Pattern: re.String()}, true
+		}
+	}
+
+	if line, ok := matchIgnore(path, f.ignore); ok {
+		return domain.FilterMatch{Rule: domain.FilterRuleIgnore, Pattern: line}, true
+	}
+
+	for _, class := range f.rule.ExcludeClasses {
+		if change.Classification.Has(class) {
+			return domain.FilterMatch{Rule: domain.FilterRuleClass, Pattern: class}, true
+		}
+	}
+
+	if len(f.rule.OnlyClasses) > 0 && !slices.ContainsFunc(f.rule.OnlyClasses, change.Classification.Has) {
+		return domain.FilterMatch{Rule: domain.FilterRuleOnlyClass, Pattern: strings.Join(f.rule.OnlyClasses, ", ")}, true
+	}
+
+	return domain.FilterMatch{}, false
+}
+
+// matchGlob returns the first doublestar glob that p matches. A glob
+// without a "/" is also tried against the base name, as in .gitignore.
+func matchGlob(p string, globs []string) (string, bool) {
+	for _, glob := range globs {
+		if doublestar.MatchUnvalidated(glob, p) {
+			return glob, true
+		}
+		if !strings.Contains(glob, "/") && doublestar.MatchUnvalidated(glob, path.Base(p)) {
+			return glob, true
+		}
+	}
+	return "", false
+}
+
+// matchIgnore applies gitignore semantics: the last pattern that matches
+// decides, so a later "!pattern" re-includes the path.
+func matchIgnore(p string, patterns []ignorePattern) (string, bool) {
+	parts := strings.Split(p, "/")
+	for i := len(patterns) - 1; i >= 0; i-- {
+		switch patterns[i].pattern.Match(parts, false) {
+		case gitignore.Exclude:
+			return patterns[i].line, true
+		case gitignore.Include:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// parseIgnorePatterns parses gitignore lines, skipping blanks and comments.
+func parseIgnorePatterns(lines []string) []ignorePattern {
+	patterns := make([]ignorePattern, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, ignorePattern{line: line, pattern: gitignore.ParsePattern(line, nil)})
+	}
+	return patterns
+}
+
+// anyFilter excludes a change when any of its filters does, reporting the
+// first match.
+type anyFilter []domain.Filter
+
+func (f anyFilter) Match(change domain.FileChange) (domain.FilterMatch, bool) {
+	for _, filter := range f {
+		if match, ok := filter.Match(change); ok {
+			return match, true
+		}
+	}
+	return domain.FilterMatch{}, false
+}
+
+// ruleFilter reports every match of its filter under a fixed rule kind.
+type ruleFilter struct {
+	filter domain.Filter
+	rule   string
+}
+
+func (f ruleFilter) Match(change domain.FileChange) (domain.FilterMatch, bool) {
+	match, ok := f.filter.Match(change)
+	if ok {
+		match.Rule = f.rule
+	}
+	return match, ok
 }
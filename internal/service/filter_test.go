@@ -0,0 +1,131 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterService_Match(t *testing.T) {
+	tests := []struct {
+		name string
+		rule domain.FilterRule
+		path string
+		want bool
+	}{
+		{"suffix", domain.FilterRule{ExcludeSuffixes: []string{".png"}}, "img/logo.png", true},
+		{"path prefix", domain.FilterRule{ExcludePaths: []string{"vendor/"}}, "vendor/lib/a.go", true},
+		{"no rules", domain.FilterRule{}, "main.go", false},
+
+		{"doublestar dir", domain.FilterRule{ExcludeGlobs: []string{"**/testdata/**"}}, "pkg/parser/testdata/in.txt", true},
+		{"doublestar root dir", domain.FilterRule{ExcludeGlobs: []string{"**/testdata/**"}}, "testdata/in.txt", true},
+		{"base name glob", domain.FilterRule{ExcludeGlobs: []string{"*.generated.*"}}, "api/client.generated.ts", true},
+		{"anchored glob", domain.FilterRule{ExcludeGlobs: []string{"docs/*.md"}}, "pkg/docs/a.md", false},
+		{"brace glob", domain.FilterRule{ExcludeGlobs: []string{"**/*.{pb,gen}.go"}}, "api/v1/svc.pb.go", true},
+
+		{"regex", domain.FilterRule{ExcludeRegexes: []string{`^migrations/\d+_.*\.sql$`}}, "migrations/0042_add.sql", true},
+		{"regex miss", domain.FilterRule{ExcludeRegexes: []string{`^migrations/\d+_.*\.sql$`}}, "migrations/README.md", false},
+
+		{"include keeps match", domain.FilterRule{IncludeGlobs: []string{"src/**"}}, "src/app/main.go", false},
+		{"include drops others", domain.FilterRule{IncludeGlobs: []string{"src/**"}}, "docs/index.md", true},
+		{"exclude beats include", domain.FilterRule{IncludeGlobs: []string{"src/**"}, ExcludeGlobs: []string{"**/*_test.go"}}, "src/app/main_test.go", true},
+
+		{"ignore dir", domain.FilterRule{IgnorePatterns: []string{"build/"}}, "web/build/app.js", true},
+		{"ignore negation", domain.FilterRule{IgnorePatterns: []string{"*.lock", "!Cargo.lock"}}, "Cargo.lock", false},
+		{"ignore before negation", domain.FilterRule{IgnorePatterns: []string{"*.lock", "!Cargo.lock"}}, "yarn.lock", true},
+		{"ignore anchored", domain.FilterRule{IgnorePatterns: []string{"/gen"}}, "pkg/gen/a.go", false},
+		{"ignore comments", domain.FilterRule{IgnorePatterns: []string{"# *.go", ""}}, "main.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewFilterService(tt.rule)
+			require.NoError(t, err)
+			_, excluded := filter.Match(domain.FileChange{Path: domain.FilePath{After: tt.path}})
+			assert.Equal(t, tt.want, excluded)
+		})
+	}
+}
+
+func TestFilterService_MatchReportsRule(t *testing.T) {
+	filter, err := NewFilterService(domain.FilterRule{
+		IncludeGlobs:    []string{"src/**", "docs/**"},
+		ExcludeSuffixes: []string{".png"},
+		ExcludePaths:    []string{"src/vendor/"},
+		ExcludeGlobs:    []string{"**/testdata/**"},
+		ExcludeRegexes:  []string{`\.min\.js$`},
+		IgnorePatterns:  []string{"*.lock", "!keep.lock"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		change domain.FileChange
+		want   domain.FilterMatch
+	}{
+		{domain.FileChange{Path: domain.FilePath{After: "cmd/main.go"}}, domain.FilterMatch{Rule: domain.FilterRuleInclude, Pattern: "src/**, docs/**"}},
+		{domain.FileChange{Path: domain.FilePath{After: "docs/logo.png"}}, domain.FilterMatch{Rule: domain.FilterRuleSuffix, Pattern: ".png"}},
+		{domain.FileChange{Path: domain.FilePath{Before: "src/vendor/a.go"}}, domain.FilterMatch{Rule: domain.FilterRulePath, Pattern: "src/vendor/"}},
+		{domain.FileChange{Path: domain.FilePath{After: "src/x/testdata/in.txt"}}, domain.FilterMatch{Rule: domain.FilterRuleGlob, Pattern: "**/testdata/**"}},
+		{domain.FileChange{Path: domain.FilePath{After: "src/app.min.js"}}, domain.FilterMatch{Rule: domain.FilterRuleRegex, Pattern: `\.min\.js$`}},
+		{domain.FileChange{Path: domain.FilePath{After: "src/deps.lock"}}, domain.FilterMatch{Rule: domain.FilterRuleIgnore, Pattern: "*.lock"}},
+	}
+
+	for _, tt := range tests {
+		match, ok := filter.Match(tt.change)
+		assert.True(t, ok, "%+v", tt.change.Path)
+		assert.Equal(t, tt.want, match, "%+v", tt.change.Path)
+	}
+
+	_, ok := filter.Match(domain.FileChange{Path: domain.FilePath{After: "src/keep.lock"}})
+	assert.False(t, ok)
+}
+
+func TestFilterService_MatchClasses(t *testing.T) {
+	source := domain.FileChange{Path: domain.FilePath{After: "main.go"}}
+	test := domain.FileChange{Path: domain.FilePath{After: "main_test.go"}, Classification: domain.Classification{IsTest: true}}
+	generated := domain.FileChange{Path: domain.FilePath{After: "api.pb.go"}, Classification: domain.Classification{IsGenerated: true}}
+
+	tests := []struct {
+		name   string
+		rule   domain.FilterRule
+		change domain.FileChange
+		want   bool
+	}{
+		{"exclude generated", domain.FilterRule{ExcludeClasses: []string{domain.ClassGenerated}}, generated, true},
+		{"exclude generated keeps source", domain.FilterRule{ExcludeClasses: []string{domain.ClassGenerated}}, source, false},
+		{"exclude source", domain.FilterRule{ExcludeClasses: []string{domain.ClassSource}}, source, true},
+		{"exclude source keeps tests", domain.FilterRule{ExcludeClasses: []string{domain.ClassSource}}, test, false},
+		{"only tests", domain.FilterRule{OnlyClasses: []string{domain.ClassTest}}, test, false},
+		{"only tests drops source", domain.FilterRule{OnlyClasses: []string{domain.ClassTest}}, source, true},
+		{"only several", domain.FilterRule{OnlyClasses: []string{domain.ClassTest, domain.ClassGenerated}}, generated, false},
+		{"exclude beats only", domain.FilterRule{OnlyClasses: []string{domain.ClassTest}, ExcludeClasses: []string{domain.ClassTest}}, test, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewFilterService(tt.rule)
+			require.NoError(t, err)
+			match, excluded := filter.Match(tt.change)
+			assert.Equal(t, tt.want, excluded)
+			if excluded && len(tt.rule.ExcludeClasses) > 0 {
+				assert.Equal(t, domain.FilterRuleClass, match.Rule)
+			}
+		})
+	}
+}
+
+func TestNewFilterService_RejectsMalformedRules(t *testing.T) {
+	for _, rule := range []domain.FilterRule{
+		{ExcludeGlobs: []string{"src/[a-"}},
+		{IncludeGlobs: []string{"{a,b"}},
+		{ExcludeRegexes: []string{"(unclosed"}},
+		{ExcludeClasses: []string{"tests"}},
+		{OnlyClasses: []string{"vendor"}},
+	} {
+		_, err := NewFilterService(rule)
+		assert.ErrorIs(t, err, domain.ErrInvalidOption, "%+v", rule)
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// IssueExtractor finds issue keys such as ABC-123 in commit messages and
+// branch names. It works purely on the report and never touches the network.
+type IssueExtractor struct {
+	pattern *regexp.Regexp
+}
+
+// NewIssueExtractor builds an extractor for the given project-key patterns.
+// Each pattern is a regular expression matching the project part of a key,
+// case-sensitively as trackers write keys in upper case.
+func NewIssueExtractor(projects []string) (*IssueExtractor, error) {
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("%w: no issue project keys configured", domain.ErrInvalidOption)
+	}
+
+	alternatives := make([]string, len(projects))
+	for i, p := range projects {
+		if _, err := regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("%w: invalid issue project pattern %q: %s", domain.ErrInvalidOption, p, err)
+		}
+		alternatives[i] = "(?:" + p + ")"
+	}
+
+	pattern, err := regexp.Compile(`(?:^|[^A-Za-z0-9])((?:` + strings.Join(alternatives, "|") + `)-[1-9][0-9]*)(?:[^0-9]|$)`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid issue project patterns: %s", domain.ErrInvalidOption, err)
+	}
+
+	return &IssueExtractor{pattern: pattern}, nil
+}
+
+// Keys returns the distinct issue keys in text in order of first
+// appearance.
+func (e *IssueExtractor) Keys(text string) []string {
+	var keys []string
+	// Matches consume one boundary character on each side, so scan with
+	// overlapping windows to catch adjacent keys like "ABC-1,ABC-2".
+	for offset := 0; offset < len(text); {
+		loc := e.pattern.FindStringSubmatchIndex(text[offset:])
+		if loc == nil {
+			break
+		}
+		key := text[offset+loc[2] : offset+loc[3]]
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+		offset += loc[3]
+	}
+	return keys
+}
+
+// Extract lists every issue referenced by the report's commits or branch
+// refs, along with the files those commits touched, sorted by key.
+func (e *IssueExtractor) Extract(report *domain.DiffReport) []domain.IssueReference {
+	byKey := map[string]*domain.IssueReference{}
+	get := func(key string) *domain.IssueReference {
+		ref, ok := byKey[key]
+		if !ok {
+			ref = &domain.IssueReference{
+				Key:      key,
+				Commits:  []string{},
+				Branches: []string{},
+				Files:    []string{},
+				Warnings: []domain.IssueWarning{},
+			}
+			byKey[key] = ref
+		}
+		return ref
+	}
+
+	for _, r := range []domain.ResolutionRef{report.Resolution.From, report.Resolution.To} {
+		if r.Type != "branch" {
+			continue
+		}
+		for _, key := range e.Keys(r.Ref) {
+			ref := get(key)
+			if !slices.Contains(ref.Branches, r.Ref) {
+				ref.Branches = append(ref.Branches, r.Ref)
+			}
+		}
+	}
+
+	commitKeys := map[string][]string{}
+	for _, c := range domain.FlattenCommits(report.HistoryView.Commits) {
+		keys := e.Keys(c.Message)
+		commitKeys[c.Hash] = keys
+		for _, key := range keys {
+			ref := get(key)
+			ref.Commits = append(ref.Commits, c.Hash)
+		}
+	}
+
+	for _, f := range report.TreeDiff.Files {
+		path := f.Path.After
+		if path == "" {
+			path = f.Path.Before
+		}
+		for _, hash := range f.History.RelatedCommits {
+			for _, key := range commitKeys[hash] {
+				ref := get(key)
+				if !slices.Contains(ref.Files, path) {
+					ref.Files = append(ref.Files, path)
+				}
+			}
+		}
+	}
+
+	issues := make([]domain.IssueReference, 0, len(byKey))
+	for _, ref := range byKey {
+		slices.Sort(ref.Files)
+		issues = append(issues, *ref)
+	}
+	slices.SortFunc(issues, func(a, b domain.IssueReference) int {
+		return compareIssueKeys(a.Key, b.Key)
+	})
+	return issues
+}
+
+// IssueEnricher attaches tracker data to extracted issue references and
+// flags commits whose references cannot be right.
+type IssueEnricher struct {
+	tracker domain.IssueTracker
+}
+
+func NewIssueEnricher(tracker domain.IssueTracker) *IssueEnricher {
+	return &IssueEnricher{tracker: tracker}
+}
+
+// Enrich fetches every issue in report.Issues. Missing issues and issues
+// resolved before a referencing commit landed on the target produce
+// warnings rather than errors; any other tracker failure aborts enrichment.
+// Run it after pull request enrichment so that forge merge times are used.
+func (e *IssueEnricher) Enrich(ctx context.Context, report *domain.DiffReport) error {
+	landed := landingTimes(report)
+
+	for i := range report.Issues {
+		ref := &report.Issues[i]
+		if ref.Warnings == nil {
+			ref.Warnings = []domain.IssueWarning{}
+		}
+
+		issue, err := e.tracker.GetIssue(ctx, ref.Key)
+		if errors.Is(err, domain.ErrIssueNotFound) {
+			for _, hash := range ref.Commits {
+				ref.Warnings = append(ref.Warnings, domain.IssueWarning{Commit: hash, Reason: domain.IssueWarningNotFound})
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		ref.Details = issue
+
+		if !issue.Closed || issue.ResolvedAt == nil {
+			continue
+		}
+		for _, hash := range ref.Commits {
+			if landed[hash].After(*issue.ResolvedAt) {
+				ref.Warnings = append(ref.Warnings, domain.IssueWarning{Commit: hash, Reason: domain.IssueWarningClosedBeforeMerge})
+			}
+		}
+	}
+
+	return nil
+}
+
+// landingTimes maps every commit of the history to when it landed on the
+// target: when the earliest pull request that brought it in was merged, or
+// else the committer date of the target commit. Without forge details, a
+// request was merged when its merge or squash commit was committed.
+func landingTimes(report *domain.DiffReport) map[string]time.Time {
+	commits := domain.FlattenCommits(report.HistoryView.Commits)
+	committed := make(map[string]time.Time, len(commits))
+	var target time.Time
+	for _, c := range commits {
+		committed[c.Hash] = c.CommittedAt
+		// The target commit may be filtered out of the history; no commit
+		// of the range was committed after it.
+		if c.CommittedAt.After(target) {
+			target = c.CommittedAt
+		}
+	}
+	if at, ok := committed[report.Resolution.To.Commit]; ok {
+		target = at
+	}
+
+	landed := make(map[string]time.Time, len(commits))
+	for _, c := range commits {
+		landed[c.Hash] = target
+	}
+	for _, pr := range report.HistoryView.PullRequests {
+		merged, ok := committed[pr.MergeCommit]
+		if pr.Details != nil && pr.Details.MergedAt != nil {
+			merged, ok = *pr.Details.MergedAt, true
+		}
+		if !ok {
+			continue
+		}
+		for _, hash := range pr.Commits {
+			if merged.Before(landed[hash]) {
+				landed[hash] = merged
+			}
+		}
+	}
+	return landed
+}
+
+// compareIssueKeys orders keys by project, then numerically by issue number.
+func compareIssueKeys(a, b string) int {
+	pa, na, _ := strings.Cut(a, "-")
+	pb, nb, _ := strings.Cut(b, "-")
+	if c := strings.Compare(pa, pb); c != 0 {
+		return c
+	}
+	if len(na) != len(nb) {
+		return len(na) - len(nb)
+	}
+	return strings.Compare(na, nb)
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueExtractor_Keys(t *testing.T) {
+	e, err := NewIssueExtractor([]string{"ABC", "OPS[0-9]?"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"ABC-123: fix login", []string{"ABC-123"}},
+		{"feature/ABC-42-new-flow", []string{"ABC-42"}},
+		{"abc-42 and Abc-43 are not keys", nil},
+		{"ABC-1,ABC-2 and ABC-1 again", []string{"ABC-1", "ABC-2"}},
+		{"OPS2-7 hotfix (OPS-8)", []string{"OPS2-7", "OPS-8"}},
+		{"XABC-1 and ABC-0 and ABC-12x", []string{"ABC-12"}},
+		{"SHA-256 and UTF-8 are not issues", nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, e.Keys(tt.text), tt.text)
+	}
+}
+
+func TestNewIssueExtractor_RejectsBadInput(t *testing.T) {
+	_, err := NewIssueExtractor(nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+
+	_, err = NewIssueExtractor([]string{"AB("})
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+}
+
+func TestIssueExtractor_Extract(t *testing.T) {
+	e, err := NewIssueExtractor([]string{"ABC"})
+	require.NoError(t, err)
+
+	report := &domain.DiffReport{
+		Resolution: domain.Resolution{
+			From: domain.ResolutionRef{Ref: "main", Type: "branch"},
+			To:   domain.ResolutionRef{Ref: "feature/ABC-7-search", Type: "branch"},
+		},
+		HistoryView: domain.HistoryView{Commits: []domain.Commit{
+			{Hash: "c1", Message: "ABC-10: add index"},
+			{Hash: "c2", Message: "Refine search (ABC-7, ABC-10)"},
+			{Hash: "c3", Message: "chore: bump deps"},
+		}},
+		TreeDiff: domain.TreeDiff{Files: []domain.FileChange{
+			{Path: domain.FilePath{After: "search/index.go"}, History: domain.FileHistory{RelatedCommits: []string{"c1", "c2"}}},
+			{Path: domain.FilePath{Before: "search/old.go"}, History: domain.FileHistory{RelatedCommits: []string{"c2"}}},
+			{Path: domain.FilePath{After: "go.sum"}, History: domain.FileHistory{RelatedCommits: []string{"c3"}}},
+		}},
+	}
+
+	assert.Equal(t, []domain.IssueReference{
+		{
+			Key:      "ABC-7",
+			Commits:  []string{"c2"},
+			Branches: []string{"feature/ABC-7-search"},
+			Files:    []string{"search/index.go", "search/old.go"},
+			Warnings: []domain.IssueWarning{},
+		},
+		{
+			Key:      "ABC-10",
+			Commits:  []string{"c1", "c2"},
+			Branches: []string{},
+			Files:    []string{"search/index.go", "search/old.go"},
+			Warnings: []domain.IssueWarning{},
+		},
+	}, e.Extract(report))
+}
+
+type fakeTracker map[string]*domain.TrackedIssue
+
+func (f fakeTracker) GetIssue(ctx context.Context, key string) (*domain.TrackedIssue, error) {
+	issue, ok := f[key]
+	if !ok {
+		return nil, domain.ErrIssueNotFound
+	}
+	return issue, nil
+}
+
+func TestIssueEnricher_Enrich(t *testing.T) {
+	resolved := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tracker := fakeTracker{
+		"ABC-1": {Key: "ABC-1", Summary: "Open work", Status: "In Progress"},
+		"ABC-2": {Key: "ABC-2", Summary: "Shipped", Status: "Done", Closed: true, ResolvedAt: &resolved},
+	}
+
+	// Every commit was written before ABC-2 was resolved; only "late"
+	// landed on the target after it.
+	merged := resolved.Add(-2 * time.Hour)
+	report := &domain.DiffReport{
+		Resolution: domain.Resolution{To: domain.ResolutionRef{Commit: "tip"}},
+		HistoryView: domain.HistoryView{
+			Commits: []domain.Commit{
+				{Hash: "early", Date: resolved.Add(-3 * time.Hour), CommittedAt: resolved.Add(-3 * time.Hour)},
+				{Hash: "merge", Date: resolved.Add(-time.Hour), CommittedAt: resolved.Add(-time.Hour), Merged: []domain.Commit{
+					{Hash: "reviewed", Date: resolved.Add(-3 * time.Hour), CommittedAt: resolved.Add(-3 * time.Hour)},
+				}},
+				{Hash: "late", Date: resolved.Add(-3 * time.Hour), CommittedAt: resolved.Add(time.Hour)},
+				{Hash: "tip", Date: resolved.Add(time.Hour), CommittedAt: resolved.Add(2 * time.Hour)},
+			},
+			PullRequests: []domain.PullRequest{
+				{Number: 1, MergeCommit: "early", Commits: []string{"early"}},
+				{Number: 2, MergeCommit: "merge", Commits: []string{"reviewed"}, Details: &domain.ForgePullRequest{MergedAt: &merged}},
+			},
+		},
+		Issues: []domain.IssueReference{
+			{Key: "ABC-1", Commits: []string{"early", "late"}},
+			{Key: "ABC-2", Commits: []string{"early", "reviewed", "late"}},
+			{Key: "ABC-3", Commits: []string{"late"}},
+		},
+	}
+
+	require.NoError(t, NewIssueEnricher(tracker).Enrich(context.Background(), report))
+
+	assert.Equal(t, tracker["ABC-1"], report.Issues[0].Details)
+	assert.Empty(t, report.Issues[0].Warnings)
+
+	assert.Equal(t, tracker["ABC-2"], report.Issues[1].Details)
+	assert.Equal(t, []domain.IssueWarning{{Commit: "late", Reason: domain.IssueWarningClosedBeforeMerge}}, report.Issues[1].Warnings)
+
+	assert.Nil(t, report.Issues[2].Details)
+	assert.Equal(t, []domain.IssueWarning{{Commit: "late", Reason: domain.IssueWarningNotFound}}, report.Issues[2].Warnings)
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// RepoReportFunc generates the diff report for a single manifest entry.
+type RepoReportFunc func(ctx context.Context, repo domain.ManifestRepo) (*domain.DiffReport, error)
+
+type MultiDiffService struct {
+	generate RepoReportFunc
+	parallel int
+}
+
+// NewMultiDiffService runs generate for each manifest entry, at most
+// parallel at a time. A parallel value below one means one.
+func NewMultiDiffService(generate RepoReportFunc, parallel int) *MultiDiffService {
+	if parallel < 1 {
+		parallel = 1
+	}
+	return &MultiDiffService{
+		generate: generate,
+		parallel: parallel,
+	}
+}
+
+// GenerateReport diffs every repository in the manifest. A repository that
+// fails does not abort the others; its error is recorded in its section and
+// counted in the summary. Sections keep the manifest order.
+func (s *MultiDiffService) GenerateReport(ctx context.Context, manifest *domain.Manifest) *domain.MultiDiffReport {
+	sections := make([]domain.RepoSection, len(manifest.Repos))
+	sem := make(chan struct{}, s.parallel)
+	var wg sync.WaitGroup
+
+	for i, repo := range manifest.Repos {
+		sections[i] = domain.RepoSection{
+			Name: repo.Name,
+			Path: repo.Path,
+			From: repo.From,
+			To:   repo.To,
+		}
+
+		if !acquire(ctx, sem) {
+			sections[i].Error = ctx.Err().Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			report, err := s.generate(ctx, repo)
+			if err != nil {
+				sections[i].Error = err.Error()
+				return
+			}
+			sections[i].Report = report
+		}()
+	}
+	wg.Wait()
+
+	return &domain.MultiDiffReport{
+		SchemaVersion: domain.ReportSchemaVersion,
+		Name:          manifest.Name,
+		Summary:       summarize(sections),
+		Repositories:  sections,
+		Metadata: domain.Metadata{
+			GeneratedAt: time.Now().UTC(),
+			Generator: domain.GeneratorInfo{
+				Name:    "supervisor",
+				Version: "0.1.0",
+			},
+		},
+	}
+}
+
+// acquire takes a slot from sem, giving up once ctx is done. Cancellation
+// is checked first so that no new work starts after it, even when a slot
+// happens to be free.
+func acquire(ctx context.Context, sem chan struct{}) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func summarize(sections []domain.RepoSection) domain.CrossRepoSummary {
+	summary := domain.CrossRepoSummary{
+		Repositories: len(sections),
+		Issues:       []string{},
+	}
+	seenIssues := map[string]bool{}
+
+	for _, section := range sections {
+		if section.Report == nil {
+			summary.Failed++
+			continue
+		}
+		summary.Succeeded++
+
+		r := section.Report
+		files := r.TreeDiff.Summary.Files
+		summary.Files.Added += files.Added
+		summary.Files.Modified += files.Modified
+		summary.Files.Deleted += files.Deleted
+		summary.Files.Renamed += files.Renamed
+		summary.Files.Copied += files.Copied
+
+		lines := r.TreeDiff.Summary.Lines
+		summary.Lines.Added += lines.Added
+		summary.Lines.Deleted += lines.Deleted
+		summary.Lines.Net += lines.Net
+
+		summary.Commits += len(r.HistoryView.Commits)
+
+		for _, issue := range r.Issues {
+			if !seenIssues[issue.Key] {
+				seenIssues[issue.Key] = true
+				summary.Issues = append(summary.Issues, issue.Key)
+			}
+		}
+	}
+
+	slices.SortFunc(summary.Issues, compareIssueKeys)
+	return summary
+}
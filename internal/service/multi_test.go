@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiDiffService_AggregatesInManifestOrder(t *testing.T) {
+	reports := map[string]*domain.DiffReport{
+		"api": {
+			TreeDiff: domain.TreeDiff{Summary: domain.DiffSummary{
+				Files: domain.FileStats{Added: 2, Modified: 1},
+				Lines: domain.SummaryLineStats{Added: 30, Deleted: 5, Net: 25},
+			}},
+			HistoryView: domain.HistoryView{Commits: []domain.Commit{{Hash: "a1"}, {Hash: "a2"}}},
+			Issues:      []domain.IssueReference{{Key: "ABC-10"}, {Key: "ABC-2"}},
+		},
+		"web": {
+			TreeDiff: domain.TreeDiff{Summary: domain.DiffSummary{
+				Files: domain.FileStats{Deleted: 1, Renamed: 1},
+				Lines: domain.SummaryLineStats{Added: 1, Deleted: 9, Net: -8},
+			}},
+			HistoryView: domain.HistoryView{Commits: []domain.Commit{{Hash: "w1"}}},
+			Issues:      []domain.IssueReference{{Key: "ABC-2"}, {Key: "OPS-1"}},
+		},
+	}
+
+	generate := func(ctx context.Context, repo domain.ManifestRepo) (*domain.DiffReport, error) {
+		if repo.Name == "api" {
+			// Finish last so that ordering cannot come from completion order.
+			time.Sleep(20 * time.Millisecond)
+		}
+		report, ok := reports[repo.Name]
+		if !ok {
+			return nil, errors.New("repository not found")
+		}
+		return report, nil
+	}
+
+	manifest := &domain.Manifest{Name: "R1", Repos: []domain.ManifestRepo{
+		{Name: "api", Path: "/src/api", From: "v1", To: "v2"},
+		{Name: "gone", Path: "/src/gone", From: "v1", To: "v2"},
+		{Name: "web", Path: "/src/web", From: "v3", To: "v4"},
+	}}
+
+	report := NewMultiDiffService(generate, 3).GenerateReport(context.Background(), manifest)
+
+	assert.Equal(t, "R1", report.Name)
+	require.Len(t, report.Repositories, 3)
+	assert.Equal(t, []string{"api", "gone", "web"}, []string{report.Repositories[0].Name, report.Repositories[1].Name, report.Repositories[2].Name})
+	assert.Same(t, reports["api"], report.Repositories[0].Report)
+	assert.Nil(t, report.Repositories[1].Report)
+	assert.Equal(t, "repository not found", report.Repositories[1].Error)
+	assert.Equal(t, "v3", report.Repositories[2].From)
+
+	assert.Equal(t, domain.CrossRepoSummary{
+		Repositories: 3,
+		Succeeded:    2,
+		Failed:       1,
+		Files:        domain.FileStats{Added: 2, Modified: 1, Deleted: 1, Renamed: 1},
+		Lines:        domain.SummaryLineStats{Added: 31, Deleted: 14, Net: 17},
+		Commits:      3,
+		Issues:       []string{"ABC-2", "ABC-10", "OPS-1"},
+	}, report.Summary)
+}
+
+func TestMultiDiffService_BoundsParallelism(t *testing.T) {
+	var running, peak atomic.Int32
+	generate := func(ctx context.Context, repo domain.ManifestRepo) (*domain.DiffReport, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return &domain.DiffReport{}, nil
+	}
+
+	manifest := &domain.Manifest{}
+	for range 12 {
+		manifest.Repos = append(manifest.Repos, domain.ManifestRepo{Name: "repo"})
+	}
+
+	report := NewMultiDiffService(generate, 3).GenerateReport(context.Background(), manifest)
+
+	assert.Equal(t, 12, report.Summary.Succeeded)
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.Greater(t, peak.Load(), int32(1))
+}
+
+func TestMultiDiffService_StopsSchedulingOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	generate := func(ctx context.Context, repo domain.ManifestRepo) (*domain.DiffReport, error) {
+		cancel()
+		return &domain.DiffReport{}, nil
+	}
+
+	manifest := &domain.Manifest{Repos: []domain.ManifestRepo{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+
+	report := NewMultiDiffService(generate, 1).GenerateReport(ctx, manifest)
+
+	assert.NotNil(t, report.Repositories[0].Report)
+	assert.Equal(t, 1, report.Summary.Succeeded)
+	assert.Equal(t, 2, report.Summary.Failed)
+	assert.Equal(t, context.Canceled.Error(), report.Repositories[2].Error)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// bytesPerToken is the usual rule of thumb for code and English prose with
+// BPE tokenizers.
+const bytesPerToken = 4
+
+// packHeaderTokens covers the bundle heading and summary, and
+// packLineTokens the markup around each file or commit line.
+const (
+	packHeaderTokens = 200
+	packLineTokens   = 12
+)
+
+// minTruncatedPatchTokens is the smallest remainder worth spending on a
+// truncated patch rather than leaving the file summarized.
+const minTruncatedPatchTokens = 64
+
+// lockfiles are dependency lock files: large, machine-written and of little
+// use to a reviewer beyond the fact that they changed.
+var lockfiles = []string{
+	"go.sum", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml",
+	"Cargo.lock", "Gemfile.lock", "composer.lock", "poetry.lock", "Pipfile.lock", "uv.lock",
+}
+
+// PackReport bundles r into at most opts.MaxTokens estimated tokens. The
+// summary and a list line for every file come first; commit subjects may
+// then take up to half of what is left, and the rest goes to patches in
+// priority order: source, tests, config, then documentation. Generated,
+// binary, vendored and lock files are only ever summarized. When even the
+// list does not fit, the lowest-priority files are dropped.
+func PackReport(r *domain.DiffReport, opts domain.PackOptions) (*domain.Pack, error) {
+	if opts.MaxTokens <= packHeaderTokens {
+		return nil, fmt.Errorf("%w: token budget must be more than %d", domain.ErrInvalidOption, packHeaderTokens)
+	}
+
+	files := rankFiles(r.TreeDiff.Files)
+	remaining := opts.MaxTokens - packHeaderTokens
+
+	for i := range files {
+		files[i].Tokens = listTokens(files[i].File)
+		remaining -= files[i].Tokens
+	}
+	for i := len(files) - 1; i >= 0 && remaining < 0; i-- {
+		remaining += files[i].Tokens
+		files[i].Tokens = 0
+		files[i].Disposition, files[i].Reason = domain.PackDropped, domain.PackReasonBudget
+	}
+
+	pack := &domain.Pack{Report: r, MaxTokens: opts.MaxTokens, Files: files}
+
+	commitBudget := remaining / 2
+	for i, c := range r.HistoryView.Commits {
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		cost := estimateTokens(subject+c.Author) + packLineTokens
+		if cost > commitBudget {
+			pack.OmittedCommits = len(r.HistoryView.Commits) - i
+			break
+		}
+		commitBudget -= cost
+		remaining -= cost
+		pack.Commits = append(pack.Commits, c)
+	}
+
+	for i := range files {
+		f := &files[i]
+		if f.Disposition != domain.PackSummarized || f.Reason != domain.PackReasonBudget {
+			continue
+		}
+
+		patch := f.File.Patch
+		if cost := estimateTokens(patch.String()); cost <= remaining {
+			remaining -= cost
+			f.Tokens += cost
+			f.Disposition, f.Reason = domain.PackIncluded, ""
+			continue
+		}
+		if remaining < minTruncatedPatchTokens {
+			continue
+		}
+
+		cut := *patch
+		truncatePatch(&cut, remaining*bytesPerToken)
+		if len(cut.Hunks) == 0 || cut.Hunks[0].Header == "" {
+			continue
+		}
+		cost := estimateTokens(cut.String())
+		remaining -= cost
+		f.Tokens += cost
+		f.File.Patch = &cut
+		f.Disposition = domain.PackTruncated
+	}
+
+	for i := range files {
+		if d := files[i].Disposition; d != domain.PackIncluded && d != domain.PackTruncated {
+			files[i].File.Patch = nil
+		}
+	}
+
+	pack.Tokens = opts.MaxTokens - remaining
+	return pack, nil
+}
+
+// rankFiles orders files by signal tier, then by churn, largest first, and
+// marks each summarized with the reason it is not (yet) included.
+func rankFiles(files []domain.FileChange) []domain.PackedFile {
+	type ranked struct {
+		domain.PackedFile
+		tier int
+	}
+	out := make([]ranked, len(files))
+	for i, f := range files {
+		tier, reason := packTier(f)
+		out[i] = ranked{PackedFile: domain.PackedFile{File: f, Disposition: domain.PackSummarized, Reason: reason}, tier: tier}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].tier != out[j].tier {
+			return out[i].tier < out[j].tier
+		}
+		ci := out[i].File.Lines.Added + out[i].File.Lines.Deleted
+		cj := out[j].File.Lines.Added + out[j].File.Lines.Deleted
+		if ci != cj {
+			return ci > cj
+		}
+		return packPath(out[i].File) < packPath(out[j].File)
+	})
+
+	packed := make([]domain.PackedFile, len(out))
+	for i, r := range out {
+		packed[i] = r.PackedFile
+	}
+	return packed
+}
+
+// packTier returns the priority tier of f, lower first, and why it would be
+// left summarized: low-signal classes, a missing patch, or else the budget.
+func packTier(f domain.FileChange) (int, string) {
+	c := f.Classification
+	switch {
+	case c.IsBinary:
+		return 5, domain.PackReasonBinary
+	case c.IsGenerated:
+		return 5, domain.PackReasonGenerated
+	case c.IsVendored:
+		return 5, domain.PackReasonVendored
+	case slices.Contains(lockfiles, path.Base(packPath(f))):
+		return 5, domain.PackReasonLockfile
+	case f.Patch == nil || len(f.Patch.Hunks) == 0:
+		return 4, domain.PackReasonNoPatch
+	case c.IsDocumentation:
+		return 3, domain.PackReasonBudget
+	case c.IsConfig:
+		return 2, domain.PackReasonBudget
+	case c.IsTest:
+		return 1, domain.PackReasonBudget
+	}
+	return 0, domain.PackReasonBudget
+}
+
+func packPath(f domain.FileChange) string {
+	if f.Path.After != "" {
+		return f.Path.After
+	}
+	return f.Path.Before
+}
+
+// listTokens estimates the list line naming f with its change and counts.
+func listTokens(f domain.FileChange) int {
+	return estimateTokens(f.Path.Before+f.Path.After+f.ChangeType) + packLineTokens
+}
+
+func estimateTokens(s string) int {
+	return (len(s) + bytesPerToken - 1) / bytesPerToken
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// patchOf returns a one-hunk patch of n added lines of 19 bytes each.
+func patchOf(n int) *domain.FilePatch {
+	return &domain.FilePatch{Hunks: []domain.PatchHunk{{
+		Header: "@@ -0,0 +1 @@",
+		Lines:  strings.Repeat("+line of some code\n", n),
+	}}}
+}
+
+func packFixture() *domain.DiffReport {
+	return &domain.DiffReport{
+		TreeDiff: domain.TreeDiff{Files: []domain.FileChange{
+			{Path: domain.FilePath{After: "go.sum"}, ChangeType: "modified", Lines: domain.FileLineStats{Added: 90}, Patch: patchOf(90)},
+			{Path: domain.FilePath{After: "docs/guide.md"}, ChangeType: "added", Lines: domain.FileLineStats{Added: 5}, Patch: patchOf(5), Classification: domain.Classification{IsDocumentation: true}},
+			{Path: domain.FilePath{After: "api.pb.go"}, ChangeType: "added", Lines: domain.FileLineStats{Added: 80}, Patch: patchOf(80), Classification: domain.Classification{IsGenerated: true}},
+			{Path: domain.FilePath{After: "main_test.go"}, ChangeType: "added", Lines: domain.FileLineStats{Added: 10}, Patch: patchOf(10), Classification: domain.Classification{IsTest: true}},
+			{Path: domain.FilePath{Before: "old.go", After: "new.go"}, ChangeType: "renamed", Similarity: 100},
+			{Path: domain.FilePath{After: "small.go"}, ChangeType: "added", Lines: domain.FileLineStats{Added: 2}, Patch: patchOf(2)},
+			{Path: domain.FilePath{After: "big.go"}, ChangeType: "added", Lines: domain.FileLineStats{Added: 40}, Patch: patchOf(40)},
+			{Path: domain.FilePath{After: "logo.png"}, ChangeType: "added", Classification: domain.Classification{IsBinary: true}},
+		}},
+		HistoryView: domain.HistoryView{Commits: []domain.Commit{
+			{Hash: "1111111", Author: "Ada", Message: "feat: add api\n\nbody"},
+			{Hash: "2222222", Author: "Ada", Message: "docs: add guide"},
+		}},
+	}
+}
+
+type disposition struct {
+	path, disposition, reason string
+}
+
+func dispositions(p *domain.Pack) []disposition {
+	out := make([]disposition, len(p.Files))
+	for i, f := range p.Files {
+		out[i] = disposition{packPath(f.File), f.Disposition, f.Reason}
+	}
+	return out
+}
+
+func TestPackReport_FitsEverything(t *testing.T) {
+	pack, err := PackReport(packFixture(), domain.PackOptions{MaxTokens: 100_000})
+	require.NoError(t, err)
+
+	assert.Equal(t, []disposition{
+		{"big.go", domain.PackIncluded, ""},
+		{"small.go", domain.PackIncluded, ""},
+		{"main_test.go", domain.PackIncluded, ""},
+		{"docs/guide.md", domain.PackIncluded, ""},
+		{"new.go", domain.PackSummarized, domain.PackReasonNoPatch},
+		{"go.sum", domain.PackSummarized, domain.PackReasonLockfile},
+		{"api.pb.go", domain.PackSummarized, domain.PackReasonGenerated},
+		{"logo.png", domain.PackSummarized, domain.PackReasonBinary},
+	}, dispositions(pack))
+	assert.Len(t, pack.Commits, 2)
+	assert.Zero(t, pack.OmittedCommits)
+	assert.Nil(t, pack.Files[5].File.Patch, "summarized files carry no patch")
+
+	total := packHeaderTokens + (4 + packLineTokens) + (5 + packLineTokens) // two commit lines
+	for _, f := range pack.Files {
+		total += f.Tokens
+	}
+	assert.Equal(t, total, pack.Tokens)
+}
+
+func TestPackReport_TruncatesAndSummarizesOverBudget(t *testing.T) {
+	r := packFixture()
+	pack, err := PackReport(r, domain.PackOptions{MaxTokens: 450})
+	require.NoError(t, err)
+
+	assert.Equal(t, []disposition{
+		{"big.go", domain.PackTruncated, domain.PackReasonBudget},
+		{"small.go", domain.PackSummarized, domain.PackReasonBudget},
+		{"main_test.go", domain.PackSummarized, domain.PackReasonBudget},
+		{"docs/guide.md", domain.PackSummarized, domain.PackReasonBudget},
+		{"new.go", domain.PackSummarized, domain.PackReasonNoPatch},
+		{"go.sum", domain.PackSummarized, domain.PackReasonLockfile},
+		{"api.pb.go", domain.PackSummarized, domain.PackReasonGenerated},
+		{"logo.png", domain.PackSummarized, domain.PackReasonBinary},
+	}, dispositions(pack))
+	assert.LessOrEqual(t, pack.Tokens, 450)
+
+	big := pack.Files[0].File.Patch
+	assert.True(t, big.Truncated)
+	assert.Positive(t, big.OmittedBytes)
+	assert.True(t, strings.HasSuffix(big.String(), domain.PatchTruncationLine(big.OmittedBytes)))
+	assert.Equal(t, 1, strings.Count(big.String(), domain.PatchTruncatedMarker))
+	assert.Len(t, r.TreeDiff.Files[6].Patch.Hunks[0].Lines, 40*19, "the report keeps its patches")
+}
+
+func TestPackReport_RecutsTruncatedPatch(t *testing.T) {
+	r := packFixture()
+	report := r.TreeDiff.Files[6].Patch
+	truncatePatch(report, 500)
+	earlier := report.OmittedBytes
+
+	pack, err := PackReport(r, domain.PackOptions{MaxTokens: 450})
+	require.NoError(t, err)
+
+	big := pack.Files[0].File.Patch
+	require.Equal(t, domain.PackTruncated, pack.Files[0].Disposition)
+	assert.Greater(t, big.OmittedBytes, earlier)
+	kept := len(big.String()) - len(domain.PatchTruncationLine(big.OmittedBytes))
+	assert.Equal(t, patchOf(40).Hunks[0].Size(), kept+big.OmittedBytes, "omitted bytes cover both cuts")
+	assert.True(t, strings.HasSuffix(big.String(), domain.PatchTruncationLine(big.OmittedBytes)))
+	assert.Equal(t, 1, strings.Count(big.String(), domain.PatchTruncatedMarker))
+}
+
+func TestPackReport_DropsLowestPriorityWhenListOverflows(t *testing.T) {
+	pack, err := PackReport(packFixture(), domain.PackOptions{MaxTokens: packHeaderTokens + 60})
+	require.NoError(t, err)
+
+	got := dispositions(pack)
+	assert.Equal(t, disposition{"big.go", domain.PackSummarized, domain.PackReasonBudget}, got[0])
+	assert.Equal(t, disposition{"logo.png", domain.PackDropped, domain.PackReasonBudget}, got[7])
+	assert.Equal(t, 2, pack.OmittedCommits)
+	assert.LessOrEqual(t, pack.Tokens, packHeaderTokens+60)
+}
+
+func TestPackReport_RejectsTinyBudget(t *testing.T) {
+	_, err := PackReport(packFixture(), domain.PackOptions{MaxTokens: 10})
+	assert.ErrorIs(t, err, domain.ErrInvalidOption)
+}
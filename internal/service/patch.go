@@ -0,0 +1,163 @@
+package service
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// firstChangedLine is the number of the first line the first hunk of p
+// adds or removes, in the old file when old is set and in the new one
+// otherwise, or 0 when there is no patch.
+func firstChangedLine(p *domain.FilePatch, old bool) int {
+	if p == nil || len(p.Hunks) == 0 {
+		return 0
+	}
+	m := hunkHeader.FindStringSubmatch(p.Hunks[0].Header)
+	if m == nil {
+		return 0
+	}
+	start := m[2]
+	if old {
+		start = m[1]
+	}
+	line, _ := strconv.Atoi(start)
+	for l := range strings.Lines(p.Hunks[0].Lines) {
+		if !strings.HasPrefix(l, " ") {
+			break
+		}
+		line++
+	}
+	return line
+}
+
+// applyPatchBudget trims the patches of files, in order, to the per-file
+// and total byte limits and reports what is left.
+func applyPatchBudget(files []domain.FileChange, opts domain.PatchOptions) *domain.PatchSummary {
+	summary := &domain.PatchSummary{}
+	remaining := opts.MaxTotalBytes
+
+	for _, f := range files {
+		if f.Patch == nil {
+			continue
+		}
+
+		limit := -1
+		if opts.MaxFileBytes > 0 {
+			limit = opts.MaxFileBytes
+		}
+		if opts.MaxTotalBytes > 0 && (limit < 0 || remaining < limit) {
+			limit = remaining
+		}
+
+		kept := truncatePatch(f.Patch, limit)
+		summary.Bytes += kept
+		if opts.MaxTotalBytes > 0 {
+			remaining -= kept
+		}
+		if f.Patch.Truncated {
+			summary.TruncatedFiles++
+		}
+	}
+	return summary
+}
+
+// truncatePatch cuts p to at most limit bytes (no limit when negative) at
+// a line boundary and returns the bytes kept. A cut patch ends with a
+// PatchTruncationLine, which counts towards the limit: it is appended to
+// the last kept hunk, or is a hunk of its own when no hunk fits. When not
+// even the marker fits, nothing is kept. Truncated hunks keep their
+// original header, and cutting an already truncated patch again leaves a
+// single marker counting everything omitted. The hunks of p are not
+// modified in place.
+func truncatePatch(p *domain.FilePatch, limit int) int {
+	size := 0
+	for _, h := range p.Hunks {
+		size += h.Size()
+	}
+	if limit < 0 || size <= limit {
+		return size
+	}
+
+	src, omitted := untruncatedHunks(p)
+	total := 0
+	for _, h := range src {
+		total += h.Size()
+	}
+
+	// The marker can only get shorter as more is kept, so reserving room
+	// for one omitting everything is enough.
+	budget := limit - len(domain.PatchTruncationLine(omitted+total))
+	p.Truncated = true
+	if budget < 0 {
+		p.Hunks, p.OmittedBytes = nil, omitted+total
+		return 0
+	}
+
+	kept := 0
+	var hunks []domain.PatchHunk
+	for _, h := range src {
+		if size := h.Size(); kept+size <= budget {
+			hunks = append(hunks, h)
+			kept += size
+			continue
+		}
+		if room := budget - kept - len(h.Header) - 1; room > 0 {
+			if lines := cutAtLine(h.Lines, room); lines != "" {
+				h.Lines = lines
+				hunks = append(hunks, h)
+				kept += h.Size()
+			}
+		}
+		break
+	}
+
+	p.OmittedBytes = omitted + total - kept
+	marker := domain.PatchTruncationLine(p.OmittedBytes)
+	if n := len(hunks); n > 0 {
+		hunks[n-1].Lines += marker
+	} else {
+		hunks = []domain.PatchHunk{{Lines: marker}}
+	}
+	p.Hunks = hunks
+	return kept + len(marker)
+}
+
+// untruncatedHunks returns the hunks of p without its truncation marker,
+// and the bytes an earlier cut left out.
+func untruncatedHunks(p *domain.FilePatch) ([]domain.PatchHunk, int) {
+	n := len(p.Hunks)
+	if !p.Truncated || n == 0 {
+		return p.Hunks, 0
+	}
+	last := p.Hunks[n-1]
+	lines, ok := strings.CutSuffix(last.Lines, domain.PatchTruncationLine(p.OmittedBytes))
+	switch {
+	case !ok:
+		return p.Hunks, p.OmittedBytes
+	case last.Header == "":
+		return p.Hunks[:n-1], p.OmittedBytes
+	}
+	hunks := slices.Clone(p.Hunks)
+	hunks[n-1].Lines = lines
+	return hunks, p.OmittedBytes
+}
+
+// cutAtLine returns the longest prefix of lines that fits in n bytes and
+// ends with a newline.
+func cutAtLine(lines string, n int) string {
+	if len(lines) <= n {
+		return lines
+	}
+	for i := n - 1; i >= 0; i-- {
+		if lines[i] == '\n' {
+			return lines[:i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	lineA = "-aaaaaaaaaaaaaaaaaa\n" // 20 bytes
+	lineB = "+bbbbbbbbbbbbbbbbbb\n"
+)
+
+func testPatch() *domain.FilePatch {
+	return &domain.FilePatch{Hunks: []domain.PatchHunk{
+		{Header: "@@ -1,2 +1,2 @@", Lines: lineA + lineB + lineB}, // 15 + 1 + 60 = 76 bytes
+		{Header: "@@ -9,2 +9,2 @@", Lines: lineA + lineB + lineB}, // 76 bytes
+	}}
+}
+
+// assertTruncated checks that a cut patch stays within limit, renders to the
+// bytes kept and reports what it left out.
+func assertTruncated(t *testing.T, p *domain.FilePatch, kept, limit, omitted int) {
+	t.Helper()
+	assert.LessOrEqual(t, kept, limit)
+	assert.Equal(t, kept, len(p.String()))
+	assert.True(t, p.Truncated)
+	assert.Equal(t, omitted, p.OmittedBytes)
+}
+
+func TestTruncatePatch(t *testing.T) {
+	t.Run("fits", func(t *testing.T) {
+		p := testPatch()
+		assert.Equal(t, 152, truncatePatch(p, 152))
+		assert.Equal(t, testPatch(), p)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		p := testPatch()
+		assert.Equal(t, 152, truncatePatch(p, -1))
+		assert.False(t, p.Truncated)
+	})
+
+	t.Run("cuts inside a hunk at a line boundary", func(t *testing.T) {
+		p := testPatch()
+		kept := truncatePatch(p, 143)
+		assert.Equal(t, []domain.PatchHunk{
+			{Header: "@@ -1,2 +1,2 @@", Lines: lineA + lineB + lineB},
+			{Header: "@@ -9,2 +9,2 @@", Lines: lineA + domain.PatchTruncationLine(40)},
+		}, p.Hunks)
+		assertTruncated(t, p, kept, 143, 40)
+	})
+
+	t.Run("drops hunks without room for a line", func(t *testing.T) {
+		p := testPatch()
+		kept := truncatePatch(p, 133)
+		assert.Equal(t, []domain.PatchHunk{
+			{Header: "@@ -1,2 +1,2 @@", Lines: lineA + lineB + lineB + domain.PatchTruncationLine(76)},
+		}, p.Hunks)
+		assertTruncated(t, p, kept, 133, 76)
+	})
+
+	t.Run("keeps the marker when no hunk fits", func(t *testing.T) {
+		p := testPatch()
+		kept := truncatePatch(p, 40)
+		assert.Equal(t, domain.PatchTruncationLine(152), p.String())
+		assertTruncated(t, p, kept, 40, 152)
+	})
+
+	t.Run("cuts a truncated patch again", func(t *testing.T) {
+		p := testPatch()
+		truncatePatch(p, 143)
+		before := slices.Clone(p.Hunks)
+		kept := truncatePatch(p, 133)
+		assert.Equal(t, []domain.PatchHunk{
+			{Header: "@@ -1,2 +1,2 @@", Lines: lineA + lineB + lineB + domain.PatchTruncationLine(76)},
+		}, p.Hunks)
+		assertTruncated(t, p, kept, 133, 76)
+		assert.Equal(t, 1, strings.Count(p.String(), domain.PatchTruncatedMarker))
+		assert.Equal(t, lineA+domain.PatchTruncationLine(40), before[1].Lines, "hunks are not modified in place")
+	})
+
+	t.Run("nothing fits", func(t *testing.T) {
+		p := testPatch()
+		assert.Equal(t, 0, truncatePatch(p, 20))
+		assert.Empty(t, p.Hunks)
+		assertTruncated(t, p, 0, 20, 152)
+	})
+}
+
+func TestApplyPatchBudget(t *testing.T) {
+	files := []domain.FileChange{
+		{Patch: testPatch()},
+		{},
+		{Patch: testPatch()},
+		{Patch: testPatch()},
+	}
+
+	summary := applyPatchBudget(files, domain.PatchOptions{MaxFileBytes: 110, MaxTotalBytes: 250})
+
+	assert.Equal(t, &domain.PatchSummary{Bytes: 243, TruncatedFiles: 3}, summary)
+	assert.Len(t, files[0].Patch.Hunks, 1, "per-file limit")
+	assert.Len(t, files[2].Patch.Hunks, 1, "per-file limit")
+	assert.Equal(t, domain.PatchTruncationLine(152), files[3].Patch.String(), "total budget spent")
+	assert.Equal(t, 152, files[3].Patch.OmittedBytes)
+}
+
+func TestApplyPatchBudget_Unlimited(t *testing.T) {
+	files := []domain.FileChange{{Patch: testPatch()}, {Patch: testPatch()}}
+
+	summary := applyPatchBudget(files, domain.PatchOptions{})
+
+	assert.Equal(t, &domain.PatchSummary{Bytes: 304}, summary)
+	assert.Equal(t, testPatch(), files[1].Patch)
+}
+
+func TestFirstChangedLine(t *testing.T) {
+	p := &domain.FilePatch{Hunks: []domain.PatchHunk{
+		{Header: "@@ -10,6 +12,7 @@ func main() {", Lines: " a\n b\n-c\n+d\n+e\n f\n"},
+	}}
+	assert.Equal(t, 14, firstChangedLine(p, false))
+	assert.Equal(t, 12, firstChangedLine(p, true))
+
+	added := &domain.FilePatch{Hunks: []domain.PatchHunk{{Header: "@@ -0,0 +1 @@", Lines: "+x\n"}}}
+	assert.Equal(t, 1, firstChangedLine(added, false))
+
+	assert.Zero(t, firstChangedLine(nil, false))
+	assert.Zero(t, firstChangedLine(&domain.FilePatch{Truncated: true}, false))
+}
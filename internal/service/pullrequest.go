@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// Merge messages written by GitHub, Bitbucket and GitLab when a request
+// lands with a merge commit, and the "(#123)" suffix GitHub appends to the
+// subject of a squash.
+var (
+	githubMerge    = regexp.MustCompile(`^Merge pull request #(\d+) from (\S+)`)
+	bitbucketMerge = regexp.MustCompile(`^Merged in (\S+) \(pull request #(\d+)\)`)
+	gitlabMerge    = regexp.MustCompile(`^Merge branch '([^']+)'`)
+	gitlabRequest  = regexp.MustCompile(`(?m)^See merge request \S*!(\d+)\s*$`)
+	squashSuffix   = regexp.MustCompile(`^(.*\S)\s*\(#(\d+)\)$`)
+)
+
+// GroupPullRequests recognises the pull requests that landed commits,
+// nested Merged commits included. A merge request's members are the
+// commits its merge brought in: reachable from its other parents but not
+// from its first. Commits that are not members of a merge and whose
+// subject ends in "(#123)" are squashes. Requests are returned in history
+// order of the commit that landed them.
+func GroupPullRequests(commits []domain.Commit) []domain.PullRequest {
+	all := domain.FlattenCommits(commits)
+	index := make(map[string]int, len(all))
+	for i, c := range all {
+		index[c.Hash] = i
+	}
+
+	var prs []domain.PullRequest
+	claimed := map[string]bool{}
+	for _, c := range all {
+		if len(c.Parents) < 2 {
+			continue
+		}
+		pr, ok := parseMergeMessage(c.Message)
+		if !ok {
+			continue
+		}
+		pr.Style = domain.PullRequestMerge
+		pr.MergeCommit, pr.CommitURL, pr.Date = c.Hash, c.DiffURL, c.Date
+		pr.Commits = mergedBy(all, index, c)
+		pr.Author = c.Author
+		if len(pr.Commits) > 0 {
+			pr.Author = all[index[pr.Commits[0]]].Author
+		}
+		for _, h := range pr.Commits {
+			claimed[h] = true
+		}
+		prs = append(prs, pr)
+	}
+
+	for _, c := range all {
+		if len(c.Parents) > 1 || claimed[c.Hash] {
+			continue
+		}
+		subject, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+		m := squashSuffix.FindStringSubmatch(strings.TrimSpace(subject))
+		if m == nil {
+			continue
+		}
+		number, _ := strconv.Atoi(m[2])
+		prs = append(prs, domain.PullRequest{
+			Number:      number,
+			Title:       m[1],
+			Style:       domain.PullRequestSquash,
+			MergeCommit: c.Hash,
+			CommitURL:   c.DiffURL,
+			Author:      c.Author,
+			Date:        c.Date,
+			Commits:     []string{c.Hash},
+		})
+	}
+
+	sort.SliceStable(prs, func(i, j int) bool {
+		return index[prs[i].MergeCommit] < index[prs[j].MergeCommit]
+	})
+	return prs
+}
+
+// parseMergeMessage reads the number, source branch and title from a
+// forge's merge message. The title is the first line of the body, or the
+// subject when the body is empty.
+func parseMergeMessage(message string) (domain.PullRequest, bool) {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	subject, body, _ := strings.Cut(message, "\n")
+	subject = strings.TrimSpace(subject)
+
+	var pr domain.PullRequest
+	switch {
+	case githubMerge.MatchString(subject):
+		m := githubMerge.FindStringSubmatch(subject)
+		pr.Number, _ = strconv.Atoi(m[1])
+		pr.SourceBranch = m[2]
+	case bitbucketMerge.MatchString(subject):
+		m := bitbucketMerge.FindStringSubmatch(subject)
+		pr.SourceBranch = m[1]
+		pr.Number, _ = strconv.Atoi(m[2])
+	case gitlabMerge.MatchString(subject) && gitlabRequest.MatchString(body):
+		pr.SourceBranch = gitlabMerge.FindStringSubmatch(subject)[1]
+		pr.Number, _ = strconv.Atoi(gitlabRequest.FindStringSubmatch(body)[1])
+	default:
+		return pr, false
+	}
+
+	pr.Title = subject
+	for line := range strings.Lines(body) {
+		if line = strings.TrimSpace(line); line != "" && !gitlabRequest.MatchString(line) {
+			pr.Title = line
+			break
+		}
+	}
+	return pr, true
+}
+
+// mergedBy lists, in history order, the commits of all that merge brought
+// in.
+func mergedBy(all []domain.Commit, index map[string]int, merge domain.Commit) []string {
+	mainline := reachable(all, index, merge.Parents[:1])
+	var members []int
+	for i := range reachable(all, index, merge.Parents[1:]) {
+		if !mainline[i] {
+			members = append(members, i)
+		}
+	}
+	slices.Sort(members)
+
+	hashes := make([]string, len(members))
+	for i, m := range members {
+		hashes[i] = all[m].Hash
+	}
+	return hashes
+}
+
+// reachable returns the indexes of the commits of all reachable from the
+// given hashes through parents in all. Parents outside all are ancestors
+// of the range's base, and so are theirs.
+func reachable(all []domain.Commit, index map[string]int, from []string) map[int]bool {
+	seen := map[int]bool{}
+	stack := slices.Clone(from)
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		i, ok := index[h]
+		if !ok || seen[i] {
+			continue
+		}
+		seen[i] = true
+		stack = append(stack, all[i].Parents...)
+	}
+	return seen
+}
+
+// PullRequestEnricher attaches forge data to the pull requests recognised
+// in a report's history.
+type PullRequestEnricher struct {
+	forge domain.PullRequestProvider
+}
+
+func NewPullRequestEnricher(forge domain.PullRequestProvider) *PullRequestEnricher {
+	return &PullRequestEnricher{forge: forge}
+}
+
+// Enrich fetches every request in report.HistoryView.PullRequests. A
+// request the forge does not know, such as one merged in a fork, is left
+// without details; any other failure aborts enrichment.
+func (e *PullRequestEnricher) Enrich(ctx context.Context, report *domain.DiffReport) error {
+	for i := range report.HistoryView.PullRequests {
+		pr := &report.HistoryView.PullRequests[i]
+		details, err := e.forge.GetPullRequest(ctx, pr.Number)
+		if errors.Is(err, domain.ErrPullRequestNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		pr.Details = details
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prHistory is a main line with a GitHub merge, a squash, a GitLab merge
+// and a Bitbucket merge whose branch commit carries a "(#99)" suffix of
+// its own, oldest first:
+//
+//	base--a1--a2------.            g1---.        bz---.
+//	   \               \          /      \      /      \
+//	    d1--------------m1---s1--+--------g2---+--------b1--x
+func prHistory() []domain.Commit {
+	day := 0
+	commit := func(hash, author, message string, parents ...string) domain.Commit {
+		day++
+		return domain.Commit{
+			Hash:    hash,
+			Author:  author,
+			Date:    time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC),
+			Message: message,
+			DiffURL: "https://example.com/commit/" + hash,
+			Parsed:  ParseCommitMessage(message),
+			Parents: parents,
+		}
+	}
+	return []domain.Commit{
+		commit("a1", "Ada", "feat: add x", "base"),
+		commit("d1", "Linus", "docs: update readme", "base"),
+		commit("a2", "Ada", "test: cover x", "a1"),
+		commit("m1", "Merger", "Merge pull request #12 from ada/feature-x\n\nfeat(api): add x", "d1", "a2"),
+		commit("s1", "Grace", "fix(cli): handle empty refs (#15)", "m1"),
+		commit("g1", "Grace", "feat: y", "s1"),
+		commit("g2", "Merger", "Merge branch 'feature-y' into 'main'\n\nAdd y\n\nSee merge request group/project!7", "s1", "g1"),
+		commit("bz", "Linus", "fix: z (#99)", "g2"),
+		commit("b1", "Merger", "Merged in fix/z (pull request #3)\n\nfix: z", "g2", "bz"),
+		commit("x", "Ada", "Merge branch 'main' into release", "b1", "d1"),
+	}
+}
+
+func TestGroupPullRequests(t *testing.T) {
+	pr := func(number int, title, branch, style, merge, author string, day int, commits ...string) domain.PullRequest {
+		return domain.PullRequest{
+			Number:       number,
+			Title:        title,
+			SourceBranch: branch,
+			Style:        style,
+			MergeCommit:  merge,
+			CommitURL:    "https://example.com/commit/" + merge,
+			Author:       author,
+			Date:         time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC),
+			Commits:      commits,
+		}
+	}
+
+	assert.Equal(t, []domain.PullRequest{
+		pr(12, "feat(api): add x", "ada/feature-x", domain.PullRequestMerge, "m1", "Ada", 4, "a1", "a2"),
+		pr(15, "fix(cli): handle empty refs", "", domain.PullRequestSquash, "s1", "Grace", 5, "s1"),
+		pr(7, "Add y", "feature-y", domain.PullRequestMerge, "g2", "Grace", 7, "g1"),
+		pr(3, "fix: z", "fix/z", domain.PullRequestMerge, "b1", "Linus", 9, "bz"),
+	}, GroupPullRequests(prHistory()))
+}
+
+func TestGroupPullRequests_NestedMerges(t *testing.T) {
+	history := prHistory()
+	merge := history[3]
+	merge.Merged = []domain.Commit{history[0], history[2]}
+	nested := []domain.Commit{history[1], merge}
+
+	prs := GroupPullRequests(nested)
+	if assert.Len(t, prs, 1) {
+		assert.Equal(t, []string{"a1", "a2"}, prs[0].Commits)
+	}
+}
+
+func TestGroupPullRequests_MergeWithoutMembers(t *testing.T) {
+	// A first-parent history holds the merge but not the commits it
+	// brought in.
+	prs := GroupPullRequests([]domain.Commit{prHistory()[3]})
+	if assert.Len(t, prs, 1) {
+		assert.Equal(t, 12, prs[0].Number)
+		assert.Empty(t, prs[0].Commits)
+		assert.Equal(t, "Merger", prs[0].Author)
+	}
+}
+
+type fakeForge map[int]*domain.ForgePullRequest
+
+func (f fakeForge) GetPullRequest(ctx context.Context, number int) (*domain.ForgePullRequest, error) {
+	if number == 500 {
+		return nil, errors.New("boom")
+	}
+	pr, ok := f[number]
+	if !ok {
+		return nil, domain.ErrPullRequestNotFound
+	}
+	return pr, nil
+}
+
+func TestPullRequestEnricher_Enrich(t *testing.T) {
+	forge := fakeForge{12: {Number: 12, Title: "Add x", State: domain.PullRequestMerged, Reviewers: []string{"grace"}}}
+	report := &domain.DiffReport{HistoryView: domain.HistoryView{
+		PullRequests: []domain.PullRequest{{Number: 12}, {Number: 13}},
+	}}
+
+	require.NoError(t, NewPullRequestEnricher(forge).Enrich(context.Background(), report))
+	assert.Equal(t, forge[12], report.HistoryView.PullRequests[0].Details)
+	assert.Nil(t, report.HistoryView.PullRequests[1].Details)
+
+	report.HistoryView.PullRequests = append(report.HistoryView.PullRequests, domain.PullRequest{Number: 500})
+	assert.Error(t, NewPullRequestEnricher(forge).Enrich(context.Background(), report))
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+)
+
+// otherLanguage names files whose language is not recognised.
+const otherLanguage = "Other"
+
+// breakdowns groups the kept files by class and by language.
+func breakdowns(files []domain.FileChange) (byClass, byLanguage []domain.BreakdownStats) {
+	totalChurn := 0
+	for _, f := range files {
+		totalChurn += f.Lines.Added + f.Lines.Deleted
+	}
+
+	byClass = make([]domain.BreakdownStats, len(domain.Classes))
+	for i, class := range domain.Classes {
+		byClass[i].Name = class
+		for _, f := range files {
+			if f.Classification.Has(class) {
+				addToBreakdown(&byClass[i], f)
+			}
+		}
+	}
+
+	byLanguage = []domain.BreakdownStats{}
+	index := map[string]int{}
+	for _, f := range files {
+		name := f.Language
+		if name == "" {
+			name = otherLanguage
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(byLanguage)
+			index[name] = i
+			byLanguage = append(byLanguage, domain.BreakdownStats{Name: name})
+		}
+		addToBreakdown(&byLanguage[i], f)
+	}
+	slices.SortFunc(byLanguage, func(a, b domain.BreakdownStats) int {
+		if c := cmp.Compare(b.Added+b.Deleted, a.Added+a.Deleted); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	for _, stats := range [][]domain.BreakdownStats{byClass, byLanguage} {
+		for i := range stats {
+			stats[i].FilesPercent = percent(stats[i].Files, len(files))
+			stats[i].ChurnPercent = percent(stats[i].Added+stats[i].Deleted, totalChurn)
+		}
+	}
+
+	return byClass, byLanguage
+}
+
+func addToBreakdown(stats *domain.BreakdownStats, f domain.FileChange) {
+	stats.Files++
+	stats.Added += f.Lines.Added
+	stats.Deleted += f.Lines.Deleted
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/NERVEbing/supervisor/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakdowns(t *testing.T) {
+	files := []domain.FileChange{
+		{Language: "Go", Lines: domain.FileLineStats{Added: 20, Deleted: 10}},
+		{Language: "Go", Lines: domain.FileLineStats{Added: 60, Deleted: 10}, Classification: domain.Classification{IsTest: true}},
+		{Language: "YAML", Lines: domain.FileLineStats{Added: 0}, Classification: domain.Classification{IsConfig: true}},
+		{Classification: domain.Classification{IsBinary: true}},
+	}
+
+	byClass, byLanguage := breakdowns(files)
+
+	assert.Equal(t, []domain.BreakdownStats{
+		{Name: domain.ClassSource, Files: 1, Added: 20, Deleted: 10, FilesPercent: 25, ChurnPercent: 30},
+		{Name: domain.ClassTest, Files: 1, Added: 60, Deleted: 10, FilesPercent: 25, ChurnPercent: 70},
+		{Name: domain.ClassGenerated},
+		{Name: domain.ClassConfig, Files: 1, FilesPercent: 25},
+		{Name: domain.ClassBinary, Files: 1, FilesPercent: 25},
+		{Name: domain.ClassVendored},
+		{Name: domain.ClassDocumentation},
+	}, byClass)
+
+	assert.Equal(t, []domain.BreakdownStats{
+		{Name: "Go", Files: 2, Added: 80, Deleted: 20, FilesPercent: 50, ChurnPercent: 100},
+		{Name: "Other", Files: 1, FilesPercent: 25},
+		{Name: "YAML", Files: 1, FilesPercent: 25},
+	}, byLanguage)
+}
+
+func TestBreakdowns_Empty(t *testing.T) {
+	byClass, byLanguage := breakdowns(nil)
+
+	assert.Len(t, byClass, len(domain.Classes))
+	for _, c := range byClass {
+		assert.Zero(t, c.FilesPercent)
+		assert.Zero(t, c.ChurnPercent)
+	}
+	assert.Empty(t, byLanguage)
+	assert.NotNil(t, byLanguage)
+}
+
+func TestPercent_RoundsToOneDecimal(t *testing.T) {
+	assert.Equal(t, 33.3, percent(1, 3))
+	assert.Equal(t, 66.7, percent(2, 3))
+	assert.Equal(t, 0.0, percent(5, 0))
+}